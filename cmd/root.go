@@ -2,8 +2,13 @@
 package cmd
 
 import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/lint"
 	"github.com/Layer9Berlin/pipedream/src/logging"
+	"github.com/Layer9Berlin/pipedream/src/lsp"
+	"github.com/Layer9Berlin/pipedream/src/middleware/cache"
 	"github.com/Layer9Berlin/pipedream/src/run"
+	"github.com/Layer9Berlin/pipedream/src/schema"
 	"github.com/Layer9Berlin/pipedream/src/version"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -33,8 +38,98 @@ func init() {
 	RootCmd.PersistentFlags().StringVarP(&run.Verbosity, "verbosity", "v", logrus.InfoLevel.String(), "Log level (debug, info, warn, error, fatal, panic)")
 	RootCmd.PersistentFlags().StringVarP(&run.FileFlag, "file", "f", "", "Path to file containing pipe to execute (default is \"\", ambiguity resolved by user prompt)")
 	RootCmd.PersistentFlags().StringVarP(&run.PipelineFlag, "pipe", "p", "", "Identifier of pipeline to execute (default is \"\", ambiguity resolved by user prompt)")
+	RootCmd.PersistentFlags().StringArrayVar(&run.ArgumentFlags, "arg", nil, "Invocation argument passed to the pipe in the format `key=value`, use dots in the key for nested values (can be repeated)")
+	RootCmd.PersistentFlags().StringVar(&run.ArgumentsFileFlag, "args-file", "", "Path to a yaml file containing invocation arguments passed to the pipe")
+	RootCmd.PersistentFlags().StringArrayVar(&run.ReportFlags, "report", nil, "Write a machine-readable execution report in the format `format=path`, where format is json or junit (can be repeated)")
+	RootCmd.PersistentFlags().IntVar(&run.MaxParallelFlag, "max-parallel", 0, "Maximum number of shell commands executed simultaneously (default is 0, meaning unlimited)")
+	RootCmd.PersistentFlags().BoolVar(&run.DryRunFlag, "dry-run", false, "Print the commands that would be executed instead of executing them (default is false)")
+	RootCmd.PersistentFlags().BoolVar(&run.NoCacheFlag, "no-cache", false, "Execute pipes instead of replaying cached results, updating the cache (default is false)")
 	RootCmd.PersistentFlags().BoolVarP(&run.ShowGraphFlag, "graph", "g", false, "Open a graph in the browser after execution (default is false)")
 
+	RootCmd.AddCommand(&cobra.Command{
+		Use:   "run [file] [pipe]",
+		Short: "Run a pipe without user interaction",
+		Long: `Run the specified pipe in the specified pipeline file.
+No selection prompt will be shown if stdin is not a terminal.
+Exits with a non-zero exit code if any errors occurred or the pipe's shell command failed.`,
+		Args: cobra.MaximumNArgs(2),
+		Run:  run.RunCmd,
+	})
+
+	RootCmd.AddCommand(&cobra.Command{
+		Use:   "lint [files]",
+		Short: "Check pipeline files for problems without executing them",
+		Long: `Check the specified pipeline files (default: all pipeline files in the current working directory) for problems.
+Reports malformed middleware arguments, references to undefined pipes, unknown keys, unresolvable argument references and import cycles.
+Exits with a non-zero exit code if any errors were found.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			exitCode, err := lint.Cmd(cmd.OutOrStdout(), args)
+			if err != nil {
+				run.Log.Fatal(err)
+			}
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+		},
+	})
+
+	RootCmd.AddCommand(&cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema for pipeline files",
+		Long: `Print a JSON Schema describing the structure of pipeline files, including the arguments of all middleware.
+Configure your editor's YAML plugin to use it for files with the .pipe extension to get completion and validation.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := schema.Cmd(cmd.OutOrStdout())
+			if err != nil {
+				run.Log.Fatal(err)
+			}
+		},
+	})
+
+	RootCmd.AddCommand(&cobra.Command{
+		Use:   "lsp",
+		Short: "Start a language server for pipeline files",
+		Long: `Start a language server speaking the Language Server Protocol via stdin and stdout.
+Provides go-to-definition, find-references, hover information and renaming of pipes, as well as the diagnostics of the lint command.
+Meant to be started by an editor with the workspace root as working directory.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// stdout is reserved for protocol messages
+			run.Log.SetOutput(os.Stderr)
+			err := lsp.Cmd(os.Stdin, cmd.OutOrStdout())
+			if err != nil {
+				run.Log.Fatal(err)
+			}
+		},
+	})
+
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage cached pipe results",
+	}
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "clear [directory]",
+		Short: "Remove all cached pipe results",
+		Long: `Remove all results cached by the cache middleware from the specified directory.
+The directory defaults to the default cache directory (.pipedream/cache) within the current working directory.
+Results are cached relative to each pipe's working directory, so caches of pipes using the dir middleware or shell.dir,
+as well as custom cache directories specified via cache.dir, are not affected, unless their path is passed explicitly.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			directory := cache.DefaultDirectory
+			if len(args) > 0 {
+				directory = args[0]
+			}
+			removed, err := cache.Clear(directory)
+			if err != nil {
+				run.Log.Fatal(err)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Removed %v cached results from %v\n", removed, directory)
+		},
+	})
+	RootCmd.AddCommand(cacheCmd)
+
 	RootCmd.AddCommand(&cobra.Command{
 		Use:   "version",
 		Short: "Print the version of the current PipeDream installation",
@@ -1,8 +1,9 @@
 module github.com/Layer9Berlin/pipedream
 
-go 1.15
+go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/ghodss/yaml v1.0.0
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.0
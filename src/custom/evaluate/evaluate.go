@@ -3,26 +3,162 @@ package evaluate
 
 import (
 	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/custom/math"
 	"gopkg.in/Knetic/govaluate.v2"
+	"strings"
 )
 
-// Bool evaluates an expression that is assumed to be boolean
-func Bool(condition string) (bool, error) {
-	expression, err := govaluate.NewEvaluableExpression(condition)
+// Expression is a parsed expression that can be evaluated with different parameters
+type Expression struct {
+	condition  string
+	expression *govaluate.EvaluableExpression
+}
+
+// Parse parses an expression, making the built-in functions available to it
+//
+// Parameter names may contain dots (e.g. `runs.build.exitCode`), other special characters require square brackets.
+// File paths passed to functions are resolved using resolvePath, if provided.
+func Parse(condition string, resolvePath func(string) string) (*Expression, error) {
+	expression, err := govaluate.NewEvaluableExpressionWithFunctions(
+		bracketDottedNames(condition),
+		functions(resolvePath),
+	)
 	if err != nil {
-		return false, fmt.Errorf("error parsing condition %q: %w", condition, err)
+		return nil, fmt.Errorf("error parsing condition %q: %w", condition, err)
 	}
+	return &Expression{
+		condition:  condition,
+		expression: expression,
+	}, nil
+}
+
+// Variables lists the names of the parameters referenced by the expression
+func (expression *Expression) Variables() []string {
+	return expression.expression.Vars()
+}
 
+// Bool evaluates an expression that is assumed to be boolean, using the provided parameters
+func (expression *Expression) Bool(parameters map[string]interface{}) (bool, error) {
 	// use empty map instead of nil to prevent panic for certain inputs
-	evaluationResult, err := expression.Evaluate(make(map[string]interface{}, 0))
+	normalizedParameters := make(map[string]interface{}, len(parameters))
+	for key, value := range parameters {
+		normalizedParameters[key] = normalize(value)
+	}
+	evaluationResult, err := expression.expression.Evaluate(normalizedParameters)
 	if err != nil {
-		return false, fmt.Errorf("error evaluating condition %q: %w", condition, err)
+		return false, fmt.Errorf("error evaluating condition %q: %w", expression.condition, err)
 	}
 
 	evaluatedBoolean, ok := evaluationResult.(bool)
 	if !ok {
-		return false, fmt.Errorf("condition %q does not evaluate to boolean", condition)
+		return false, fmt.Errorf("condition %q does not evaluate to boolean", expression.condition)
 	}
 
 	return evaluatedBoolean, nil
 }
+
+// Bool evaluates an expression that is assumed to be boolean
+func Bool(condition string) (bool, error) {
+	expression, err := Parse(condition, nil)
+	if err != nil {
+		return false, err
+	}
+	return expression.Bool(nil)
+}
+
+// AddParameters makes the values available to expressions by key, nested values additionally by dotted key
+//
+// For example, `{package: {name: test}}` provides the parameters `package` and `package.name`.
+func AddParameters(parameters map[string]interface{}, values map[string]interface{}) {
+	addParameters(parameters, "", values)
+}
+
+func addParameters(parameters map[string]interface{}, prefix string, values map[string]interface{}) {
+	for key, value := range values {
+		parameters[prefix+key] = value
+		if nestedValues, isMap := value.(map[string]interface{}); isMap {
+			addParameters(parameters, prefix+key+".", nestedValues)
+		}
+	}
+}
+
+// normalize converts numbers to float64, as govaluate does not compare other numeric types
+func normalize(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case int:
+		return float64(typedValue)
+	case int64:
+		return float64(typedValue)
+	case float32:
+		return float64(typedValue)
+	case []interface{}:
+		result := make([]interface{}, 0, len(typedValue))
+		for _, item := range typedValue {
+			result = append(result, normalize(item))
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// bracketDottedNames wraps parameter names containing dots in square brackets, as required by govaluate
+func bracketDottedNames(condition string) string {
+	result := strings.Builder{}
+	runes := []rune(condition)
+	for index := 0; index < len(runes); {
+		character := runes[index]
+		switch {
+		case character == '\'' || character == '"' || character == '[':
+			// copy string literals and bracketed names verbatim
+			closingCharacter := character
+			if character == '[' {
+				closingCharacter = ']'
+			}
+			end := index + 1
+			for end < len(runes) && runes[end] != closingCharacter {
+				if runes[end] == '\\' && character != '[' {
+					end++
+				}
+				end++
+			}
+			end = math.MinInt(end+1, len(runes))
+			result.WriteString(string(runes[index:end]))
+			index = end
+		case isNameStart(character):
+			end := index + 1
+			for end < len(runes) && (isNameCharacter(runes[end]) ||
+				(runes[end] == '.' && end+1 < len(runes) && isNameCharacter(runes[end+1]))) {
+				end++
+			}
+			name := string(runes[index:end])
+			if strings.Contains(name, ".") {
+				name = fmt.Sprintf("[%v]", name)
+			}
+			result.WriteString(name)
+			index = end
+		case character >= '0' && character <= '9':
+			// numbers may contain dots, but are not names
+			end := index + 1
+			for end < len(runes) && (isNameCharacter(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			result.WriteString(string(runes[index:end]))
+			index = end
+		default:
+			result.WriteRune(character)
+			index++
+		}
+	}
+	return result.String()
+}
+
+func isNameStart(character rune) bool {
+	return character == '_' ||
+		(character >= 'a' && character <= 'z') ||
+		(character >= 'A' && character <= 'Z')
+}
+
+func isNameCharacter(character rune) bool {
+	return isNameStart(character) || (character >= '0' && character <= '9')
+}
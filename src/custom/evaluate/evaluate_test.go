@@ -28,3 +28,55 @@ func TestBooleanCondition(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, result, true)
 }
+
+func TestConditionWithParameters(t *testing.T) {
+	expression, err := Parse("runs.build.exitCode == 0 && arg == 'quoted' && input != ''", nil)
+	require.Nil(t, err)
+	require.ElementsMatch(t, []string{"runs.build.exitCode", "arg", "input"}, expression.Variables())
+	result, err := expression.Bool(map[string]interface{}{
+		"runs.build.exitCode": 0,
+		"arg":                 "quoted",
+		"input":               "some \"quoted\" 'input'",
+	})
+	require.Nil(t, err)
+	require.True(t, result)
+}
+
+func TestMissingParameter(t *testing.T) {
+	expression, err := Parse("missing == 'value'", nil)
+	require.Nil(t, err)
+	_, err = expression.Bool(nil)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "No parameter 'missing' found")
+}
+
+func TestBracketDottedNames(t *testing.T) {
+	require.Equal(t, "[env.HOME] == 'some.value' && [runs.some-pipe.stdout] != \"a.b\" && 1.5 > 1",
+		bracketDottedNames("env.HOME == 'some.value' && [runs.some-pipe.stdout] != \"a.b\" && 1.5 > 1"))
+	require.Equal(t, "contains([item.name], 'x') && name.", bracketDottedNames("contains(item.name, 'x') && name."))
+	require.Equal(t, "'unterminated.string", bracketDottedNames("'unterminated.string"))
+}
+
+func TestAddParameters(t *testing.T) {
+	parameters := map[string]interface{}{
+		"existing": "value",
+	}
+	AddParameters(parameters, map[string]interface{}{
+		"package": map[string]interface{}{
+			"name": "test",
+			"version": map[string]interface{}{
+				"major": 1,
+			},
+		},
+	})
+	require.Equal(t, "value", parameters["existing"])
+	require.Equal(t, "test", parameters["package.name"])
+	require.Equal(t, 1, parameters["package.version.major"])
+	require.Equal(t, map[string]interface{}{"major": 1}, parameters["package.version"])
+
+	expression, err := Parse("package.name == 'test' && package.version.major == 1", nil)
+	require.Nil(t, err)
+	result, err := expression.Bool(parameters)
+	require.Nil(t, err)
+	require.True(t, result)
+}
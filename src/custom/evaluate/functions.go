@@ -0,0 +1,123 @@
+package evaluate
+
+import (
+	"fmt"
+	"gopkg.in/Knetic/govaluate.v2"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func functions(resolvePath func(string) string) map[string]govaluate.ExpressionFunction {
+	if resolvePath == nil {
+		resolvePath = func(path string) string {
+			return path
+		}
+	}
+	return map[string]govaluate.ExpressionFunction{
+		"contains": func(arguments ...interface{}) (interface{}, error) {
+			if len(arguments) != 2 {
+				return nil, fmt.Errorf("`contains` expects 2 arguments, got %v", len(arguments))
+			}
+			return strings.Contains(fmt.Sprint(arguments[0]), fmt.Sprint(arguments[1])), nil
+		},
+		"matches": func(arguments ...interface{}) (interface{}, error) {
+			if len(arguments) != 2 {
+				return nil, fmt.Errorf("`matches` expects 2 arguments, got %v", len(arguments))
+			}
+			return regexp.MatchString(fmt.Sprint(arguments[1]), fmt.Sprint(arguments[0]))
+		},
+		"semver_gt": func(arguments ...interface{}) (interface{}, error) {
+			if len(arguments) != 2 {
+				return nil, fmt.Errorf("`semver_gt` expects 2 arguments, got %v", len(arguments))
+			}
+			comparison, err := compareVersions(fmt.Sprint(arguments[0]), fmt.Sprint(arguments[1]))
+			return comparison > 0, err
+		},
+		"file_exists": func(arguments ...interface{}) (interface{}, error) {
+			if len(arguments) != 1 {
+				return nil, fmt.Errorf("`file_exists` expects 1 argument, got %v", len(arguments))
+			}
+			_, err := os.Stat(resolvePath(fmt.Sprint(arguments[0])))
+			return err == nil, nil
+		},
+	}
+}
+
+// compareVersions compares two semantic versions, returning a positive number if the first is greater
+//
+// A leading `v` is ignored, as is build metadata. Pre-release versions have lower precedence than releases.
+func compareVersions(first string, second string) (int, error) {
+	firstNumbers, firstPreRelease, err := parseVersion(first)
+	if err != nil {
+		return 0, err
+	}
+	secondNumbers, secondPreRelease, err := parseVersion(second)
+	if err != nil {
+		return 0, err
+	}
+	for index := range firstNumbers {
+		if firstNumbers[index] != secondNumbers[index] {
+			return firstNumbers[index] - secondNumbers[index], nil
+		}
+	}
+	switch {
+	case firstPreRelease == secondPreRelease:
+		return 0, nil
+	case firstPreRelease == "":
+		return 1, nil
+	case secondPreRelease == "":
+		return -1, nil
+	default:
+		return comparePreReleases(firstPreRelease, secondPreRelease), nil
+	}
+}
+
+func parseVersion(version string) ([3]int, string, error) {
+	numbers := [3]int{}
+	trimmedVersion := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	trimmedVersion = strings.SplitN(trimmedVersion, "+", 2)[0]
+	parts := strings.SplitN(trimmedVersion, "-", 2)
+	preRelease := ""
+	if len(parts) == 2 {
+		preRelease = parts[1]
+	}
+	components := strings.Split(parts[0], ".")
+	if len(components) > 3 {
+		return numbers, "", fmt.Errorf("invalid semantic version %q", version)
+	}
+	for index, component := range components {
+		number, err := strconv.Atoi(component)
+		if err != nil || number < 0 {
+			return numbers, "", fmt.Errorf("invalid semantic version %q", version)
+		}
+		numbers[index] = number
+	}
+	return numbers, preRelease, nil
+}
+
+func comparePreReleases(first string, second string) int {
+	firstIdentifiers := strings.Split(first, ".")
+	secondIdentifiers := strings.Split(second, ".")
+	for index := 0; index < len(firstIdentifiers) && index < len(secondIdentifiers); index++ {
+		firstNumber, firstErr := strconv.Atoi(firstIdentifiers[index])
+		secondNumber, secondErr := strconv.Atoi(secondIdentifiers[index])
+		switch {
+		case firstErr == nil && secondErr == nil:
+			if firstNumber != secondNumber {
+				return firstNumber - secondNumber
+			}
+		case firstErr == nil:
+			// numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case secondErr == nil:
+			return 1
+		default:
+			if comparison := strings.Compare(firstIdentifiers[index], secondIdentifiers[index]); comparison != 0 {
+				return comparison
+			}
+		}
+	}
+	return len(firstIdentifiers) - len(secondIdentifiers)
+}
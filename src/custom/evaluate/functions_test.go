@@ -0,0 +1,79 @@
+package evaluate
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFunctions_Contains(t *testing.T) {
+	expression, err := Parse("contains(input, 'needle') && !contains(input, 'thread') && 2 IN list", nil)
+	require.Nil(t, err)
+	result, err := expression.Bool(map[string]interface{}{
+		"input": "haystack with needle",
+		"list":  []interface{}{1, 2, 3},
+	})
+	require.Nil(t, err)
+	require.True(t, result)
+}
+
+func TestFunctions_Matches(t *testing.T) {
+	result, err := Bool("matches('version 1.2.3', '^version \\\\d+') && !matches('other', '^version')")
+	require.Nil(t, err)
+	require.True(t, result)
+
+	_, err = Bool("matches('value', '(')")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "error parsing regexp")
+}
+
+func TestFunctions_SemverGt(t *testing.T) {
+	for _, versions := range [][2]string{
+		{"1.2.4", "1.2.3"},
+		{"v2.0.0", "1.10.0"},
+		{"1.10.0", "1.9.0"},
+		{"1.0.0", "1.0.0-rc.1"},
+		{"1.0.0-rc.2", "1.0.0-rc.1"},
+		{"1.0.0-beta", "1.0.0-alpha.1"},
+		{"1.0.0-alpha.1", "1.0.0-alpha"},
+		{"1.1", "1.0.9"},
+	} {
+		result, err := compareVersions(versions[0], versions[1])
+		require.Nil(t, err)
+		require.Greater(t, result, 0, versions)
+		result, err = compareVersions(versions[1], versions[0])
+		require.Nil(t, err)
+		require.Less(t, result, 0, versions)
+	}
+
+	result, err := Bool("semver_gt('1.0.0+build.1', 'v1.0.0')")
+	require.Nil(t, err)
+	require.False(t, result)
+
+	_, err = Bool("semver_gt('latest', '1.0.0')")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid semantic version \"latest\"")
+}
+
+func TestFunctions_FileExists(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-evaluate-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "existing.txt"), []byte("test"), 0644))
+
+	expression, err := Parse("file_exists('existing.txt') && !file_exists('missing.txt')", func(path string) string {
+		return filepath.Join(directory, path)
+	})
+	require.Nil(t, err)
+	result, err := expression.Bool(nil)
+	require.Nil(t, err)
+	require.True(t, result)
+}
+
+func TestFunctions_WrongNumberOfArguments(t *testing.T) {
+	_, err := Bool("contains('value')")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "`contains` expects 2 arguments, got 1")
+}
@@ -0,0 +1,59 @@
+package io
+
+import (
+	"bytes"
+	systemio "io"
+	"sync"
+)
+
+// BufferedPipe is an in-memory pipe whose writes never block
+//
+// Data written to the pipe is buffered until it is read.
+// Reads block until data is available or the pipe has been closed, after which they return io.EOF.
+type BufferedPipe struct {
+	buffer    *bytes.Buffer
+	closed    bool
+	condition *sync.Cond
+}
+
+// NewBufferedPipe creates a new, empty BufferedPipe
+func NewBufferedPipe() *BufferedPipe {
+	return &BufferedPipe{
+		buffer:    new(bytes.Buffer),
+		closed:    false,
+		condition: sync.NewCond(&sync.Mutex{}),
+	}
+}
+
+// Read reads up to len(p) bytes into p, blocking until data is available or the pipe has been closed
+func (pipe *BufferedPipe) Read(p []byte) (int, error) {
+	pipe.condition.L.Lock()
+	defer pipe.condition.L.Unlock()
+	for pipe.buffer.Len() == 0 && !pipe.closed {
+		pipe.condition.Wait()
+	}
+	if pipe.buffer.Len() == 0 {
+		return 0, systemio.EOF
+	}
+	return pipe.buffer.Read(p)
+}
+
+// Write appends the data to the pipe's buffer, failing only if the pipe has been closed
+func (pipe *BufferedPipe) Write(p []byte) (int, error) {
+	pipe.condition.L.Lock()
+	defer pipe.condition.L.Unlock()
+	if pipe.closed {
+		return 0, systemio.ErrClosedPipe
+	}
+	defer pipe.condition.Broadcast()
+	return pipe.buffer.Write(p)
+}
+
+// Close marks the end of the data, so that reads return io.EOF once the remaining data has been read
+func (pipe *BufferedPipe) Close() error {
+	pipe.condition.L.Lock()
+	defer pipe.condition.L.Unlock()
+	pipe.closed = true
+	pipe.condition.Broadcast()
+	return nil
+}
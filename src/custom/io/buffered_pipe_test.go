@@ -0,0 +1,49 @@
+package io
+
+import (
+	"github.com/stretchr/testify/require"
+	systemio "io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestBufferedPipe(t *testing.T) {
+	pipe := NewBufferedPipe()
+	// writes do not block, even if nothing is read
+	for i := 0; i < 100; i++ {
+		_, err := pipe.Write([]byte("test"))
+		require.Nil(t, err)
+	}
+	buffer := make([]byte, 8)
+	n, err := pipe.Read(buffer)
+	require.Nil(t, err)
+	require.Equal(t, "testtest", string(buffer[:n]))
+
+	resultChannel := make(chan string)
+	go func() {
+		result, _ := ioutil.ReadAll(pipe)
+		resultChannel <- string(result)
+	}()
+	_, _ = pipe.Write([]byte("end"))
+	require.Nil(t, pipe.Close())
+	require.Equal(t, strings.Repeat("test", 98)+"end", <-resultChannel)
+
+	_, err = pipe.Write([]byte("test"))
+	require.Equal(t, systemio.ErrClosedPipe, err)
+	n, err = pipe.Read(buffer)
+	require.Equal(t, 0, n)
+	require.Equal(t, systemio.EOF, err)
+}
+
+func TestBufferedPipe_ReadBlocksUntilWrite(t *testing.T) {
+	pipe := NewBufferedPipe()
+	resultChannel := make(chan string)
+	go func() {
+		buffer := make([]byte, 8)
+		n, _ := pipe.Read(buffer)
+		resultChannel <- string(buffer[:n])
+	}()
+	_, _ = pipe.Write([]byte("test"))
+	require.Equal(t, "test", <-resultChannel)
+}
@@ -0,0 +1,19 @@
+package strings
+
+import (
+	nativestrings "strings"
+)
+
+// NonEmptyLines splits a string into lines, skipping those that contain only whitespace
+//
+// Both `\n` and `\r\n` line endings are supported.
+func NonEmptyLines(value string) []string {
+	lines := nativestrings.Split(nativestrings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if nativestrings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
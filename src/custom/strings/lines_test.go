@@ -0,0 +1,11 @@
+package strings
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStrings_NonEmptyLines(t *testing.T) {
+	require.Equal(t, []string{"first", " second", "third"}, NonEmptyLines("first\r\n second\n\n  \nthird\n"))
+	require.Equal(t, []string{}, NonEmptyLines(""))
+}
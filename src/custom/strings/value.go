@@ -0,0 +1,27 @@
+package strings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// FromValue converts a value to a string, using compact json for maps and lists
+func FromValue(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(typedValue, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		result, err := json.Marshal(typedValue)
+		if err != nil {
+			return fmt.Sprint(typedValue)
+		}
+		return string(result)
+	default:
+		return fmt.Sprint(typedValue)
+	}
+}
@@ -0,0 +1,17 @@
+package strings
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestStrings_FromValue(t *testing.T) {
+	require.Equal(t, "test", FromValue("test"))
+	require.Equal(t, "", FromValue(nil))
+	require.Equal(t, "1000000", FromValue(1e6))
+	require.Equal(t, "0.5", FromValue(0.5))
+	require.Equal(t, "3", FromValue(3))
+	require.Equal(t, "true", FromValue(true))
+	require.Equal(t, `{"name":"a","version":1}`, FromValue(map[string]interface{}{"version": 1, "name": "a"}))
+	require.Equal(t, `["a",2]`, FromValue([]interface{}{"a", 2}))
+}
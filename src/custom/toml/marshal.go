@@ -0,0 +1,48 @@
+package toml
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"strings"
+)
+
+// Marshal writes a map as TOML data, with the keys of each table in alphabetical order
+//
+// Nested maps are written as tables, lists as arrays. As TOML has no null value, nil values are omitted.
+func Marshal(values map[string]interface{}) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	encoder := toml.NewEncoder(buffer)
+	encoder.Indent = ""
+	err := encoder.Encode(values)
+	return buffer.Bytes(), err
+}
+
+// MarshalOrdered writes a map as TOML data, with the top-level keys in the specified order
+//
+// The TOML encoder always sorts map keys, so each top-level key is encoded separately.
+// Plain values are written first, as any value following a table header would belong to that table.
+// Keys of nested tables are written in alphabetical order.
+func MarshalOrdered(keys []string, values map[string]interface{}) ([]byte, error) {
+	plainValues := new(bytes.Buffer)
+	tables := new(bytes.Buffer)
+	for _, key := range keys {
+		result, err := Marshal(map[string]interface{}{key: values[key]})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write value for key `%v`: %w", key, err)
+		}
+		if !strings.HasPrefix(string(result), "[") {
+			plainValues.Write(result)
+			continue
+		}
+		if tables.Len() > 0 {
+			tables.WriteString("\n")
+		}
+		tables.Write(result)
+	}
+	if plainValues.Len() > 0 && tables.Len() > 0 {
+		plainValues.WriteString("\n")
+	}
+	plainValues.Write(tables.Bytes())
+	return plainValues.Bytes(), nil
+}
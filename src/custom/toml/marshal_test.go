@@ -0,0 +1,75 @@
+package toml
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestToml_Marshal(t *testing.T) {
+	values := map[string]interface{}{
+		"title":   "line\nbreak \"quoted\"",
+		"enabled": true,
+		"count":   3.0,
+		"ratio":   0.5,
+		"missing": nil,
+		"dotted.key": map[string]interface{}{
+			"b": 2,
+			"a": "first",
+			"nested": map[string]interface{}{
+				"list": []interface{}{1, "two", map[string]interface{}{"three": 3}},
+			},
+		},
+		"empty": map[string]interface{}{},
+	}
+	result, err := Marshal(values)
+	require.Nil(t, err)
+	require.Equal(t, `count = 3.0
+enabled = true
+ratio = 0.5
+title = "line\nbreak \"quoted\""
+
+["dotted.key"]
+a = "first"
+b = 2
+["dotted.key".nested]
+list = [1, "two", {three = 3}]
+
+[empty]
+`, string(result))
+
+	parsedValues, err := Unmarshal(result)
+	require.Nil(t, err)
+	require.Equal(t, "line\nbreak \"quoted\"", parsedValues["title"])
+	require.Equal(t, []interface{}{int64(1), "two", map[string]interface{}{"three": int64(3)}},
+		parsedValues["dotted.key"].(map[string]interface{})["nested"].(map[string]interface{})["list"])
+}
+
+func TestToml_MarshalOrdered(t *testing.T) {
+	result, err := MarshalOrdered([]string{"second", "first", "~"}, map[string]interface{}{
+		"first":  "1",
+		"second": "2",
+		"~":      "\u0001",
+	})
+	require.Nil(t, err)
+	require.Equal(t, "second = \"2\"\nfirst = \"1\"\n\"~\" = \"\\u0001\"\n", string(result))
+}
+
+func TestToml_MarshalOrderedTables(t *testing.T) {
+	result, err := MarshalOrdered([]string{"table", "value", "another"}, map[string]interface{}{
+		"table": map[string]interface{}{
+			"key": "value",
+		},
+		"value":   1,
+		"another": map[string]interface{}{},
+	})
+	require.Nil(t, err)
+	require.Equal(t, "value = 1\n\n[table]\nkey = \"value\"\n\n[another]\n", string(result))
+}
+
+func TestToml_MarshalUnsupportedType(t *testing.T) {
+	_, err := MarshalOrdered([]string{"key"}, map[string]interface{}{
+		"key": func() {},
+	})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "failed to write value for key `key`: unsupported type: func")
+}
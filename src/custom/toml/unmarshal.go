@@ -0,0 +1,42 @@
+// Package toml provides functions for reading and writing TOML data
+package toml
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+// Unmarshal reads TOML data into a map
+//
+// Integers are read as int64 and dates as time.Time.
+// Arrays of tables are returned as []interface{}, like any other array.
+func Unmarshal(data []byte) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, 10)
+	err := toml.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeTables(result).(map[string]interface{}), nil
+}
+
+func normalizeTables(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, nestedValue := range typedValue {
+			typedValue[key] = normalizeTables(nestedValue)
+		}
+		return typedValue
+	case []map[string]interface{}:
+		result := make([]interface{}, 0, len(typedValue))
+		for _, table := range typedValue {
+			result = append(result, normalizeTables(table))
+		}
+		return result
+	case []interface{}:
+		for index, item := range typedValue {
+			typedValue[index] = normalizeTables(item)
+		}
+		return typedValue
+	default:
+		return value
+	}
+}
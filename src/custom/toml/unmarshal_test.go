@@ -0,0 +1,106 @@
+package toml
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestToml_Unmarshal(t *testing.T) {
+	result, err := Unmarshal([]byte(`# comment
+title = "TOML \"example\""
+literal = 'C:\path'
+enabled = true
+count = 1_000
+ratio = 0.5
+hex = 0xff
+date = 1979-05-27
+datetime = 1979-05-27 07:32:00Z
+description = """
+first line
+second line"""
+site."google.com" = true
+
+[package]
+name = "pipedream" # comment
+authors = [
+	"first",
+	"second", # trailing comma
+]
+metadata = { license = "MIT", nested = { level = 2 } }
+
+[dependencies.serde]
+version = "1.0"
+
+[[bin]]
+name = "first"
+
+[[bin]]
+name = "second"
+
+[bin.settings]
+debug = false
+`))
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"title":       "TOML \"example\"",
+		"literal":     "C:\\path",
+		"enabled":     true,
+		"count":       int64(1000),
+		"ratio":       0.5,
+		"hex":         int64(255),
+		"date":        time.Date(1979, 5, 27, 0, 0, 0, 0, time.FixedZone("date-local", 0)),
+		"datetime":    time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"description": "first line\nsecond line",
+		"site": map[string]interface{}{
+			"google.com": true,
+		},
+		"package": map[string]interface{}{
+			"name":    "pipedream",
+			"authors": []interface{}{"first", "second"},
+			"metadata": map[string]interface{}{
+				"license": "MIT",
+				"nested": map[string]interface{}{
+					"level": int64(2),
+				},
+			},
+		},
+		"dependencies": map[string]interface{}{
+			"serde": map[string]interface{}{
+				"version": "1.0",
+			},
+		},
+		"bin": []interface{}{
+			map[string]interface{}{
+				"name": "first",
+			},
+			map[string]interface{}{
+				"name": "second",
+				"settings": map[string]interface{}{
+					"debug": false,
+				},
+			},
+		},
+	}, result)
+}
+
+func TestToml_UnmarshalErrors(t *testing.T) {
+	for name, input := range map[string]string{
+		"missing equals":     "key value",
+		"missing value":      "key =",
+		"invalid value":      "key = invalid",
+		"unclosed string":    "key = \"value",
+		"unclosed array":     "key = [1, 2",
+		"trailing content":   "key = 1 2",
+		"not a table":        "key = 1\n[key]",
+		"not an array":       "[key]\n[[key]]",
+		"unclosed table":     "[table",
+		"unclosed inline":    "key = { a = 1",
+		"multi-line literal": "key = 'value\n'",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(input))
+			require.NotNil(t, err)
+		})
+	}
+}
@@ -25,7 +25,7 @@ func graphTemplate() string {
 <div id="run_graph"></div>
 <script type="text/javascript">
     const nodes = new vis.DataSet([
-        {{ range .UserRuns }}{id: {{.Id}}, label: {{ .GraphLabel }}, group: {{ .GraphGroup }} },
+        {{ range .UserRuns }}{id: {{.Id}}, label: {{ .GraphLabel }}, group: {{ .GraphGroup }}{{ with .GraphTooltip }}, title: {{ . }}{{ end }} },
 		{{ end }}]);
 
     const edges = new vis.DataSet([
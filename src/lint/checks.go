@@ -0,0 +1,520 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var fileKeys = []string{"default", "hooks", "import", "private", "public", "version"}
+
+// onError hooks receive these arguments in addition to the ones they are invoked with
+var errorHookKeys = []string{"error", "exitCode", "failedPipe"}
+
+var referenceType = reflect.TypeOf(pipeline.Reference{})
+
+// same as the interpolate middleware's pattern for argument references
+var argumentReferenceRegex = regexp.MustCompile("@{([0-9a-zA-Z\\-_.:/\\\\]*)( *\\| *([0-9a-zA-Z-_.:'\"]*))?}")
+
+type scope map[string]bool
+
+func (currentScope scope) with(keys ...string) scope {
+	result := make(scope, len(currentScope)+len(keys))
+	for key := range currentScope {
+		result[key] = true
+	}
+	for _, key := range keys {
+		result[key] = true
+	}
+	return result
+}
+
+func (currentScope scope) contains(key string) bool {
+	// nested values might be provided as a map further up
+	components := strings.Split(key, ".")
+	for index := range components {
+		if currentScope[strings.Join(components[:index+1], ".")] {
+			return true
+		}
+	}
+	return false
+}
+
+type linting struct {
+	callSites         map[string]scope
+	collecting        bool
+	definitions       pipeline.DefinitionsLookup
+	definitionSymbols []Symbol
+	describers        map[string]middleware.ArgumentsDescriber
+	fileOrder         []string
+	files             map[string]*pipelineFile
+	issues            []Issue
+	keys              []string
+	muted             bool
+	readFile          func(filename string) ([]byte, error)
+	referenceSymbols  []Symbol
+}
+
+func newLinting(middlewareStack []middleware.Middleware, readFile func(filename string) ([]byte, error)) *linting {
+	state := &linting{
+		callSites:         make(map[string]scope, 32),
+		definitions:       pipeline.DefinitionsLookup{},
+		definitionSymbols: make([]Symbol, 0, 32),
+		describers:        make(map[string]middleware.ArgumentsDescriber, len(middlewareStack)),
+		files:             make(map[string]*pipelineFile, 8),
+		issues:            make([]Issue, 0, 8),
+		readFile:          readFile,
+		referenceSymbols:  make([]Symbol, 0, 32),
+	}
+	for _, middlewareItem := range middlewareStack {
+		if describer, ok := middlewareItem.(middleware.ArgumentsDescriber); ok {
+			for key := range describer.Arguments() {
+				state.describers[key] = describer
+				state.keys = append(state.keys, key)
+			}
+		}
+	}
+	sort.Strings(state.keys)
+	return state
+}
+
+func (state *linting) addIssue(issue Issue) {
+	if !state.collecting && !state.muted {
+		state.issues = append(state.issues, issue)
+	}
+}
+
+func (state *linting) report(file *pipelineFile, node *yaml.Node, severity Severity, format string, values ...interface{}) {
+	state.addIssue(Issue{
+		Column:   node.Column,
+		Line:     node.Line,
+		Message:  fmt.Sprintf(format, values...),
+		Path:     file.path,
+		Severity: severity,
+	})
+}
+
+func (state *linting) lintFile(file *pipelineFile) {
+	if file.root == nil {
+		return
+	}
+	forEachPair(file.root, func(keyNode *yaml.Node, valueNode *yaml.Node) {
+		switch keyNode.Value {
+		case "default":
+			commandNode := mappingValue(valueNode, "command")
+			if commandNode != nil && commandNode.Kind == yaml.ScalarNode && commandNode.Value != "" {
+				state.checkReference(file, commandNode, &commandNode.Value, nil, scope{}, true)
+			}
+		case "hooks":
+			forEachPair(valueNode, func(hookKeyNode *yaml.Node, hookValueNode *yaml.Node) {
+				hookScope := scope{}
+				if hookKeyNode.Value == "onError" {
+					hookScope = hookScope.with(errorHookKeys...)
+				}
+				state.lintReferences(file, reflect.TypeOf([]pipeline.Reference{}), hookValueNode, hookScope, true)
+			})
+		case "private", "public":
+			isPublic := keyNode.Value == "public"
+			forEachPair(valueNode, func(identifierNode *yaml.Node, definitionNode *yaml.Node) {
+				if !state.collecting {
+					state.definitionSymbols = append(state.definitionSymbols, newSymbol(file.path, identifierNode, nil, isPublic))
+				}
+				definitionScope := state.bodyScope(definitionNode, state.callSites[identifierNode.Value])
+				state.lintBody(file, definitionNode, definitionScope, true)
+			})
+		case "import", "version", "<<":
+		default:
+			if suggestion := closestKey(keyNode.Value, fileKeys); suggestion != "" {
+				state.report(file, keyNode, SeverityError, "unknown file-level key %q, did you mean %q?", keyNode.Value, suggestion)
+			} else {
+				state.report(file, keyNode, SeverityError, "unknown file-level key %q, expected one of %v", keyNode.Value, strings.Join(fileKeys, ", "))
+			}
+		}
+	})
+}
+
+// lintBody checks a map of pipe arguments, as found in definitions and inline invocations
+func (state *linting) lintBody(file *pipelineFile, bodyNode *yaml.Node, bodyScope scope, checkInterpolation bool) {
+	bodyNode = resolveAlias(bodyNode)
+	if isNull(bodyNode) {
+		return
+	}
+	if bodyNode.Kind != yaml.MappingNode {
+		state.report(file, bodyNode, SeverityError, "expected a mapping of pipe arguments")
+		return
+	}
+	checkInterpolation = checkInterpolation && interpolationWarningsEnabled(bodyNode)
+	forEachPair(bodyNode, func(keyNode *yaml.Node, valueNode *yaml.Node) {
+		if keyNode.Value == "<<" {
+			return
+		}
+		describer, isMiddlewareKey := state.describers[keyNode.Value]
+		if !isMiddlewareKey {
+			if suggestion := closestKey(keyNode.Value, state.keys); suggestion != "" {
+				state.report(file, keyNode, SeverityWarning, "%q is not interpreted by any middleware, did you mean %q?", keyNode.Value, suggestion)
+			}
+			state.checkArgumentReferences(file, valueNode, bodyScope, checkInterpolation, nil)
+			return
+		}
+		shapeType, err := decodeShape(describer.Arguments()[keyNode.Value], valueNode)
+		if err != nil {
+			state.report(file, valueNode, SeverityError, "malformed arguments for %q: %v", keyNode.Value, err)
+			return
+		}
+		nestedBodies := state.lintReferences(file, shapeType, valueNode, bodyScope, checkInterpolation)
+		state.checkArgumentReferences(file, valueNode, bodyScope, checkInterpolation, nestedBodies)
+	})
+}
+
+// lintReferences checks all pipeline references contained in an argument value, returning the nodes of their inline arguments
+func (state *linting) lintReferences(
+	file *pipelineFile,
+	shapeType reflect.Type,
+	valueNode *yaml.Node,
+	referenceScope scope,
+	checkInterpolation bool,
+) map[*yaml.Node]bool {
+	nestedBodies := make(map[*yaml.Node]bool, 4)
+	walkReferences(shapeType, valueNode, func(identifierNode *yaml.Node, identifier *string, argumentsNode *yaml.Node) {
+		if argumentsNode != nil {
+			nestedBodies[argumentsNode] = true
+		}
+		state.checkReference(file, identifierNode, identifier, argumentsNode, referenceScope, checkInterpolation)
+	})
+	return nestedBodies
+}
+
+func (state *linting) checkReference(
+	file *pipelineFile,
+	identifierNode *yaml.Node,
+	identifier *string,
+	argumentsNode *yaml.Node,
+	referenceScope scope,
+	checkInterpolation bool,
+) {
+	// inline arguments are interpolated using the invoking pipe's arguments first
+	nestedScope := state.bodyScope(argumentsNode, referenceScope)
+	// identifiers containing interpolation directives can only be resolved at runtime
+	if identifier != nil && !strings.Contains(*identifier, "@") {
+		if state.collecting {
+			state.callSites[*identifier] = nestedScope.with(keysOf(state.callSites[*identifier])...)
+		} else {
+			state.referenceSymbols = append(state.referenceSymbols, newSymbol(file.path, identifierNode, argumentsNode, false))
+			_, found := middleware.LookUpPipelineDefinition(state.definitions, *identifier, file.fileName)
+			// inline invocations may define a named pipe on the spot,
+			// but without any middleware arguments such an invocation would not do anything
+			if !found && !state.hasMiddlewareKey(argumentsNode) {
+				state.report(file, identifierNode, SeverityError, "unable to find pipe %q", *identifier)
+			}
+		}
+	}
+	if argumentsNode != nil {
+		state.lintBody(file, argumentsNode, nestedScope, checkInterpolation)
+	}
+}
+
+func (state *linting) hasMiddlewareKey(bodyNode *yaml.Node) bool {
+	result := false
+	forEachPair(bodyNode, func(keyNode *yaml.Node, _ *yaml.Node) {
+		if _, isMiddlewareKey := state.describers[keyNode.Value]; isMiddlewareKey {
+			result = true
+		}
+	})
+	return result
+}
+
+// checkArgumentReferences reports `@{...}` references without default value that cannot be resolved
+func (state *linting) checkArgumentReferences(
+	file *pipelineFile,
+	node *yaml.Node,
+	availableKeys scope,
+	checkInterpolation bool,
+	skippedNodes map[*yaml.Node]bool,
+) {
+	if !checkInterpolation || node == nil || skippedNodes[node] {
+		return
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		for _, match := range argumentReferenceRegex.FindAllStringSubmatch(node.Value, -1) {
+			if match[2] == "" && !availableKeys.contains(match[1]) {
+				state.report(file, node, SeverityWarning, "unable to find value for argument `%v`", match[1])
+			}
+		}
+	case yaml.MappingNode, yaml.SequenceNode, yaml.DocumentNode:
+		for _, childNode := range node.Content {
+			state.checkArgumentReferences(file, childNode, availableKeys, checkInterpolation, skippedNodes)
+		}
+	}
+}
+
+// bodyScope returns the argument keys available within a map of pipe arguments
+func (state *linting) bodyScope(bodyNode *yaml.Node, outerScope scope) scope {
+	bodyNode = resolveAlias(bodyNode)
+	result := outerScope.with()
+	if bodyNode == nil || bodyNode.Kind != yaml.MappingNode {
+		return result
+	}
+	forEachPair(bodyNode, func(keyNode *yaml.Node, valueNode *yaml.Node) {
+		result[keyNode.Value] = true
+		for _, key := range providedKeys(keyNode.Value, resolveAlias(valueNode)) {
+			result[key] = true
+		}
+	})
+	return result
+}
+
+// providedKeys lists the argument keys that a middleware sets based on its arguments
+func providedKeys(middlewareKey string, valueNode *yaml.Node) []string {
+	keys := make([]string, 0, 4)
+	switch middlewareKey {
+	case "extract":
+		for _, mapKey := range []string{"defaults", "values"} {
+			forEachPair(mappingValue(valueNode, mapKey), func(keyNode *yaml.Node, _ *yaml.Node) {
+				keys = append(keys, keyNode.Value)
+			})
+		}
+	case "foreach":
+		for mapKey, defaultValue := range map[string]string{"index": "index", "item": "item"} {
+			if keyNode := mappingValue(valueNode, mapKey); keyNode != nil && keyNode.Kind == yaml.ScalarNode {
+				keys = append(keys, keyNode.Value)
+			} else {
+				keys = append(keys, defaultValue)
+			}
+		}
+	case "inherit":
+		if valueNode != nil && valueNode.Kind == yaml.SequenceNode {
+			for _, itemNode := range valueNode.Content {
+				keys = append(keys, itemNode.Value)
+			}
+		}
+	}
+	return keys
+}
+
+func interpolationWarningsEnabled(bodyNode *yaml.Node) bool {
+	interpolateNode := mappingValue(bodyNode, "interpolate")
+	enable := true
+	ignoreWarnings := false
+	if enableNode := mappingValue(interpolateNode, "enable"); enableNode != nil {
+		_ = enableNode.Decode(&enable)
+	}
+	if ignoreWarningsNode := mappingValue(interpolateNode, "ignoreWarnings"); ignoreWarningsNode != nil {
+		_ = ignoreWarningsNode.Decode(&ignoreWarnings)
+	}
+	return enable && !ignoreWarnings
+}
+
+// decodeShape decodes the value into the first matching shape, returning its type
+func decodeShape(shapes []interface{}, valueNode *yaml.Node) (reflect.Type, error) {
+	var value interface{}
+	err := valueNode.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	var shapeErr error
+	for _, shape := range shapes {
+		err := pipeline.DecodeArguments(value, shape)
+		if err == nil {
+			return reflect.TypeOf(shape), nil
+		}
+		// report the error of the shape the value was most likely intended to have
+		if shapeErr == nil || shapeKindMatches(reflect.TypeOf(shape), valueNode) {
+			shapeErr = err
+		}
+	}
+	return nil, simplifyDecodeError(shapeErr)
+}
+
+func shapeKindMatches(shapeType reflect.Type, valueNode *yaml.Node) bool {
+	for shapeType.Kind() == reflect.Ptr {
+		shapeType = shapeType.Elem()
+	}
+	switch shapeType.Kind() {
+	case reflect.Slice, reflect.Array:
+		return valueNode.Kind == yaml.SequenceNode
+	case reflect.Struct, reflect.Map:
+		return valueNode.Kind == yaml.MappingNode
+	default:
+		return valueNode.Kind == yaml.ScalarNode
+	}
+}
+
+func simplifyDecodeError(err error) error {
+	messages := []string{err.Error()}
+	var decodeError *mapstructure.Error
+	if errors.As(err, &decodeError) {
+		messages = decodeError.Errors
+	}
+	simplifiedMessages := make([]string, 0, len(messages))
+	for _, message := range messages {
+		// errors concerning the argument value itself refer to it by an empty name
+		message = strings.Replace(message, "'' has invalid keys:", "unknown keys:", 1)
+		simplifiedMessages = append(simplifiedMessages, strings.TrimPrefix(message, "'' "))
+	}
+	return errors.New(strings.Join(simplifiedMessages, "; "))
+}
+
+// walkReferences visits all pipeline references in a value node, using the shape type to locate them
+func walkReferences(
+	shapeType reflect.Type,
+	node *yaml.Node,
+	visit func(identifierNode *yaml.Node, identifier *string, argumentsNode *yaml.Node),
+) {
+	node = resolveAlias(node)
+	if node == nil {
+		return
+	}
+	if shapeType == referenceType {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			if !isNull(node) {
+				identifier := node.Value
+				visit(node, &identifier, nil)
+			}
+		case yaml.MappingNode:
+			if len(node.Content) == 2 {
+				identifierNode := node.Content[0]
+				if isNull(identifierNode) {
+					// anonymous pipeline
+					visit(identifierNode, nil, node.Content[1])
+				} else {
+					identifier := identifierNode.Value
+					visit(identifierNode, &identifier, node.Content[1])
+				}
+			}
+		}
+		return
+	}
+	switch shapeType.Kind() {
+	case reflect.Ptr:
+		walkReferences(shapeType.Elem(), node, visit)
+	case reflect.Slice, reflect.Array:
+		if node.Kind == yaml.SequenceNode {
+			for _, itemNode := range node.Content {
+				walkReferences(shapeType.Elem(), itemNode, visit)
+			}
+		}
+	case reflect.Struct:
+		forEachPair(node, func(keyNode *yaml.Node, valueNode *yaml.Node) {
+			field, ok := shapeType.FieldByNameFunc(func(fieldName string) bool {
+				return strings.EqualFold(fieldName, keyNode.Value)
+			})
+			if ok {
+				walkReferences(field.Type, valueNode, visit)
+			}
+		})
+	case reflect.Map:
+		forEachPair(node, func(_ *yaml.Node, valueNode *yaml.Node) {
+			walkReferences(shapeType.Elem(), valueNode, visit)
+		})
+	}
+}
+
+// closestKey returns the key the given one was most likely meant to be, if any
+func closestKey(key string, knownKeys []string) string {
+	for _, knownKey := range knownKeys {
+		if strings.EqualFold(key, knownKey) {
+			return knownKey
+		}
+	}
+	if len(key) < 4 {
+		return ""
+	}
+	for _, knownKey := range knownKeys {
+		if editDistance(key, knownKey) == 1 {
+			return knownKey
+		}
+	}
+	return ""
+}
+
+// editDistance counts the insertions, deletions, substitutions and transpositions needed to turn one string into the other
+func editDistance(first string, second string) int {
+	distances := make([][]int, len(first)+1)
+	for firstIndex := range distances {
+		distances[firstIndex] = make([]int, len(second)+1)
+		distances[firstIndex][0] = firstIndex
+	}
+	for secondIndex := range distances[0] {
+		distances[0][secondIndex] = secondIndex
+	}
+	for firstIndex := 1; firstIndex <= len(first); firstIndex++ {
+		for secondIndex := 1; secondIndex <= len(second); secondIndex++ {
+			substitutionCost := 1
+			if first[firstIndex-1] == second[secondIndex-1] {
+				substitutionCost = 0
+			}
+			distances[firstIndex][secondIndex] = minimum(
+				distances[firstIndex-1][secondIndex]+1,
+				distances[firstIndex][secondIndex-1]+1,
+				distances[firstIndex-1][secondIndex-1]+substitutionCost,
+			)
+			if firstIndex > 1 && secondIndex > 1 &&
+				first[firstIndex-1] == second[secondIndex-2] &&
+				first[firstIndex-2] == second[secondIndex-1] {
+				distances[firstIndex][secondIndex] = minimum(
+					distances[firstIndex][secondIndex],
+					distances[firstIndex-2][secondIndex-2]+1,
+				)
+			}
+		}
+	}
+	return distances[len(first)][len(second)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+func keysOf(currentScope scope) []string {
+	keys := make([]string, 0, len(currentScope))
+	for key := range currentScope {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func forEachPair(node *yaml.Node, callback func(keyNode *yaml.Node, valueNode *yaml.Node)) {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		callback(node.Content[index], resolveAlias(node.Content[index+1]))
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	var result *yaml.Node = nil
+	forEachPair(node, func(keyNode *yaml.Node, valueNode *yaml.Node) {
+		if keyNode.Value == key {
+			result = valueNode
+		}
+	})
+	return result
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func isNull(node *yaml.Node) bool {
+	return node == nil || (node.Kind == yaml.ScalarNode && node.Tag == "!!null")
+}
@@ -0,0 +1,177 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type pipelineFile struct {
+	fileName string
+	imports  []importDeclaration
+	path     string
+	root     *yaml.Node
+}
+
+type importDeclaration struct {
+	node *yaml.Node
+	path string
+}
+
+var yamlErrorLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// loadFiles reads and parses the specified files as well as all files they import
+//
+// Files that cannot be parsed are reported as issues and excluded from further checks.
+func (state *linting) loadFiles(filePaths []string) error {
+	type pendingFile struct {
+		importedBy *pipelineFile
+		node       *yaml.Node
+		path       string
+	}
+	queue := make([]pendingFile, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		queue = append(queue, pendingFile{path: filePath})
+	}
+	visited := make(map[string]bool, len(filePaths))
+	for len(queue) > 0 {
+		pending := queue[0]
+		queue = queue[1:]
+		if visited[pending.path] {
+			continue
+		}
+		visited[pending.path] = true
+
+		fileData, err := state.readFile(pending.path)
+		if err != nil {
+			if pending.importedBy == nil {
+				return err
+			}
+			state.report(pending.importedBy, pending.node, SeverityError, "unable to read imported file %q", pending.path)
+			continue
+		}
+		file := &pipelineFile{
+			fileName: filepath.Base(pending.path),
+			path:     pending.path,
+		}
+		if !state.parseFile(file, fileData) {
+			continue
+		}
+		state.files[file.path] = file
+		state.fileOrder = append(state.fileOrder, file.path)
+		for _, declaration := range file.imports {
+			queue = append(queue, pendingFile{
+				importedBy: file,
+				node:       declaration.node,
+				path:       declaration.path,
+			})
+		}
+	}
+	return nil
+}
+
+func (state *linting) parseFile(file *pipelineFile, fileData []byte) bool {
+	document := yaml.Node{}
+	err := yaml.Unmarshal(fileData, &document)
+	if err != nil {
+		state.reportYamlError(file, err)
+		return false
+	}
+	err = document.Decode(&pipeline.File{})
+	if err != nil {
+		state.reportYamlError(file, err)
+		return false
+	}
+	if len(document.Content) == 0 {
+		return true
+	}
+	root := resolveAlias(document.Content[0])
+	if root.Kind != yaml.MappingNode {
+		state.report(file, root, SeverityError, "expected a mapping of file-level keys")
+		return false
+	}
+	file.root = root
+	importNode := mappingValue(root, "import")
+	if importNode != nil && importNode.Kind == yaml.SequenceNode {
+		for _, itemNode := range importNode.Content {
+			file.imports = append(file.imports, importDeclaration{
+				node: itemNode,
+				path: itemNode.Value,
+			})
+		}
+	}
+	return true
+}
+
+func (state *linting) reportYamlError(file *pipelineFile, err error) {
+	messages := []string{err.Error()}
+	var typeError *yaml.TypeError
+	if errors.As(err, &typeError) {
+		messages = typeError.Errors
+	}
+	for _, message := range messages {
+		line := 0
+		if match := yamlErrorLineRegex.FindStringSubmatch(message); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			message = match[2]
+		}
+		state.addIssue(Issue{
+			Line:     line,
+			Message:  fmt.Sprintf("invalid yaml: %v", strings.TrimPrefix(message, "yaml: ")),
+			Path:     file.path,
+			Severity: SeverityError,
+		})
+	}
+}
+
+func (state *linting) loadedPaths() []string {
+	return append([]string{}, state.fileOrder...)
+}
+
+// checkImportCycles reports import declarations that lead back to a file that is (directly or indirectly) importing them
+func (state *linting) checkImportCycles(filePaths []string) {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	status := make(map[string]int, len(state.files))
+	importChain := make([]string, 0, 8)
+	var visit func(file *pipelineFile)
+	visit = func(file *pipelineFile) {
+		status[file.path] = inProgress
+		importChain = append(importChain, file.path)
+		for _, declaration := range file.imports {
+			importedFile, ok := state.files[declaration.path]
+			if !ok {
+				continue
+			}
+			switch status[importedFile.path] {
+			case inProgress:
+				cycle := append([]string{}, importChain...)
+				for index, chainPath := range cycle {
+					if chainPath == importedFile.path {
+						cycle = cycle[index:]
+						break
+					}
+				}
+				cycle = append(cycle, importedFile.path)
+				state.report(file, declaration.node, SeverityWarning, "import cycle: %v", strings.Join(cycle, " -> "))
+			case unvisited:
+				visit(importedFile)
+			}
+		}
+		importChain = importChain[:len(importChain)-1]
+		status[file.path] = done
+	}
+	for _, filePath := range filePaths {
+		if file, ok := state.files[filePath]; ok && status[filePath] == unvisited {
+			visit(file)
+		}
+	}
+}
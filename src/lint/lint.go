@@ -0,0 +1,222 @@
+// Package lint provides static validation of pipeline files, reporting problems without executing any pipes
+package lint
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/middleware/stack"
+	"github.com/Layer9Berlin/pipedream/src/parsing"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Severity indicates whether an issue will break the execution or merely looks suspicious
+type Severity string
+
+const (
+	// SeverityError marks issues that will cause errors or silently ignored settings at runtime
+	SeverityError Severity = "error"
+	// SeverityWarning marks issues that might be intentional, like arguments only provided via the command line
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found in a pipeline file
+type Issue struct {
+	Column   int
+	Line     int
+	Message  string
+	Path     string
+	Severity Severity
+}
+
+// String formats the issue as `path:line:column: severity: message`
+func (issue Issue) String() string {
+	return fmt.Sprintf("%v:%v:%v: %v: %v", issue.Path, issue.Line, issue.Column, issue.Severity, issue.Message)
+}
+
+// Symbol is an occurrence of a pipe identifier in a pipeline file
+type Symbol struct {
+	// Arguments are the inline arguments of an invocation (always nil for definitions)
+	Arguments map[string]interface{}
+	// BuiltIn indicates whether a definition is part of the built-in pipes
+	BuiltIn bool
+	// Column is the position of the identifier's first character, excluding any quotes
+	Column     int
+	Identifier string
+	Line       int
+	Path       string
+	// Public indicates whether a definition is public (always false for invocations)
+	Public bool
+}
+
+// Analysis contains the issues found in pipeline files, as well as an index of all pipe identifiers
+type Analysis struct {
+	// Definitions lists the identifiers of all pipes defined in the analyzed, imported and built-in files
+	Definitions []Symbol
+	// DefinitionsLookup contains the parsed definitions of all pipes, as used during execution
+	DefinitionsLookup pipeline.DefinitionsLookup
+	Issues            []Issue
+	// References lists all invocations of pipes in the analyzed and imported files
+	References []Symbol
+}
+
+// Resolve finds the definition that an identifier used within the specified file refers to
+//
+// The second return value is the corresponding symbol, if the definition has been indexed.
+func (analysis *Analysis) Resolve(identifier string, path string) (*pipeline.Definition, *Symbol) {
+	definition, found := middleware.LookUpPipelineDefinition(analysis.DefinitionsLookup, identifier, filepath.Base(path))
+	if !found {
+		return nil, nil
+	}
+	for index, symbol := range analysis.Definitions {
+		if symbol.Identifier == identifier &&
+			symbol.BuiltIn == definition.BuiltIn &&
+			symbol.Public == definition.Public &&
+			filepath.Base(symbol.Path) == definition.FileName {
+			return definition, &analysis.Definitions[index]
+		}
+	}
+	return definition, nil
+}
+
+// Linter validates pipeline files against the argument shapes described by the middleware stack
+type Linter struct {
+	middlewareStack []middleware.Middleware
+	parser          *parsing.Parser
+	projectPath     string
+	readFile        func(filename string) ([]byte, error)
+}
+
+// NewLinter creates a new Linter
+func NewLinter(options ...LinterOption) *Linter {
+	linter := &Linter{
+		middlewareStack: stack.SetUpMiddleware(),
+		parser:          parsing.NewParser(),
+		projectPath:     "",
+		readFile:        ioutil.ReadFile,
+	}
+	for _, applyOption := range options {
+		applyOption(linter)
+	}
+	return linter
+}
+
+// Lint validates the specified pipeline files (or all pipeline files in the working directory if none are specified)
+//
+// Imported files and built-in pipes are taken into account when resolving references,
+// but apart from parsing errors, issues are only reported for the specified files.
+// An error is returned only if the files could not be linted at all.
+func (linter *Linter) Lint(filePaths []string) ([]Issue, error) {
+	analysis, err := linter.Analyze(filePaths)
+	if err != nil {
+		return nil, err
+	}
+	return analysis.Issues, nil
+}
+
+// Analyze is like Lint, but additionally indexes the definitions of and references to all pipes
+func (linter *Linter) Analyze(filePaths []string) (*Analysis, error) {
+	if len(filePaths) == 0 {
+		userFilePaths, err := linter.parser.UserPipelineFilePaths("")
+		if err != nil {
+			return nil, err
+		}
+		filePaths = userFilePaths
+	}
+
+	builtInFilePaths, err := linter.parser.BuiltInPipelineFilePaths(linter.projectPath)
+	if err != nil {
+		return nil, err
+	}
+	_, builtInDefinitions, _, err := linter.parser.ParsePipelineFiles(builtInFilePaths, true)
+	if err != nil {
+		return nil, err
+	}
+
+	state := newLinting(linter.middlewareStack, linter.readFile)
+	err = state.loadFiles(filePaths)
+	if err != nil {
+		return nil, err
+	}
+	_, userDefinitions, _, err := linter.parser.ParsePipelineFiles(state.loadedPaths(), false)
+	if err != nil {
+		return nil, err
+	}
+	state.definitions = pipeline.MergePipelineDefinitions(builtInDefinitions, userDefinitions)
+
+	state.checkImportCycles(filePaths)
+
+	// the first pass records the arguments each pipe is invoked with,
+	// so that argument references can be resolved in the second pass
+	state.collecting = true
+	for _, file := range state.files {
+		state.lintFile(file)
+	}
+	state.collecting = false
+	lintedPaths := make(map[string]bool, len(filePaths))
+	for _, filePath := range filePaths {
+		lintedPaths[filePath] = true
+	}
+	for _, filePath := range state.fileOrder {
+		state.muted = !lintedPaths[filePath]
+		state.lintFile(state.files[filePath])
+	}
+	state.muted = false
+	state.indexBuiltInDefinitions(builtInFilePaths)
+
+	sort.SliceStable(state.issues, func(i, j int) bool {
+		if state.issues[i].Path != state.issues[j].Path {
+			return state.issues[i].Path < state.issues[j].Path
+		}
+		if state.issues[i].Line != state.issues[j].Line {
+			return state.issues[i].Line < state.issues[j].Line
+		}
+		return state.issues[i].Column < state.issues[j].Column
+	})
+	return &Analysis{
+		Definitions:       state.definitionSymbols,
+		DefinitionsLookup: state.definitions,
+		Issues:            state.issues,
+		References:        state.referenceSymbols,
+	}, nil
+}
+
+// Cmd implements the lint command, printing all issues found in the specified pipeline files followed by a summary
+//
+// The returned exit code is non-zero if any errors were found.
+func Cmd(writer io.Writer, filePaths []string) (int, error) {
+	executableLocation, _ := os.Executable()
+	projectPath, _ := filepath.EvalSymlinks(path.Dir(executableLocation))
+	issues, err := NewLinter(WithProjectPath(projectPath)).Lint(filePaths)
+	if err != nil {
+		return 1, err
+	}
+	return writeIssues(writer, issues)
+}
+
+func writeIssues(writer io.Writer, issues []Issue) (int, error) {
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errorCount++
+		}
+		_, err := fmt.Fprintln(writer, issue)
+		if err != nil {
+			return 1, err
+		}
+	}
+	if len(issues) == 0 {
+		_, err := fmt.Fprintln(writer, "No issues found")
+		return 0, err
+	}
+	_, err := fmt.Fprintf(writer, "Found %v errors and %v warnings\n", errorCount, len(issues)-errorCount)
+	if err != nil || errorCount > 0 {
+		return 1, err
+	}
+	return 0, nil
+}
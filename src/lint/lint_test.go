@@ -0,0 +1,376 @@
+package lint
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinter_Lint_validFile(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "valid.pipe", `
+version: 0.0.1
+default:
+  command: build
+public:
+  build:
+    target: linux
+    pipe:
+      - compile:
+          flags: "-v"
+      - built-in
+      - ~:
+          shell:
+            run: "echo @{target}"
+private:
+  compile:
+    shell:
+      run: "go build @{flags} -o @{target|bin}"
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Empty(t, issues)
+}
+
+func TestLinter_Lint_unresolvedReferences(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "references.pipe", `
+public:
+  test:
+    pipe:
+      - missing-pipe
+    each:
+      - missing-each
+    sequence:
+      - missing-sequence:
+          arg: value
+    collect:
+      values:
+        - missing-collect
+    select:
+      options:
+        - missing-select
+    catch: missing-catch
+    when: "1 == 1"
+    else: missing-else
+    cond:
+      - when: "1 == 1"
+        pipe: missing-cond
+    output:
+      process: built-in
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		filePath + `:5:9: error: unable to find pipe "missing-pipe"`,
+		filePath + `:7:9: error: unable to find pipe "missing-each"`,
+		filePath + `:9:9: error: unable to find pipe "missing-sequence"`,
+		filePath + `:13:11: error: unable to find pipe "missing-collect"`,
+		filePath + `:16:11: error: unable to find pipe "missing-select"`,
+		filePath + `:17:12: error: unable to find pipe "missing-catch"`,
+		filePath + `:19:11: error: unable to find pipe "missing-else"`,
+		filePath + `:22:15: error: unable to find pipe "missing-cond"`,
+	}, issueStrings(issues))
+}
+
+func TestLinter_Lint_namedInlinePipe(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "inline.pipe", `
+public:
+  test:
+    pipe:
+      - some-inline-pipe:
+          shell:
+            run: "echo test"
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Empty(t, issues)
+}
+
+func TestLinter_Lint_malformedArguments(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "malformed.pipe", `
+public:
+  test:
+    retry:
+      attempts: 3
+      dleay: 1s
+    each:
+      itmes:
+        - built-in
+    timeout:
+      - 1s
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		filePath + `:5:7: error: malformed arguments for "retry": unknown keys: dleay`,
+		filePath + `:8:7: error: malformed arguments for "each": unknown keys: itmes`,
+		filePath + `:11:7: error: malformed arguments for "timeout": expected type 'string', got unconvertible type '[]interface {}', value: '[1s]'`,
+	}, issueStrings(issues))
+}
+
+func TestLinter_Lint_unknownKeys(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "keys.pipe", `
+pubilc:
+  test:
+    shel:
+      run: "echo test"
+Private:
+  test:
+    custom: value
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		filePath + `:2:1: error: unknown file-level key "pubilc", did you mean "public"?`,
+		filePath + `:6:1: error: unknown file-level key "Private", did you mean "private"?`,
+	}, issueStrings(issues))
+
+	filePath = writeLintFile(t, dir, "middleware-keys.pipe", `
+public:
+  test:
+    shel:
+      run: "echo test"
+    Pipe:
+      - built-in
+    custom: value
+`)
+	issues, err = NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		filePath + `:4:5: warning: "shel" is not interpreted by any middleware, did you mean "shell"?`,
+		filePath + `:6:5: warning: "Pipe" is not interpreted by any middleware, did you mean "pipe"?`,
+	}, issueStrings(issues))
+}
+
+func TestLinter_Lint_argumentReferences(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "interpolation.pipe", `
+public:
+  test:
+    pipe:
+      - greet:
+          name: world
+      - ~:
+          greeting: hello
+          shell:
+            run: "echo @{greeting} @{name} @{unknown}"
+    foreach:
+      items: [a, b]
+      pipe: greet
+    extract:
+      values:
+        extracted: [some, path]
+    shell:
+      run: "echo @{extracted} @{missing} @{nested.value} @{missing|default} @?{also-missing}"
+  quiet:
+    interpolate:
+      ignoreWarnings: true
+    shell:
+      run: "echo @{missing}"
+private:
+  greet:
+    shell:
+      run: "echo @{name} @{item} @{other}"
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		filePath + ":10:18: warning: unable to find value for argument `name`",
+		filePath + ":10:18: warning: unable to find value for argument `unknown`",
+		filePath + ":18:12: warning: unable to find value for argument `missing`",
+		filePath + ":18:12: warning: unable to find value for argument `nested.value`",
+		filePath + ":27:12: warning: unable to find value for argument `other`",
+	}, issueStrings(issues))
+}
+
+func TestLinter_Lint_hooks(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "hooks.pipe", `
+hooks:
+  before:
+    - missing-hook
+  onError:
+    - notify:
+        channel: ci
+public:
+  test:
+    shell:
+      run: "true"
+private:
+  notify:
+    shell:
+      run: "echo @{error} @{channel}"
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		filePath + `:4:7: error: unable to find pipe "missing-hook"`,
+	}, issueStrings(issues))
+}
+
+func TestLinter_Lint_imports(t *testing.T) {
+	dir := setUpLintDir(t)
+	firstPath := filepath.Join(dir, "first.pipe")
+	secondPath := filepath.Join(dir, "second.pipe")
+	writeLintFile(t, dir, "first.pipe", `
+import:
+  - `+secondPath+`
+public:
+  test:
+    pipe:
+      - imported
+`)
+	writeLintFile(t, dir, "second.pipe", `
+import:
+  - `+firstPath+`
+  - `+filepath.Join(dir, "missing.pipe")+`
+public:
+  imported:
+    shell:
+      run: "true"
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{firstPath})
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		secondPath + ":3:5: warning: import cycle: " + firstPath + " -> " + secondPath + " -> " + firstPath,
+		secondPath + ":4:5: error: unable to read imported file \"" + filepath.Join(dir, "missing.pipe") + "\"",
+	}, issueStrings(issues))
+}
+
+func TestLinter_Lint_invalidYaml(t *testing.T) {
+	dir := setUpLintDir(t)
+	filePath := writeLintFile(t, dir, "invalid.pipe", `
+public:
+  test:
+    shell: [
+`)
+	issues, err := NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Len(t, issues, 1)
+	require.Equal(t, SeverityError, issues[0].Severity)
+	require.Contains(t, issues[0].Message, "invalid yaml")
+	require.Greater(t, issues[0].Line, 0)
+
+	filePath = writeLintFile(t, dir, "wrong-type.pipe", `
+public:
+  - test
+`)
+	issues, err = NewLinter(WithProjectPath(dir)).Lint([]string{filePath})
+	require.Nil(t, err)
+	require.Len(t, issues, 1)
+	require.Equal(t, 3, issues[0].Line)
+	require.Contains(t, issues[0].Message, "invalid yaml: cannot unmarshal")
+}
+
+func TestLinter_Analyze(t *testing.T) {
+	dir := setUpLintDir(t)
+	importedPath := writeLintFile(t, dir, "imported.pipe", `
+public:
+  shared:
+    shell:
+      run: "true"
+`)
+	filePath := writeLintFile(t, dir, "analyzed.pipe", `
+import:
+  - `+importedPath+`
+public:
+  test:
+    pipe:
+      - shared:
+          arg: value
+      - "built-in"
+`)
+	analysis, err := NewLinter(WithProjectPath(dir)).Analyze([]string{filePath})
+	require.Nil(t, err)
+	require.Empty(t, analysis.Issues)
+	require.Equal(t, []Symbol{
+		{Arguments: map[string]interface{}{"arg": "value"}, Column: 9, Identifier: "shared", Line: 7, Path: filePath},
+		{Column: 10, Identifier: "built-in", Line: 9, Path: filePath},
+	}, analysis.References)
+	require.Contains(t, analysis.Definitions, Symbol{Column: 3, Identifier: "test", Line: 5, Path: filePath, Public: true})
+	require.Contains(t, analysis.Definitions, Symbol{Column: 3, Identifier: "shared", Line: 3, Path: importedPath, Public: true})
+
+	definition, symbol := analysis.Resolve("built-in", filePath)
+	require.NotNil(t, definition)
+	require.True(t, definition.BuiltIn)
+	require.Equal(t, filepath.Join(dir, "pipedream_pipes", "misc", "built-in.pipe"), symbol.Path)
+	require.Equal(t, 3, symbol.Line)
+
+	definition, symbol = analysis.Resolve("shared", filePath)
+	require.NotNil(t, definition)
+	require.Equal(t, importedPath, symbol.Path)
+
+	definition, symbol = analysis.Resolve("missing", filePath)
+	require.Nil(t, definition)
+	require.Nil(t, symbol)
+}
+
+func TestLinter_Lint_missingFile(t *testing.T) {
+	dir := setUpLintDir(t)
+	_, err := NewLinter(WithProjectPath(dir)).Lint([]string{filepath.Join(dir, "missing.pipe")})
+	require.NotNil(t, err)
+}
+
+func TestLinter_Lint_missingBuiltInPipes(t *testing.T) {
+	_, err := NewLinter(WithProjectPath(t.TempDir())).Lint([]string{"test.pipe"})
+	require.NotNil(t, err)
+}
+
+func TestWriteIssues(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	exitCode, err := writeIssues(buffer, []Issue{})
+	require.Nil(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, "No issues found\n", buffer.String())
+
+	buffer = new(bytes.Buffer)
+	exitCode, err = writeIssues(buffer, []Issue{
+		{Column: 3, Line: 2, Message: "some warning", Path: "test.pipe", Severity: SeverityWarning},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, "test.pipe:2:3: warning: some warning\nFound 0 errors and 1 warnings\n", buffer.String())
+
+	buffer = new(bytes.Buffer)
+	exitCode, err = writeIssues(buffer, []Issue{
+		{Column: 3, Line: 2, Message: "some error", Path: "test.pipe", Severity: SeverityError},
+		{Column: 1, Line: 5, Message: "some warning", Path: "test.pipe", Severity: SeverityWarning},
+	})
+	require.Nil(t, err)
+	require.Equal(t, 1, exitCode)
+	require.Equal(t, "test.pipe:2:3: error: some error\ntest.pipe:5:1: warning: some warning\nFound 1 errors and 1 warnings\n", buffer.String())
+}
+
+func setUpLintDir(t *testing.T) string {
+	dir := t.TempDir()
+	builtInDir := filepath.Join(dir, "pipedream_pipes", "misc")
+	require.Nil(t, os.MkdirAll(builtInDir, 0755))
+	writeLintFile(t, builtInDir, "built-in.pipe", `
+public:
+  built-in:
+    shell:
+      run: "true"
+`)
+	return dir
+}
+
+func writeLintFile(t *testing.T, dir string, fileName string, content string) string {
+	filePath := filepath.Join(dir, fileName)
+	require.Nil(t, ioutil.WriteFile(filePath, []byte(content), 0644))
+	return filePath
+}
+
+func issueStrings(issues []Issue) []string {
+	result := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		result = append(result, issue.String())
+	}
+	return result
+}
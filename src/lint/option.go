@@ -0,0 +1,42 @@
+package lint
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/parsing"
+)
+
+// LinterOption represents an option that can be passed to the `NewLinter` constructor
+type LinterOption func(linter *Linter)
+
+// WithMiddlewareStack sets the middleware stack describing the valid argument keys and their shapes
+func WithMiddlewareStack(middlewareStack []middleware.Middleware) LinterOption {
+	return func(linter *Linter) {
+		linter.middlewareStack = middlewareStack
+	}
+}
+
+// WithParser sets the parser used to find and parse pipeline files
+//
+// Useful for tests.
+func WithParser(parser *parsing.Parser) LinterOption {
+	return func(linter *Linter) {
+		linter.parser = parser
+	}
+}
+
+// WithProjectPath sets the path of the PipeDream installation containing the built-in pipes
+func WithProjectPath(projectPath string) LinterOption {
+	return func(linter *Linter) {
+		linter.projectPath = projectPath
+	}
+}
+
+// WithReadFileImplementation sets the implementation of the function reading a file's content
+//
+// Useful for tests and for linting files with unsaved changes.
+// Note that the parser should be configured to read files in the same way.
+func WithReadFileImplementation(readFile func(filename string) ([]byte, error)) LinterOption {
+	return func(linter *Linter) {
+		linter.readFile = readFile
+	}
+}
@@ -0,0 +1,45 @@
+package lint
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+func newSymbol(path string, identifierNode *yaml.Node, argumentsNode *yaml.Node, isPublic bool) Symbol {
+	column := identifierNode.Column
+	if identifierNode.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		column++
+	}
+	var arguments map[string]interface{} = nil
+	if argumentsNode != nil && argumentsNode.Kind == yaml.MappingNode {
+		_ = argumentsNode.Decode(&arguments)
+	}
+	return Symbol{
+		Arguments:  arguments,
+		Column:     column,
+		Identifier: identifierNode.Value,
+		Line:       identifierNode.Line,
+		Path:       path,
+		Public:     isPublic,
+	}
+}
+
+// indexBuiltInDefinitions adds the definitions in the built-in files to the index, without linting them
+func (state *linting) indexBuiltInDefinitions(builtInFilePaths []string) {
+	for _, filePath := range builtInFilePaths {
+		fileData, err := state.readFile(filePath)
+		if err != nil {
+			continue
+		}
+		document := yaml.Node{}
+		if yaml.Unmarshal(fileData, &document) != nil || len(document.Content) == 0 {
+			continue
+		}
+		for _, visibility := range []string{"private", "public"} {
+			forEachPair(mappingValue(document.Content[0], visibility), func(identifierNode *yaml.Node, _ *yaml.Node) {
+				symbol := newSymbol(filePath, identifierNode, nil, visibility == "public")
+				symbol.BuiltIn = true
+				state.definitionSymbols = append(state.definitionSymbols, symbol)
+			})
+		}
+	}
+}
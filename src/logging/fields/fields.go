@@ -48,6 +48,13 @@ func Run(run interface{}) LogEntryField {
 	}
 }
 
+// Position adds the location in a pipeline file that the log entry relates to, e.g. `file.pipe:42:7`
+func Position(position interface{}) LogEntryField {
+	return func(entry *logrus.Entry) *logrus.Entry {
+		return entry.WithField("position", position)
+	}
+}
+
 // Middleware adds information about the middleware context to the log entry
 func Middleware(middleware interface{}) LogEntryField {
 	return func(entry *logrus.Entry) *logrus.Entry {
@@ -87,3 +87,10 @@ func TestMiddlewareLogEntries_DataStream(t *testing.T) {
 	)
 	require.Equal(t, fmt.Sprint(aurora.Gray(18, "⎇ test | message"), "\n"), string(result))
 }
+
+func TestLogFields_Position(t *testing.T) {
+	result, _ := logging.LogFormatter{}.Format(
+		EntryWithFields(Message("test message"), Position("test.pipe:42:7")),
+	)
+	require.Contains(t, string(result), "test message (test.pipe:42:7)")
+}
@@ -36,11 +36,17 @@ func (formatter LogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 		indentation = 0
 	}
 
+	position := extractField(entry, "position")
+	if position != "" {
+		position = " (" + position + ")"
+	}
+
 	result := coloredOutput(entry,
 		fmt.Sprint(
 			strings.Repeat(" ", indentation),
 			extractField(entry, "prefix"),
 			extractFields(entry, "middleware", "message", "info"),
+			position,
 		),
 	) + "\n"
 
@@ -172,3 +172,12 @@ func TestLogger_LongMessage(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, append(message[:1024], []byte("…\n")...), log)
 }
+
+func TestLogger_Position(t *testing.T) {
+	logger := logrus.New()
+	log, err := LogFormatter{}.Format(logrus.NewEntry(logger).
+		WithField("message", "some message").
+		WithField("position", "test.pipe:42:7"))
+	require.Nil(t, err)
+	require.Contains(t, string(log), "some message (test.pipe:42:7)")
+}
@@ -0,0 +1,104 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// message is an incoming JSON-RPC request (with ID) or notification (without ID)
+type message struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *responseError) Error() string {
+	return err.Message
+}
+
+// connection reads and writes JSON-RPC messages framed by a `Content-Length` header, as used by LSP
+type connection struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+func newConnection(reader io.Reader, writer io.Writer) *connection {
+	return &connection{
+		reader: bufio.NewReader(reader),
+		writer: writer,
+	}
+}
+
+func (conn *connection) read() (*message, error) {
+	contentLength := -1
+	for {
+		line, err := conn.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		header := strings.SplitN(line, ":", 2)
+		if len(header) == 2 && strings.EqualFold(strings.TrimSpace(header[0]), "Content-Length") {
+			contentLength, err = strconv.Atoi(strings.TrimSpace(header[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid content length %q", header[1])
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, fmt.Errorf("missing content length header")
+	}
+	content := make([]byte, contentLength)
+	_, err := io.ReadFull(conn.reader, content)
+	if err != nil {
+		return nil, err
+	}
+	result := &message{}
+	err = json.Unmarshal(content, result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+	return result, nil
+}
+
+func (conn *connection) write(value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(conn.writer, "Content-Length: %v\r\n\r\n%s", len(content), content)
+	return err
+}
+
+func (conn *connection) respond(id *json.RawMessage, result interface{}, err *responseError) error {
+	response := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+	if err != nil {
+		response["error"] = err
+	} else {
+		response["result"] = result
+	}
+	return conn.write(response)
+}
+
+func (conn *connection) notify(method string, params interface{}) error {
+	return conn.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
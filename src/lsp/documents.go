@@ -0,0 +1,106 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// documentPath converts a `file://` URI to the path used when analyzing the document
+//
+// Paths of files within the workspace are relative to the workspace root, matching import declarations.
+func (server *Server) documentPath(uri string) string {
+	parsedURI, err := url.Parse(uri)
+	if err != nil || parsedURI.Scheme != "file" {
+		return uri
+	}
+	documentPath := filepath.FromSlash(parsedURI.Path)
+	relativePath, err := filepath.Rel(server.rootPath, documentPath)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return documentPath
+	}
+	return relativePath
+}
+
+// documentURI converts a path as used when analyzing documents to a `file://` URI
+func (server *Server) documentURI(documentPath string) string {
+	if !filepath.IsAbs(documentPath) {
+		documentPath = filepath.Join(server.rootPath, documentPath)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(documentPath)}).String()
+}
+
+// readFile returns the content of open documents, including unsaved changes, and reads all other files from disk
+func (server *Server) readFile(filename string) ([]byte, error) {
+	if text, ok := server.documents[filepath.Clean(filename)]; ok {
+		return []byte(text), nil
+	}
+	return server.readFileFromDisk(filename)
+}
+
+func (server *Server) lineText(documentPath string, line int) string {
+	data, err := server.readFile(documentPath)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[line-1], "\r")
+}
+
+// position converts a one-based line and (rune) column to an LSP position
+func (server *Server) position(documentPath string, line int, column int) Position {
+	if line < 1 {
+		return Position{}
+	}
+	character := 0
+	for index, char := range []rune(server.lineText(documentPath, line)) {
+		if index >= column-1 {
+			break
+		}
+		character += len(utf16.Encode([]rune{char}))
+	}
+	return Position{Line: line - 1, Character: character}
+}
+
+// column converts an LSP position's UTF-16 character offset to a one-based (rune) column
+func (server *Server) column(documentPath string, position Position) int {
+	column := 1
+	character := 0
+	for _, char := range server.lineText(documentPath, position.Line+1) {
+		character += len(utf16.Encode([]rune{char}))
+		if character > position.Character {
+			break
+		}
+		column++
+	}
+	return column
+}
+
+// wordRange is the range from the specified position to the end of the word starting there
+func (server *Server) wordRange(documentPath string, line int, column int) Range {
+	start := server.position(documentPath, line, column)
+	lineRunes := []rune(server.lineText(documentPath, line))
+	endColumn := column
+	for endColumn-1 >= 0 && endColumn-1 < len(lineRunes) {
+		char := lineRunes[endColumn-1]
+		if unicode.IsSpace(char) || char == ',' {
+			break
+		}
+		// colons are allowed within identifiers like `shell::run`, but not as key separator
+		if char == ':' && (endColumn == len(lineRunes) || unicode.IsSpace(lineRunes[endColumn])) {
+			break
+		}
+		endColumn++
+	}
+	return Range{Start: start, End: server.position(documentPath, line, endColumn)}
+}
+
+func identifierLength(identifier string) int {
+	return utf8.RuneCountInString(identifier)
+}
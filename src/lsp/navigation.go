@@ -0,0 +1,215 @@
+package lsp
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
+	"github.com/Layer9Berlin/pipedream/src/lint"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// symbolAt finds the pipe identifier at the specified position
+//
+// The second return value indicates whether the symbol is a definition (rather than an invocation).
+func (server *Server) symbolAt(params textDocumentPositionParams) (*lint.Symbol, bool) {
+	if server.analysis == nil {
+		return nil, false
+	}
+	documentPath := server.documentPath(params.TextDocument.URI)
+	line := params.Position.Line + 1
+	column := server.column(documentPath, params.Position)
+	contains := func(symbol lint.Symbol) bool {
+		return symbol.Identifier != "" &&
+			symbol.Path == documentPath &&
+			symbol.Line == line &&
+			column >= symbol.Column &&
+			column < symbol.Column+identifierLength(symbol.Identifier)
+	}
+	for index, symbol := range server.analysis.References {
+		if contains(symbol) {
+			return &server.analysis.References[index], false
+		}
+	}
+	for index, symbol := range server.analysis.Definitions {
+		if contains(symbol) {
+			return &server.analysis.Definitions[index], true
+		}
+	}
+	return nil, false
+}
+
+// definitionSymbolAt finds the definition of the pipe whose identifier is at the specified position
+func (server *Server) definitionSymbolAt(params textDocumentPositionParams) *lint.Symbol {
+	symbol, isDefinition := server.symbolAt(params)
+	if symbol == nil || isDefinition {
+		return symbol
+	}
+	_, definitionSymbol := server.analysis.Resolve(symbol.Identifier, symbol.Path)
+	return definitionSymbol
+}
+
+// referencesTo lists all invocations resolving to the specified definition
+func (server *Server) referencesTo(definitionSymbol *lint.Symbol) []lint.Symbol {
+	result := make([]lint.Symbol, 0, 8)
+	for _, reference := range server.analysis.References {
+		if _, resolvedSymbol := server.analysis.Resolve(reference.Identifier, reference.Path); resolvedSymbol == definitionSymbol {
+			result = append(result, reference)
+		}
+	}
+	return result
+}
+
+func (server *Server) symbolRange(symbol lint.Symbol) Range {
+	return Range{
+		Start: server.position(symbol.Path, symbol.Line, symbol.Column),
+		End:   server.position(symbol.Path, symbol.Line, symbol.Column+identifierLength(symbol.Identifier)),
+	}
+}
+
+func (server *Server) symbolLocation(symbol lint.Symbol) Location {
+	return Location{
+		URI:   server.documentURI(symbol.Path),
+		Range: server.symbolRange(symbol),
+	}
+}
+
+func (server *Server) definition(params textDocumentPositionParams) interface{} {
+	definitionSymbol := server.definitionSymbolAt(params)
+	if definitionSymbol == nil {
+		return nil
+	}
+	return server.symbolLocation(*definitionSymbol)
+}
+
+func (server *Server) references(params referenceParams) []Location {
+	definitionSymbol := server.definitionSymbolAt(params.textDocumentPositionParams)
+	if definitionSymbol == nil {
+		return []Location{}
+	}
+	locations := make([]Location, 0, 8)
+	if params.Context.IncludeDeclaration {
+		locations = append(locations, server.symbolLocation(*definitionSymbol))
+	}
+	for _, reference := range server.referencesTo(definitionSymbol) {
+		locations = append(locations, server.symbolLocation(reference))
+	}
+	return locations
+}
+
+// hover shows the arguments of the pipe at the specified position
+//
+// For invocations, these are the definition's arguments merged with the inline arguments,
+// just like at runtime (inline arguments take precedence).
+func (server *Server) hover(params textDocumentPositionParams) interface{} {
+	symbol, isDefinition := server.symbolAt(params)
+	if symbol == nil {
+		return nil
+	}
+	var definition *pipeline.Definition
+	var definitionSymbol *lint.Symbol
+	if isDefinition {
+		definition, definitionSymbol = server.definitionOf(symbol), symbol
+	} else {
+		definition, definitionSymbol = server.analysis.Resolve(symbol.Identifier, symbol.Path)
+	}
+
+	arguments := stringmap.CopyMap(symbol.Arguments)
+	if arguments == nil {
+		arguments = make(map[string]interface{}, 8)
+	}
+	if definition != nil {
+		_ = stringmap.MergeIntoMap(arguments, definition.DefinitionArguments)
+	}
+
+	contents := new(strings.Builder)
+	_, _ = fmt.Fprintf(contents, "**%v**\n\n", symbol.Identifier)
+	if definition == nil {
+		_, _ = fmt.Fprintln(contents, "No definition found")
+	} else {
+		visibility := "Private"
+		if definition.Public {
+			visibility = "Public"
+		}
+		if definition.BuiltIn {
+			visibility = "Built-in " + strings.ToLower(visibility)
+		}
+		location := definition.FileName
+		if definitionSymbol != nil {
+			location = fmt.Sprintf("%v:%v", definitionSymbol.Path, definitionSymbol.Line)
+		}
+		_, _ = fmt.Fprintf(contents, "%v pipe defined in `%v`\n", visibility, location)
+	}
+	if len(arguments) > 0 {
+		yamlArguments, err := yaml.Marshal(arguments)
+		if err == nil {
+			_, _ = fmt.Fprintf(contents, "\n```yaml\n%s```\n", yamlArguments)
+		}
+	}
+	hoverRange := server.symbolRange(*symbol)
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: contents.String()},
+		Range:    &hoverRange,
+	}
+}
+
+// definitionOf finds the parsed definition corresponding to a definition symbol
+func (server *Server) definitionOf(symbol *lint.Symbol) *pipeline.Definition {
+	definitions := server.analysis.DefinitionsLookup[symbol.Identifier]
+	for index, definition := range definitions {
+		if definition.BuiltIn == symbol.BuiltIn &&
+			definition.Public == symbol.Public &&
+			definition.FileName == filepath.Base(symbol.Path) {
+			return &definitions[index]
+		}
+	}
+	return nil
+}
+
+func (server *Server) renameableSymbol(params textDocumentPositionParams) (*lint.Symbol, *responseError) {
+	symbol, _ := server.symbolAt(params)
+	if symbol == nil {
+		return nil, &responseError{Code: errorCodeRequestFailed, Message: "no pipe identifier at this position"}
+	}
+	definitionSymbol := server.definitionSymbolAt(params)
+	if definitionSymbol == nil {
+		return nil, &responseError{Code: errorCodeRequestFailed, Message: fmt.Sprintf("unable to find definition of pipe %q", symbol.Identifier)}
+	}
+	if definitionSymbol.BuiltIn {
+		return nil, &responseError{Code: errorCodeRequestFailed, Message: fmt.Sprintf("cannot rename built-in pipe %q", symbol.Identifier)}
+	}
+	return symbol, nil
+}
+
+func (server *Server) prepareRename(params textDocumentPositionParams) (interface{}, *responseError) {
+	symbol, err := server.renameableSymbol(params)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"range":       server.symbolRange(*symbol),
+		"placeholder": symbol.Identifier,
+	}, nil
+}
+
+// rename changes the identifier of a pipe's definition and all invocations resolving to it, across all files
+func (server *Server) rename(params renameParams) (interface{}, *responseError) {
+	if params.NewName == "" || strings.ContainsAny(params.NewName, "@") || strings.IndexFunc(params.NewName, unicode.IsSpace) >= 0 {
+		return nil, &responseError{Code: errorCodeInvalidParams, Message: fmt.Sprintf("invalid pipe identifier %q", params.NewName)}
+	}
+	if _, err := server.renameableSymbol(params.textDocumentPositionParams); err != nil {
+		return nil, err
+	}
+	definitionSymbol := server.definitionSymbolAt(params.textDocumentPositionParams)
+	edit := WorkspaceEdit{Changes: make(map[string][]TextEdit, 4)}
+	for _, symbol := range append([]lint.Symbol{*definitionSymbol}, server.referencesTo(definitionSymbol)...) {
+		uri := server.documentURI(symbol.Path)
+		edit.Changes[uri] = append(edit.Changes[uri], TextEdit{
+			Range:   server.symbolRange(symbol),
+			NewText: params.NewName,
+		})
+	}
+	return edit, nil
+}
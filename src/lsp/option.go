@@ -0,0 +1,38 @@
+package lsp
+
+// ServerOption represents an option that can be passed to the `NewServer` constructor
+type ServerOption func(server *Server)
+
+// WithChdirImplementation sets the implementation of the function changing the working directory to the workspace root
+//
+// Useful for tests.
+func WithChdirImplementation(chdir func(dir string) error) ServerOption {
+	return func(server *Server) {
+		server.chdir = chdir
+	}
+}
+
+// WithFindByGlobImplementation sets the implementation of the function finding the pipeline files in the workspace
+//
+// Useful for tests.
+func WithFindByGlobImplementation(findByGlob func(pattern string) ([]string, error)) ServerOption {
+	return func(server *Server) {
+		server.findByGlob = findByGlob
+	}
+}
+
+// WithProjectPath sets the path of the PipeDream installation containing the built-in pipes
+func WithProjectPath(projectPath string) ServerOption {
+	return func(server *Server) {
+		server.projectPath = projectPath
+	}
+}
+
+// WithReadFileImplementation sets the implementation of the function reading the content of files that are not open in the editor
+//
+// Useful for tests.
+func WithReadFileImplementation(readFile func(filename string) ([]byte, error)) ServerOption {
+	return func(server *Server) {
+		server.readFileFromDisk = readFile
+	}
+}
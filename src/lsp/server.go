@@ -0,0 +1,290 @@
+// Package lsp provides the implementation of the lsp command, a language server for pipeline files
+//
+// The server speaks the Language Server Protocol over stdio and supports go-to-definition, find-references,
+// hover, rename and diagnostics, all based on the static analysis performed by the linter.
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/lint"
+	"github.com/Layer9Berlin/pipedream/src/parsing"
+	"github.com/Layer9Berlin/pipedream/src/version"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Server is a language server for pipeline files
+type Server struct {
+	analysis         *lint.Analysis
+	chdir            func(dir string) error
+	conn             *connection
+	diagnosedPaths   map[string]bool
+	documents        map[string]string
+	findByGlob       func(pattern string) ([]string, error)
+	projectPath      string
+	readFileFromDisk func(filename string) ([]byte, error)
+	rootPath         string
+	shuttingDown     bool
+}
+
+// NewServer creates a new Server
+func NewServer(options ...ServerOption) *Server {
+	server := &Server{
+		chdir:            os.Chdir,
+		diagnosedPaths:   make(map[string]bool, 8),
+		documents:        make(map[string]string, 8),
+		findByGlob:       filepath.Glob,
+		projectPath:      "",
+		readFileFromDisk: ioutil.ReadFile,
+		rootPath:         "",
+	}
+	for _, applyOption := range options {
+		applyOption(server)
+	}
+	return server
+}
+
+// Serve handles the messages read from the reader, writing responses and notifications to the writer
+//
+// Returns once the client sends the `exit` notification or closes the connection.
+func (server *Server) Serve(reader io.Reader, writer io.Writer) error {
+	server.conn = newConnection(reader, writer)
+	for {
+		incomingMessage, err := server.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if incomingMessage.Method == "exit" {
+			if !server.shuttingDown {
+				return fmt.Errorf("received exit notification without prior shutdown request")
+			}
+			return nil
+		}
+		err = server.handle(incomingMessage)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (server *Server) handle(incomingMessage *message) error {
+	if incomingMessage.ID == nil {
+		return server.handleNotification(incomingMessage)
+	}
+	var result interface{}
+	var resultErr *responseError
+	if server.shuttingDown {
+		resultErr = &responseError{Code: errorCodeInvalidRequest, Message: "server is shutting down"}
+	} else {
+		result, resultErr = server.handleRequest(incomingMessage)
+	}
+	return server.conn.respond(incomingMessage.ID, result, resultErr)
+}
+
+func (server *Server) handleRequest(request *message) (interface{}, *responseError) {
+	switch request.Method {
+	case "initialize":
+		params := initializeParams{}
+		if err := decodeParams(request, &params); err != nil {
+			return nil, err
+		}
+		return server.initialize(params)
+	case "shutdown":
+		server.shuttingDown = true
+		return nil, nil
+	case "textDocument/definition":
+		params := textDocumentPositionParams{}
+		if err := decodeParams(request, &params); err != nil {
+			return nil, err
+		}
+		return server.definition(params), nil
+	case "textDocument/references":
+		params := referenceParams{}
+		if err := decodeParams(request, &params); err != nil {
+			return nil, err
+		}
+		return server.references(params), nil
+	case "textDocument/hover":
+		params := textDocumentPositionParams{}
+		if err := decodeParams(request, &params); err != nil {
+			return nil, err
+		}
+		return server.hover(params), nil
+	case "textDocument/prepareRename":
+		params := textDocumentPositionParams{}
+		if err := decodeParams(request, &params); err != nil {
+			return nil, err
+		}
+		return server.prepareRename(params)
+	case "textDocument/rename":
+		params := renameParams{}
+		if err := decodeParams(request, &params); err != nil {
+			return nil, err
+		}
+		return server.rename(params)
+	}
+	return nil, &responseError{Code: errorCodeMethodNotFound, Message: fmt.Sprintf("method %q not supported", request.Method)}
+}
+
+func (server *Server) handleNotification(notification *message) error {
+	switch notification.Method {
+	case "initialized":
+	case "textDocument/didOpen":
+		params := didOpenTextDocumentParams{}
+		if decodeParams(notification, &params) != nil {
+			return nil
+		}
+		server.documents[server.documentPath(params.TextDocument.URI)] = params.TextDocument.Text
+	case "textDocument/didChange":
+		params := didChangeTextDocumentParams{}
+		if decodeParams(notification, &params) != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// only full document synchronization is supported
+		server.documents[server.documentPath(params.TextDocument.URI)] = params.ContentChanges[len(params.ContentChanges)-1].Text
+	case "textDocument/didClose":
+		params := didCloseTextDocumentParams{}
+		if decodeParams(notification, &params) != nil {
+			return nil
+		}
+		delete(server.documents, server.documentPath(params.TextDocument.URI))
+	case "textDocument/didSave":
+	default:
+		// other notifications can safely be ignored
+		return nil
+	}
+	return server.analyze()
+}
+
+func decodeParams(incomingMessage *message, params interface{}) *responseError {
+	err := json.Unmarshal(incomingMessage.Params, params)
+	if err != nil {
+		return &responseError{Code: errorCodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+func (server *Server) initialize(params initializeParams) (interface{}, *responseError) {
+	if params.RootURI != nil && *params.RootURI != "" {
+		server.rootPath = server.documentPath(*params.RootURI)
+	} else if params.RootPath != nil {
+		server.rootPath = *params.RootPath
+	}
+	if server.rootPath != "" {
+		// relative paths in import declarations are resolved relative to the working directory during execution
+		err := server.chdir(server.rootPath)
+		if err != nil {
+			return nil, &responseError{Code: errorCodeRequestFailed, Message: fmt.Sprintf("failed to open workspace: %v", err)}
+		}
+	}
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"change":    textDocumentSyncKindFull,
+				"save":      true,
+			},
+			"definitionProvider": true,
+			"referencesProvider": true,
+			"hoverProvider":      true,
+			"renameProvider": map[string]interface{}{
+				"prepareProvider": true,
+			},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "pipedream",
+			"version": version.Version,
+		},
+	}, nil
+}
+
+// analyze lints all pipeline files in the workspace as well as all open documents and publishes the issues found
+func (server *Server) analyze() error {
+	filePaths, err := server.findByGlob("*.pipe")
+	if err != nil {
+		return server.conn.notify("window/logMessage", logMessageParams{Type: 1, Message: err.Error()})
+	}
+	for documentPath := range server.documents {
+		if !containsString(filePaths, documentPath) {
+			filePaths = append(filePaths, documentPath)
+		}
+	}
+	sort.Strings(filePaths)
+	linter := lint.NewLinter(
+		lint.WithProjectPath(server.projectPath),
+		lint.WithReadFileImplementation(server.readFile),
+		lint.WithParser(parsing.NewParser(
+			parsing.WithReadFileImplementation(server.readFile),
+		)),
+	)
+	analysis, err := linter.Analyze(filePaths)
+	if err != nil {
+		// keep the previous analysis, which is probably more helpful than nothing
+		return server.conn.notify("window/logMessage", logMessageParams{Type: 1, Message: fmt.Sprintf("failed to analyze pipeline files: %v", err)})
+	}
+	server.analysis = analysis
+	return server.publishDiagnostics()
+}
+
+func (server *Server) publishDiagnostics() error {
+	diagnostics := make(map[string][]Diagnostic, len(server.diagnosedPaths))
+	for documentPath := range server.diagnosedPaths {
+		// clear diagnostics of files without issues
+		diagnostics[documentPath] = []Diagnostic{}
+	}
+	for _, issue := range server.analysis.Issues {
+		severity := diagnosticSeverityError
+		if issue.Severity == lint.SeverityWarning {
+			severity = diagnosticSeverityWarning
+		}
+		diagnostics[issue.Path] = append(diagnostics[issue.Path], Diagnostic{
+			Range:    server.wordRange(issue.Path, issue.Line, issue.Column),
+			Severity: severity,
+			Source:   "pipedream",
+			Message:  issue.Message,
+		})
+	}
+	documentPaths := make([]string, 0, len(diagnostics))
+	for documentPath := range diagnostics {
+		documentPaths = append(documentPaths, documentPath)
+	}
+	sort.Strings(documentPaths)
+	server.diagnosedPaths = make(map[string]bool, len(diagnostics))
+	for _, documentPath := range documentPaths {
+		if len(diagnostics[documentPath]) > 0 {
+			server.diagnosedPaths[documentPath] = true
+		}
+		err := server.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         server.documentURI(documentPath),
+			Diagnostics: diagnostics[documentPath],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, existingValue := range values {
+		if existingValue == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Cmd implements the lsp command, serving language server requests via stdin and stdout
+func Cmd(reader io.Reader, writer io.Writer) error {
+	executableLocation, _ := os.Executable()
+	projectPath, _ := filepath.EvalSymlinks(path.Dir(executableLocation))
+	return NewServer(WithProjectPath(projectPath)).Serve(reader, writer)
+}
@@ -0,0 +1,281 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const mainFileContent = `
+import:
+  - include/other.pipe
+public:
+  build:
+    pipe:
+      - helper:
+          value: inline
+      - greet
+      - built-in
+private:
+  greet:
+    shell:
+      run: echo @{missing}
+`
+
+const otherFileContent = `
+public:
+  helper:
+    value: default
+    other: "@{value}"
+`
+
+func TestServer_initialize(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	responses := serve(t, projectDir, rootDir)
+	require.Equal(t, true, responses[1]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})["definitionProvider"])
+	require.Equal(t, "pipedream", responses[1]["result"].(map[string]interface{})["serverInfo"].(map[string]interface{})["name"])
+	require.Nil(t, responses[2]["result"])
+	require.Contains(t, responses[2], "result")
+}
+
+func TestServer_diagnostics(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	notifications := serveNotifications(t, projectDir, rootDir, "textDocument/publishDiagnostics")
+	// published after initialization and after opening the document
+	require.Len(t, notifications, 2)
+	params := notifications[1]["params"].(map[string]interface{})
+	require.Equal(t, fileURI(filepath.Join(rootDir, "main.pipe")), params["uri"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				"start": map[string]interface{}{"line": float64(13), "character": float64(11)},
+				"end":   map[string]interface{}{"line": float64(13), "character": float64(15)},
+			},
+			"severity": float64(diagnosticSeverityWarning),
+			"source":   "pipedream",
+			"message":  "unable to find value for argument `missing`",
+		},
+	}, params["diagnostics"])
+}
+
+func TestServer_diagnosticsOfUnsavedChanges(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	mainURI := fileURI(filepath.Join(rootDir, "main.pipe"))
+	notifications := serveNotifications(t, projectDir, rootDir, "textDocument/publishDiagnostics",
+		notification("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": mainURI, "version": 2},
+			"contentChanges": []interface{}{map[string]interface{}{"text": strings.Replace(mainFileContent, "@{missing}", "test", 1)}},
+		}),
+	)
+	require.Len(t, notifications, 3)
+	require.Equal(t, []interface{}{}, notifications[2]["params"].(map[string]interface{})["diagnostics"])
+}
+
+func TestServer_definition(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	mainURI := fileURI(filepath.Join(rootDir, "main.pipe"))
+	responses := serve(t, projectDir, rootDir,
+		request(10, "textDocument/definition", positionParams(mainURI, 6, 10)),
+		request(11, "textDocument/definition", positionParams(mainURI, 8, 8)),
+		request(12, "textDocument/definition", positionParams(mainURI, 9, 8)),
+		request(13, "textDocument/definition", positionParams(mainURI, 0, 0)),
+	)
+	require.Equal(t, map[string]interface{}{
+		"uri":   fileURI(filepath.Join(rootDir, "include", "other.pipe")),
+		"range": rangeValue(2, 2, 2, 8),
+	}, responses[10]["result"])
+	require.Equal(t, map[string]interface{}{
+		"uri":   mainURI,
+		"range": rangeValue(11, 2, 11, 7),
+	}, responses[11]["result"])
+	require.Equal(t, map[string]interface{}{
+		"uri":   fileURI(filepath.Join(projectDir, "pipedream_pipes", "misc", "built-in.pipe")),
+		"range": rangeValue(2, 2, 2, 10),
+	}, responses[12]["result"])
+	require.Nil(t, responses[13]["result"])
+}
+
+func TestServer_references(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	mainURI := fileURI(filepath.Join(rootDir, "main.pipe"))
+	otherURI := fileURI(filepath.Join(rootDir, "include", "other.pipe"))
+	params := positionParams(otherURI, 2, 4)
+	params["context"] = map[string]interface{}{"includeDeclaration": true}
+	responses := serve(t, projectDir, rootDir,
+		request(10, "textDocument/references", params),
+	)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"uri": otherURI, "range": rangeValue(2, 2, 2, 8)},
+		map[string]interface{}{"uri": mainURI, "range": rangeValue(6, 8, 6, 14)},
+	}, responses[10]["result"])
+}
+
+func TestServer_hover(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	mainURI := fileURI(filepath.Join(rootDir, "main.pipe"))
+	responses := serve(t, projectDir, rootDir,
+		request(10, "textDocument/hover", positionParams(mainURI, 6, 10)),
+		request(11, "textDocument/hover", positionParams(mainURI, 3, 0)),
+	)
+	contents := responses[10]["result"].(map[string]interface{})["contents"].(map[string]interface{})
+	require.Equal(t, "markdown", contents["kind"])
+	require.Equal(t, "**helper**\n\nPublic pipe defined in `include/other.pipe:3`\n\n```yaml\nother: '@{value}'\nvalue: inline\n```\n", contents["value"])
+	require.Nil(t, responses[11]["result"])
+}
+
+func TestServer_rename(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	mainURI := fileURI(filepath.Join(rootDir, "main.pipe"))
+	otherURI := fileURI(filepath.Join(rootDir, "include", "other.pipe"))
+	renameParams := positionParams(mainURI, 6, 10)
+	renameParams["newName"] = "assist"
+	builtInRenameParams := positionParams(mainURI, 9, 8)
+	builtInRenameParams["newName"] = "assist"
+	invalidRenameParams := positionParams(mainURI, 6, 10)
+	invalidRenameParams["newName"] = "two words"
+	responses := serve(t, projectDir, rootDir,
+		request(10, "textDocument/prepareRename", positionParams(mainURI, 6, 10)),
+		request(11, "textDocument/rename", renameParams),
+		request(12, "textDocument/rename", builtInRenameParams),
+		request(13, "textDocument/rename", invalidRenameParams),
+	)
+	require.Equal(t, map[string]interface{}{
+		"range":       rangeValue(6, 8, 6, 14),
+		"placeholder": "helper",
+	}, responses[10]["result"])
+	require.Equal(t, map[string]interface{}{
+		"changes": map[string]interface{}{
+			otherURI: []interface{}{map[string]interface{}{"range": rangeValue(2, 2, 2, 8), "newText": "assist"}},
+			mainURI:  []interface{}{map[string]interface{}{"range": rangeValue(6, 8, 6, 14), "newText": "assist"}},
+		},
+	}, responses[11]["result"])
+	require.Equal(t, "cannot rename built-in pipe \"built-in\"", responses[12]["error"].(map[string]interface{})["message"])
+	require.Equal(t, float64(errorCodeInvalidParams), responses[13]["error"].(map[string]interface{})["code"])
+}
+
+func TestServer_unknownMethod(t *testing.T) {
+	rootDir, projectDir := setUpWorkspace(t)
+	responses := serve(t, projectDir, rootDir, request(10, "textDocument/unknown", map[string]interface{}{}))
+	require.Equal(t, float64(errorCodeMethodNotFound), responses[10]["error"].(map[string]interface{})["code"])
+}
+
+func TestServer_exitWithoutShutdown(t *testing.T) {
+	err := NewServer().Serve(strings.NewReader(frame(notification("exit", nil))), new(bytes.Buffer))
+	require.NotNil(t, err)
+}
+
+func TestConnection_read_missingContentLength(t *testing.T) {
+	_, err := newConnection(strings.NewReader("Content-Type: test\r\n\r\n{}"), new(bytes.Buffer)).read()
+	require.NotNil(t, err)
+}
+
+func setUpWorkspace(t *testing.T) (string, string) {
+	projectDir := t.TempDir()
+	builtInDir := filepath.Join(projectDir, "pipedream_pipes", "misc")
+	require.Nil(t, os.MkdirAll(builtInDir, 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(builtInDir, "built-in.pipe"), []byte(`
+public:
+  built-in:
+    shell:
+      run: "true"
+`), 0644))
+	rootDir := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(rootDir, "include"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(rootDir, "main.pipe"), []byte(mainFileContent), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(rootDir, "include", "other.pipe"), []byte(otherFileContent), 0644))
+	workingDir, err := os.Getwd()
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		_ = os.Chdir(workingDir)
+	})
+	return rootDir, projectDir
+}
+
+// serve runs a session consisting of the specified messages, wrapped in initialization and shutdown
+//
+// Returns the responses, indexed by request ID.
+func serve(t *testing.T, projectDir string, rootDir string, messages ...map[string]interface{}) map[int]map[string]interface{} {
+	responses := make(map[int]map[string]interface{}, len(messages))
+	for _, outgoingMessage := range runSession(t, projectDir, rootDir, messages) {
+		if id, ok := outgoingMessage["id"]; ok {
+			responses[int(id.(float64))] = outgoingMessage
+		}
+	}
+	return responses
+}
+
+// serveNotifications is like serve, but returns the notifications with the specified method instead
+func serveNotifications(t *testing.T, projectDir string, rootDir string, method string, messages ...map[string]interface{}) []map[string]interface{} {
+	notifications := make([]map[string]interface{}, 0, 4)
+	for _, outgoingMessage := range runSession(t, projectDir, rootDir, messages) {
+		if outgoingMessage["method"] == method {
+			notifications = append(notifications, outgoingMessage)
+		}
+	}
+	return notifications
+}
+
+func runSession(t *testing.T, projectDir string, rootDir string, messages []map[string]interface{}) []map[string]interface{} {
+	mainPath := filepath.Join(rootDir, "main.pipe")
+	input := new(strings.Builder)
+	input.WriteString(frame(request(1, "initialize", map[string]interface{}{"rootUri": fileURI(rootDir)})))
+	input.WriteString(frame(notification("initialized", map[string]interface{}{})))
+	input.WriteString(frame(notification("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": fileURI(mainPath), "languageId": "yaml", "version": 1, "text": mainFileContent},
+	})))
+	for _, incomingMessage := range messages {
+		input.WriteString(frame(incomingMessage))
+	}
+	input.WriteString(frame(request(2, "shutdown", nil)))
+	input.WriteString(frame(notification("exit", nil)))
+
+	output := new(bytes.Buffer)
+	require.Nil(t, NewServer(WithProjectPath(projectDir)).Serve(strings.NewReader(input.String()), output))
+
+	result := make([]map[string]interface{}, 0, 8)
+	for _, framedMessage := range strings.Split(output.String(), "Content-Length: ")[1:] {
+		content := framedMessage[strings.Index(framedMessage, "\r\n\r\n")+4:]
+		decodedMessage := make(map[string]interface{})
+		require.Nil(t, json.Unmarshal([]byte(content), &decodedMessage))
+		result = append(result, decodedMessage)
+	}
+	return result
+}
+
+func request(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notification(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func frame(value interface{}) string {
+	content, _ := json.Marshal(value)
+	return fmt.Sprintf("Content-Length: %v\r\n\r\n%s", len(content), content)
+}
+
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func positionParams(uri string, line int, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+func rangeValue(startLine int, startCharacter int, endLine int, endCharacter int) map[string]interface{} {
+	return map[string]interface{}{
+		"start": map[string]interface{}{"line": float64(startLine), "character": float64(startCharacter)},
+		"end":   map[string]interface{}{"line": float64(endLine), "character": float64(endCharacter)},
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+// ArgumentsDescriber is implemented by middleware that can describe the shape of the arguments they interpret
+//
+// This allows pipeline files to be validated without executing them.
+type ArgumentsDescriber interface {
+	// Arguments maps each argument key interpreted by the middleware to the accepted shapes of its value
+	//
+	// Each shape is a pointer to a value that the argument can be decoded into using `pipeline.DecodeArguments`.
+	Arguments() map[string][]interface{}
+}
+
+// SchemaContributor is implemented by middleware that provide JSON Schema fragments for some of their argument keys
+//
+// The fragments take precedence over the schemas derived from the shapes returned by ArgumentsDescriber,
+// which is useful for constraints and descriptions that cannot be expressed using Go types.
+type SchemaContributor interface {
+	// ArgumentsSchema maps argument keys to the JSON Schema their value should adhere to
+	ArgumentsSchema() map[string]interface{}
+}
@@ -0,0 +1,240 @@
+// Package cache provides a middleware that stores a pipe's results and replays them on subsequent runs with the same inputs
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/logging/fields"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// Middleware is a result cache
+type Middleware struct {
+	now func() time.Time
+}
+
+// String is a human-readable description
+func (Middleware) String() string {
+	return "cache"
+}
+
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"cache": {&arguments},
+	}
+}
+
+// NewMiddleware creates a new Middleware instance
+func NewMiddleware() Middleware {
+	return NewMiddlewareWithClock(time.Now)
+}
+
+// NewMiddlewareWithClock creates a new Middleware instance with the specified function to determine the current time
+func NewMiddlewareWithClock(now func() time.Time) Middleware {
+	return Middleware{
+		now: now,
+	}
+}
+
+type middlewareArguments struct {
+	Dir      string
+	Failures bool
+	Key      []string
+	TTL      string
+}
+
+func newMiddlewareArguments() middlewareArguments {
+	return middlewareArguments{
+		Dir:      DefaultDirectory,
+		Failures: false,
+		Key:      nil,
+		TTL:      "",
+	}
+}
+
+// Apply is where the middleware's logic resides
+//
+// It adapts the run based on its slice of the run's arguments.
+// It may also trigger side effects such as executing shell commands or full runs of other pipelines.
+// When done, this function should call next in order to continue unwinding the stack.
+func (cacheMiddleware Middleware) Apply(
+	run *pipeline.Run,
+	next func(*pipeline.Run),
+	executionContext *middleware.ExecutionContext,
+) {
+	arguments := newMiddlewareArguments()
+	if !pipeline.ParseArguments(&arguments, "cache", run) {
+		next(run)
+		return
+	}
+
+	if executionContext == nil {
+		run.Log.Error(
+			errors.New("results cannot be cached without an execution context"),
+			fields.Middleware(cacheMiddleware),
+		)
+		return
+	}
+	var ttl time.Duration
+	if arguments.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(arguments.TTL)
+		if err != nil || ttl <= 0 {
+			run.Log.Error(
+				fmt.Errorf("invalid cache ttl %q, expected a positive duration like `1h`", arguments.TTL),
+				fields.Middleware(cacheMiddleware),
+			)
+			return
+		}
+	}
+	store := newStore(run.ResolvePath(arguments.Dir))
+
+	// the key depends on the complete input, so the pipe is executed in a child run once it is available
+	run.Log.Trace(
+		fields.DataStream(cacheMiddleware, "copying stdin")...,
+	)
+	stdinCopy := run.Stdin.Copy()
+	run.Log.Trace(
+		fields.DataStream(cacheMiddleware, "creating stdout writer")...,
+	)
+	stdoutAppender := run.Stdout.WriteCloser()
+	run.Log.Trace(
+		fields.DataStream(cacheMiddleware, "creating stderr writer")...,
+	)
+	stderrAppender := run.Stderr.WriteCloser()
+
+	run.DontCompleteBefore(func() {
+		defer func() {
+			// need to clean up by closing the writers we created
+			run.Log.PossibleError(stdoutAppender.Close())
+			run.Log.PossibleError(stderrAppender.Close())
+		}()
+		stdin, err := ioutil.ReadAll(stdinCopy)
+		run.Log.PossibleError(err)
+
+		key, err := cacheKey(run, arguments.Key, stdin)
+		if err != nil {
+			run.Log.Error(err, fields.Middleware(cacheMiddleware))
+			return
+		}
+
+		// a dry run should show the commands that would be executed, so cached results are ignored
+		if !executionContext.CacheDisabled && !executionContext.DryRun {
+			cachedResult, err := store.load(key)
+			if err != nil {
+				run.Log.Warn(
+					fields.Symbol("📦"),
+					fields.Message("failed to read cached result"),
+					fields.Info(err.Error()),
+					fields.Middleware(cacheMiddleware),
+				)
+			}
+			if cachedResult != nil && !cachedResult.expired(cacheMiddleware.now(), ttl) {
+				run.Log.Debug(
+					fields.Symbol("📦"),
+					fields.Message("hit"),
+					fields.Info(fmt.Sprintf("cached %v ago", cacheMiddleware.now().Sub(cachedResult.Created).Round(time.Second))),
+					fields.Middleware(cacheMiddleware),
+				)
+				exitCode := cachedResult.ExitCode
+				run.ExitCode = &exitCode
+				_, err = stdoutAppender.Write(cachedResult.Stdout)
+				run.Log.PossibleError(err)
+				_, err = stderrAppender.Write(cachedResult.Stderr)
+				run.Log.PossibleError(err)
+				return
+			}
+		}
+
+		run.Log.Debug(
+			fields.Symbol("📦"),
+			fields.Message("miss"),
+			fields.Middleware(cacheMiddleware),
+		)
+		stdout, stderr, childRun := cacheMiddleware.execute(run, stdin, executionContext)
+		run.ExitCode = childRun.ExitCode
+		_, err = stdoutAppender.Write(stdout)
+		run.Log.PossibleError(err)
+		_, err = stderrAppender.Write(stderr)
+		run.Log.PossibleError(err)
+
+		exitCode := 0
+		if childRun.ExitCode != nil {
+			exitCode = *childRun.ExitCode
+		}
+		if executionContext.DryRun || childRun.Cancelled() || (exitCode != 0 && !arguments.Failures) {
+			return
+		}
+		err = store.save(key, &result{
+			Created:  cacheMiddleware.now(),
+			ExitCode: exitCode,
+			Stderr:   stderr,
+			Stdout:   stdout,
+		})
+		if err != nil {
+			run.Log.Warn(
+				fields.Symbol("📦"),
+				fields.Message("failed to cache result"),
+				fields.Info(err.Error()),
+				fields.Middleware(cacheMiddleware),
+			)
+		}
+	})
+}
+
+// execute runs the pipe again without the `cache` argument, returning its complete output
+func (cacheMiddleware Middleware) execute(
+	run *pipeline.Run,
+	stdin []byte,
+	executionContext *middleware.ExecutionContext,
+) ([]byte, []byte, *pipeline.Run) {
+	var stdout, stderr []byte
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(2)
+	childRun := executionContext.FullRun(
+		middleware.WithParentRun(run),
+		middleware.WithIdentifier(run.Identifier),
+		middleware.WithArguments(run.ArgumentsCopy()),
+		middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+			// need to remove the argument to prevent infinite recursion
+			// we can only do this within the full run, as the pipe's definition might contain a `cache` argument
+			childRun.Log.PossibleError(childRun.RemoveArgumentAtPath("cache"))
+			childRun.Log.Trace(
+				fields.DataStream(cacheMiddleware, "merging buffered parent stdin into child stdin")...,
+			)
+			childRun.Stdin.MergeWith(bytes.NewReader(stdin))
+			executionContext.AddConnection(run, childRun, "cache")
+		}),
+		middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+			childRun.Log.Trace(
+				fields.DataStream(cacheMiddleware, "copying child stdout")...,
+			)
+			stdoutCopy := childRun.Stdout.Copy()
+			childRun.Log.Trace(
+				fields.DataStream(cacheMiddleware, "copying child stderr")...,
+			)
+			stderrCopy := childRun.Stderr.Copy()
+			go func() {
+				defer waitGroup.Done()
+				var err error
+				stdout, err = ioutil.ReadAll(stdoutCopy)
+				run.Log.PossibleError(err)
+			}()
+			go func() {
+				defer waitGroup.Done()
+				var err error
+				stderr, err = ioutil.ReadAll(stderrCopy)
+				run.Log.PossibleError(err)
+			}()
+		}))
+	waitGroup.Wait()
+	childRun.Wait()
+	return stdout, stderr, childRun
+}
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type cacheTest struct {
+	t          *testing.T
+	directory  string
+	executions int
+	exitCode   int
+	now        time.Time
+}
+
+func (test *cacheTest) execute(arguments map[string]interface{}, input string, options ...middleware.ExecutionContextOption) *pipeline.Run {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.SetWorkingDirectory(test.directory)
+	identifier := "expensive"
+	run, err := pipeline.NewRun(&identifier, arguments, nil, parentRun)
+	require.Nil(test.t, err)
+	run.Log.SetLevel(logrus.DebugLevel)
+	run.Stdin.MergeWith(strings.NewReader(input))
+	executionContext := middleware.NewExecutionContext(append([]middleware.ExecutionContextOption{
+		middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+			test.executions++
+			require.Equal(test.t, "expensive", *childRun.Identifier)
+			require.False(test.t, childRun.HaveArgumentAtPath("cache"))
+			exitCode := test.exitCode
+			childRun.ExitCode = &exitCode
+			stdinCopy := childRun.Stdin.Copy()
+			childRun.DontCompleteBefore(func() {
+				childInput, err := ioutil.ReadAll(stdinCopy)
+				require.Nil(test.t, err)
+				require.Equal(test.t, input, string(childInput))
+			})
+			childRun.Stdout.Replace(strings.NewReader("output " + input))
+			childRun.Stderr.Replace(strings.NewReader("warning"))
+		})}, options...)...)
+	NewMiddlewareWithClock(func() time.Time {
+		return test.now
+	}).Apply(run, func(run *pipeline.Run) {
+		test.t.Fatal("next should not be called for the cached run")
+	}, executionContext)
+	run.Start()
+	run.Wait()
+	return run
+}
+
+func TestCache_ReplaysResult(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory}
+	arguments := map[string]interface{}{
+		"cache":   map[string]interface{}{},
+		"version": "1.0",
+	}
+
+	run := test.execute(arguments, "input")
+	require.Equal(t, 1, test.executions)
+	require.Equal(t, "output input", run.Stdout.String())
+	require.Equal(t, "warning", run.Stderr.String())
+	require.Equal(t, 0, *run.ExitCode)
+	require.Contains(t, run.Log.String(), "📦 cache | miss")
+	entries, err := ioutil.ReadDir(filepath.Join(test.directory, DefaultDirectory))
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+
+	run = test.execute(arguments, "input")
+	require.Equal(t, 1, test.executions)
+	require.Equal(t, "output input", run.Stdout.String())
+	require.Equal(t, "warning", run.Stderr.String())
+	require.Equal(t, 0, *run.ExitCode)
+	require.Contains(t, run.Log.String(), "📦 cache | hit")
+
+	// different input or arguments result in a different key
+	run = test.execute(arguments, "other input")
+	require.Equal(t, 2, test.executions)
+	require.Equal(t, "output other input", run.Stdout.String())
+	arguments["version"] = "2.0"
+	test.execute(arguments, "input")
+	require.Equal(t, 3, test.executions)
+}
+
+func TestCache_TTL(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory, now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	arguments := map[string]interface{}{
+		"cache": map[string]interface{}{
+			"ttl": "1h",
+		},
+	}
+
+	test.execute(arguments, "input")
+	test.now = test.now.Add(59 * time.Minute)
+	test.execute(arguments, "input")
+	require.Equal(t, 1, test.executions)
+	test.now = test.now.Add(2 * time.Minute)
+	test.execute(arguments, "input")
+	require.Equal(t, 2, test.executions)
+}
+
+func TestCache_KeyWithArgumentsAndFiles(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory}
+	arguments := map[string]interface{}{
+		"cache": map[string]interface{}{
+			"key": []interface{}{"package.name", "go.sum"},
+			"dir": "custom-cache",
+		},
+		"package": map[string]interface{}{
+			"name": "pipedream",
+		},
+		"irrelevant": "a",
+	}
+
+	test.execute(arguments, "input")
+	arguments["irrelevant"] = "b"
+	test.execute(arguments, "input")
+	require.Equal(t, 1, test.executions)
+
+	// creating or changing a listed file invalidates the result
+	require.Nil(t, ioutil.WriteFile(filepath.Join(test.directory, "go.sum"), []byte("v1"), 0644))
+	test.execute(arguments, "input")
+	require.Equal(t, 2, test.executions)
+	test.execute(arguments, "input")
+	require.Equal(t, 2, test.executions)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(test.directory, "go.sum"), []byte("v2"), 0644))
+	test.execute(arguments, "input")
+	require.Equal(t, 3, test.executions)
+
+	arguments["package"] = map[string]interface{}{"name": "other"}
+	test.execute(arguments, "input")
+	require.Equal(t, 4, test.executions)
+
+	entries, err := ioutil.ReadDir(filepath.Join(test.directory, "custom-cache"))
+	require.Nil(t, err)
+	require.Len(t, entries, 4)
+}
+
+func TestCache_KeyWithGlobPattern(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory}
+	arguments := map[string]interface{}{
+		"cache": map[string]interface{}{
+			"key": []interface{}{"src/*.go"},
+		},
+		"version": "1.0",
+	}
+	require.Nil(t, os.Mkdir(filepath.Join(directory, "src"), 0755))
+
+	test.execute(arguments, "input")
+	// only the listed entries are part of the key
+	arguments["version"] = "2.0"
+	test.execute(arguments, "input")
+	require.Equal(t, 1, test.executions)
+
+	// adding, changing or renaming a matching file invalidates the result
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "src", "a.go"), []byte("v1"), 0644))
+	test.execute(arguments, "input")
+	require.Equal(t, 2, test.executions)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "src", "a.go"), []byte("v2"), 0644))
+	test.execute(arguments, "input")
+	require.Equal(t, 3, test.executions)
+	require.Nil(t, os.Rename(filepath.Join(directory, "src", "a.go"), filepath.Join(directory, "src", "b.go")))
+	test.execute(arguments, "input")
+	require.Equal(t, 4, test.executions)
+
+	// other files do not
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "src", "README.md"), []byte("docs"), 0644))
+	test.execute(arguments, "input")
+	require.Equal(t, 4, test.executions)
+}
+
+func TestCache_KeyWithMissingEntry(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory}
+	arguments := map[string]interface{}{
+		"cache": map[string]interface{}{
+			"key": []interface{}{"target"},
+		},
+	}
+
+	test.execute(arguments, "input")
+	test.execute(arguments, "input")
+	require.Equal(t, 1, test.executions)
+	// creating a file of the same name invalidates the result
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "target"), []byte("content"), 0644))
+	test.execute(arguments, "input")
+	require.Equal(t, 2, test.executions)
+	// an argument takes precedence over a file of the same name
+	arguments["target"] = "linux"
+	test.execute(arguments, "input")
+	require.Equal(t, 3, test.executions)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "target"), []byte("changed"), 0644))
+	test.execute(arguments, "input")
+	require.Equal(t, 3, test.executions)
+}
+
+func TestCache_Failures(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory}
+	test.exitCode = 2
+	arguments := map[string]interface{}{
+		"cache": map[string]interface{}{},
+	}
+
+	run := test.execute(arguments, "input")
+	require.Equal(t, 2, *run.ExitCode)
+	test.execute(arguments, "input")
+	require.Equal(t, 2, test.executions)
+
+	arguments["cache"] = map[string]interface{}{
+		"failures": true,
+	}
+	test.execute(arguments, "input")
+	run = test.execute(arguments, "input")
+	require.Equal(t, 3, test.executions)
+	require.Equal(t, 2, *run.ExitCode)
+	require.Equal(t, "output input", run.Stdout.String())
+}
+
+func TestCache_Disabled(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory}
+	arguments := map[string]interface{}{
+		"cache": map[string]interface{}{},
+	}
+
+	test.execute(arguments, "input")
+	test.execute(arguments, "input", middleware.WithCacheDisabled(true))
+	require.Equal(t, 2, test.executions)
+	// the result is still cached
+	test.execute(arguments, "input")
+	require.Equal(t, 2, test.executions)
+}
+
+func TestCache_DryRun(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	test := &cacheTest{t: t, directory: directory}
+	arguments := map[string]interface{}{
+		"cache": map[string]interface{}{},
+	}
+
+	test.execute(arguments, "input")
+	// cached results are neither used nor written during a dry run
+	test.execute(arguments, "input", middleware.WithDryRun(true))
+	require.Equal(t, 2, test.executions)
+	test.execute(arguments, "other input", middleware.WithDryRun(true))
+	require.Equal(t, 3, test.executions)
+	entries, err := ioutil.ReadDir(filepath.Join(test.directory, DefaultDirectory))
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestCache_Errors(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"cache": map[string]interface{}{},
+	}, nil, nil)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {}, nil)
+	run.Start()
+	run.Wait()
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.String(), "results cannot be cached without an execution context")
+
+	run, _ = pipeline.NewRun(nil, map[string]interface{}{
+		"cache": map[string]interface{}{
+			"ttl": "forever",
+		},
+	}, nil, nil)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {}, middleware.NewExecutionContext())
+	run.Start()
+	run.Wait()
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.String(), "invalid cache ttl \"forever\"")
+
+	run, _ = pipeline.NewRun(nil, map[string]interface{}{
+		"cache": map[string]interface{}{
+			"key": []interface{}{"[unterminated"},
+		},
+	}, nil, nil)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {}, middleware.NewExecutionContext())
+	run.Start()
+	run.Wait()
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.String(), "invalid file pattern `[unterminated` for cache key")
+}
+
+func TestCache_WithoutArgument(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+	nextCalled := false
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {
+		nextCalled = true
+	}, nil)
+	require.True(t, nextCalled)
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultDirectory is where results are cached, relative to the pipe's working directory
+const DefaultDirectory = ".pipedream/cache"
+
+var entryFileNameRegex = regexp.MustCompile(`^[0-9a-f]{64}\.json$`)
+
+// result is a pipe's cached output
+type result struct {
+	Created  time.Time `json:"created"`
+	ExitCode int       `json:"exitCode"`
+	Stderr   []byte    `json:"stderr"`
+	Stdout   []byte    `json:"stdout"`
+}
+
+// expired indicates whether the result is older than the time to live (zero meaning it never expires)
+func (cachedResult *result) expired(now time.Time, ttl time.Duration) bool {
+	return ttl > 0 && now.Sub(cachedResult.Created) > ttl
+}
+
+// store persists results as JSON files named after their keys
+type store struct {
+	directory string
+}
+
+func newStore(directory string) store {
+	return store{directory: directory}
+}
+
+func (resultStore store) path(key string) string {
+	return filepath.Join(resultStore.directory, key+".json")
+}
+
+// load returns the result cached under the key, or nil if there is none
+func (resultStore store) load(key string) (*result, error) {
+	data, err := ioutil.ReadFile(resultStore.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cachedResult := &result{}
+	if err = json.Unmarshal(data, cachedResult); err != nil {
+		return nil, fmt.Errorf("invalid cache entry %v: %w", key, err)
+	}
+	return cachedResult, nil
+}
+
+// save writes the result to a temporary file first, so that concurrent runs never read a partial entry
+func (resultStore store) save(key string, cachedResult *result) error {
+	data, err := json.Marshal(cachedResult)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(resultStore.directory, 0755); err != nil {
+		return err
+	}
+	temporaryFile, err := ioutil.TempFile(resultStore.directory, "."+key+".*")
+	if err != nil {
+		return err
+	}
+	_, err = temporaryFile.Write(data)
+	if closeErr := temporaryFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(temporaryFile.Name())
+		return err
+	}
+	return os.Rename(temporaryFile.Name(), resultStore.path(key))
+}
+
+// Clear removes all cached results from the directory, returning the number of removed entries
+//
+// Other files in the directory are left untouched.
+func Clear(directory string) (int, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	removed := 0
+	for _, file := range files {
+		if file.IsDir() || !entryFileNameRegex.MatchString(file.Name()) {
+			continue
+		}
+		if err = os.Remove(filepath.Join(directory, file.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// cacheKey hashes the run's identifier, input and arguments, as well as the contents of any files listed in the key
+//
+// If no key is specified, all arguments are included. Otherwise, only the listed entries are:
+// an entry is interpreted as an argument (use dots for nested values), if the run has it,
+// and as a path of files relative to the pipe's working directory, if it contains a glob pattern or the path exists.
+// Entries that are neither are part of the key as well, so that passing the argument or creating the file
+// invalidates the cached result.
+func cacheKey(run *pipeline.Run, key []string, stdin []byte) (string, error) {
+	identifier := ""
+	if run.Identifier != nil {
+		identifier = *run.Identifier
+	}
+	keyArguments := run.ArgumentsCopy()
+	delete(keyArguments, "cache")
+	files := make(map[string]map[string]string, len(key))
+	if key != nil {
+		keyArguments = make(map[string]interface{}, len(key))
+	}
+	for _, entry := range key {
+		argumentPath := strings.Split(entry, ".")
+		if run.HaveArgumentAtPath(argumentPath...) {
+			value, err := run.ArgumentAtPath(argumentPath...)
+			if err != nil {
+				return "", err
+			}
+			keyArguments[entry] = value
+			continue
+		}
+		fileHashes, err := hashFiles(run, entry)
+		if err != nil {
+			return "", err
+		}
+		if fileHashes == nil {
+			keyArguments[entry] = nil
+			continue
+		}
+		files[entry] = fileHashes
+	}
+	// maps are marshalled with sorted keys, so the serialization is deterministic
+	serialized, err := json.Marshal(map[string]interface{}{
+		"arguments":  keyArguments,
+		"files":      files,
+		"identifier": identifier,
+		"stdin":      stdin,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to compute cache key: %w", err)
+	}
+	hash := sha256.Sum256(serialized)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// hashFiles hashes the contents of the files matching the pattern, by path relative to the pipe's working directory
+//
+// The result is nil if the pattern is not a glob pattern and the path does not exist. Directories are skipped.
+func hashFiles(run *pipeline.Run, pattern string) (map[string]string, error) {
+	matches, err := filepath.Glob(run.ResolvePath(pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid file pattern `%v` for cache key: %w", pattern, err)
+	}
+	if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, nil
+	}
+	result := make(map[string]string, len(matches))
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file `%v` for cache key: %w", match, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(match)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file `%v` for cache key: %w", match, err)
+		}
+		relativePath, err := filepath.Rel(run.WorkingDirectory(), match)
+		if err != nil {
+			relativePath = match
+		}
+		fileHash := sha256.Sum256(data)
+		result[relativePath] = hex.EncodeToString(fileHash[:])
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	resultStore := newStore(filepath.Join(directory, "nested"))
+	key := strings.Repeat("a", 64)
+
+	cachedResult, err := resultStore.load(key)
+	require.Nil(t, err)
+	require.Nil(t, cachedResult)
+
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Nil(t, resultStore.save(key, &result{
+		Created:  created,
+		ExitCode: 1,
+		Stderr:   []byte("error"),
+		Stdout:   []byte("\x00binary"),
+	}))
+	cachedResult, err = resultStore.load(key)
+	require.Nil(t, err)
+	require.Equal(t, &result{
+		Created:  created,
+		ExitCode: 1,
+		Stderr:   []byte("error"),
+		Stdout:   []byte("\x00binary"),
+	}, cachedResult)
+	require.False(t, cachedResult.expired(created.Add(time.Hour), 0))
+	require.False(t, cachedResult.expired(created.Add(time.Hour), 2*time.Hour))
+	require.True(t, cachedResult.expired(created.Add(time.Hour), time.Minute))
+
+	require.Nil(t, ioutil.WriteFile(resultStore.path(key), []byte("invalid"), 0644))
+	_, err = resultStore.load(key)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid cache entry")
+}
+
+func TestClear(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-cache-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+
+	removed, err := Clear(filepath.Join(directory, "missing"))
+	require.Nil(t, err)
+	require.Equal(t, 0, removed)
+
+	resultStore := newStore(directory)
+	require.Nil(t, resultStore.save(strings.Repeat("a", 64), &result{}))
+	require.Nil(t, resultStore.save(strings.Repeat("b", 64), &result{}))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "notes.json"), []byte("{}"), 0644))
+
+	removed, err = Clear(directory)
+	require.Nil(t, err)
+	require.Equal(t, 2, removed)
+	entries, err := ioutil.ReadDir(directory)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "notes.json", entries[0].Name())
+}
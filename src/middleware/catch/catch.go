@@ -18,6 +18,13 @@ func (Middleware) String() string {
 	return "catch"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"catch": {&pipeline.Reference{}},
+	}
+}
+
 // NewMiddleware creates a new Middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
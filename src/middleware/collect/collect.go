@@ -2,6 +2,10 @@
 package collect
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/custom/toml"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
@@ -10,14 +14,14 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 )
 
-// Middleware is a directory navigator
+// Middleware is a data aggregator
 type Middleware struct {
 	fileWriter func(fileName string, data string) error
-	WorkingDir string
 }
 
 // String is a human-readable description
@@ -25,19 +29,56 @@ func (Middleware) String() string {
 	return "collect"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"collect": {&arguments},
+	}
+}
+
 // NewMiddleware creates a new Middleware instance
 func NewMiddleware() Middleware {
-	workingDir, _ := os.Getwd()
 	return Middleware{
 		fileWriter: defaultWriteToFile,
-		WorkingDir: workingDir,
 	}
 }
 
 type middlewareArguments struct {
-	File   *string
-	Nested *bool
-	Values []pipeline.Reference
+	ContinueOnError bool
+	Details         bool
+	FailFast        bool
+	File            *string
+	Format          string
+	Keys            []string
+	Nested          *bool
+	Ordered         bool
+	Parallel        int
+	Values          []pipeline.Reference
+}
+
+func newMiddlewareArguments() middlewareArguments {
+	return middlewareArguments{
+		ContinueOnError: false,
+		Details:         false,
+		FailFast:        false,
+		File:            nil,
+		Format:          "yaml",
+		Keys:            nil,
+		Nested:          nil,
+		Ordered:         false,
+		Parallel:        0,
+		Values:          nil,
+	}
+}
+
+// childResult is the outcome of a single child's execution
+type childResult struct {
+	key      string
+	stdout   []byte
+	stderr   []byte
+	exitCode int
+	failed   bool
 }
 
 // Apply is where the middleware's logic resides
@@ -50,11 +91,26 @@ func (collectMiddleware Middleware) Apply(
 	next func(*pipeline.Run),
 	executionContext *middleware.ExecutionContext,
 ) {
-	collectArguments := middlewareArguments{}
+	collectArguments := newMiddlewareArguments()
 	pipeline.ParseArguments(&collectArguments, "collect", run)
 
 	if len(collectArguments.Values) > 0 {
 		childIdentifiers, childArguments, info := pipeline.CollectReferences(collectArguments.Values)
+		keys, err := childKeys(childIdentifiers, collectArguments)
+		if err != nil {
+			run.Log.Error(err, fields.Middleware(collectMiddleware))
+			next(run)
+			return
+		}
+		marshal, err := marshaller(collectArguments.Format)
+		if err != nil {
+			run.Log.Error(err, fields.Middleware(collectMiddleware))
+			next(run)
+			return
+		}
+		if collectArguments.Parallel > 0 {
+			run.LimitParallelism(collectArguments.Parallel)
+		}
 		fileName := "-"
 		if collectArguments.File != nil {
 			fileName = filepath.Base(*collectArguments.File)
@@ -67,62 +123,258 @@ func (collectMiddleware Middleware) Apply(
 		)
 
 		waitGroup := sync.WaitGroup{}
-		results := make(map[string]interface{}, len(collectArguments.Values))
-		resultsMutex := sync.Mutex{}
+		results := make([]*childResult, 0, len(childIdentifiers))
+		childRuns := make([]*pipeline.Run, 0, len(childIdentifiers))
+		// failedResult is the first failed child, if any
+		var failedResult *childResult = nil
+		failureMutex := sync.Mutex{}
+		cancelRemainingChildren := func() {
+			for _, childRun := range childRuns {
+				if !childRun.Completed() {
+					childRun.Log.PossibleError(executionContext.CancelWithDescendants(childRun))
+				}
+			}
+		}
 		for index, childIdentifier := range childIdentifiers {
-			arguments := childArguments[index]
-			identifier := childIdentifier
-			if identifier != nil {
-				executionContext.FullRun(
-					middleware.WithParentRun(run),
-					middleware.WithIdentifier(identifier),
-					middleware.WithArguments(arguments),
-					middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
-						run.Log.Trace(
-							fields.DataStream(collectMiddleware, "copy child stdout into parent stdout")...,
-						)
-						childStdout := childRun.Stdout.Copy()
-						waitGroup.Add(1)
-						childRun.DontCompleteBefore(func() {
-							defer waitGroup.Done()
-							completeChildStdout, err := ioutil.ReadAll(childStdout)
+			if keys[index] == "" {
+				// anonymous children without a custom key cannot be stored
+				continue
+			}
+			result := &childResult{key: keys[index]}
+			results = append(results, result)
+			childRun := executionContext.FullRun(
+				middleware.WithParentRun(run),
+				middleware.WithIdentifier(childIdentifier),
+				middleware.WithArguments(childArguments[index]),
+				middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+					run.Log.Trace(
+						fields.DataStream(collectMiddleware, "copy child stdout and stderr")...,
+					)
+					childStdout := childRun.Stdout.Copy()
+					childStderr := childRun.Stderr.Copy()
+					waitGroup.Add(1)
+					childRun.DontCompleteBefore(func() {
+						stderrWaitGroup := sync.WaitGroup{}
+						stderrWaitGroup.Add(1)
+						go func() {
+							defer stderrWaitGroup.Done()
+							completeChildStderr, err := ioutil.ReadAll(childStderr)
 							childRun.Log.PossibleError(err)
-							resultsMutex.Lock()
-							defer resultsMutex.Unlock()
-							if collectArguments.Nested != nil && *collectArguments.Nested {
-								var childResult interface{}
-								err = yaml.Unmarshal(completeChildStdout, &childResult)
-								childRun.Log.PossibleError(err)
-								results[*identifier] = childResult
-							} else {
-								results[*identifier] = string(completeChildStdout)
+							result.stderr = completeChildStderr
+						}()
+						completeChildStdout, err := ioutil.ReadAll(childStdout)
+						childRun.Log.PossibleError(err)
+						result.stdout = completeChildStdout
+						stderrWaitGroup.Wait()
+					})
+					go func() {
+						defer waitGroup.Done()
+						childRun.Wait()
+						if childRun.ExitCode != nil {
+							result.exitCode = *childRun.ExitCode
+						}
+						result.failed = result.exitCode != 0
+						if !result.failed || !(collectArguments.FailFast || collectArguments.ContinueOnError) {
+							return
+						}
+						failureMutex.Lock()
+						defer failureMutex.Unlock()
+						if failedResult == nil {
+							failedResult = result
+							if collectArguments.FailFast {
+								cancelRemainingChildren()
 							}
-						})
-					}))
+						}
+					}()
+				}))
+			failureMutex.Lock()
+			childRuns = append(childRuns, childRun)
+			if failedResult != nil && collectArguments.FailFast && !childRun.Completed() {
+				childRun.Log.PossibleError(executionContext.CancelWithDescendants(childRun))
 			}
+			failureMutex.Unlock()
 		}
 		stdoutWriteCloser := run.Stdout.WriteCloser()
 		run.DontCompleteBefore(func() {
+			defer func() {
+				_ = stdoutWriteCloser.Close()
+			}()
 			waitGroup.Wait()
-			yamlResults, err := yaml.Marshal(results)
-			run.Log.PossibleError(err)
+			if failedResult != nil {
+				run.Log.Error(
+					collectMiddleware.failureError(failedResult, results, collectArguments.FailFast),
+					fields.Middleware(collectMiddleware),
+				)
+				if collectArguments.FailFast {
+					return
+				}
+			}
+			collectedData, err := marshal(collectedValues(results, collectArguments, run))
+			if err != nil {
+				run.Log.Error(err, fields.Middleware(collectMiddleware))
+				return
+			}
 			if collectArguments.File == nil {
-				_, err = stdoutWriteCloser.Write(yamlResults)
+				_, err = stdoutWriteCloser.Write(collectedData)
 				run.Log.PossibleError(err)
+			} else if executionContext.DryRun {
+				run.Log.Debug(
+					fields.Symbol("🧺"),
+					fields.Message(fileName),
+					fields.Info("skipped in dry run"),
+					fields.Middleware(collectMiddleware),
+				)
 			} else {
 				err = collectMiddleware.fileWriter(
-					filepath.Join(collectMiddleware.WorkingDir, *collectArguments.File),
-					string(yamlResults),
+					run.ResolvePath(*collectArguments.File),
+					string(collectedData),
 				)
 				run.Log.PossibleError(err)
 			}
-			_ = stdoutWriteCloser.Close()
 		})
 	}
 
 	next(run)
 }
 
+// childKeys determines the key under which each child's result is stored
+//
+// This is the child's identifier, unless a custom key has been provided in the `keys` argument.
+// Anonymous children without a custom key are assigned an empty key and will be skipped.
+func childKeys(childIdentifiers []*string, collectArguments middlewareArguments) ([]string, error) {
+	if len(collectArguments.Keys) > len(childIdentifiers) {
+		return nil, fmt.Errorf("%v keys provided for %v values", len(collectArguments.Keys), len(childIdentifiers))
+	}
+	keys := make([]string, 0, len(childIdentifiers))
+	usedKeys := make(map[string]bool, len(childIdentifiers))
+	for index, childIdentifier := range childIdentifiers {
+		key := ""
+		if index < len(collectArguments.Keys) {
+			key = collectArguments.Keys[index]
+		}
+		if key == "" && childIdentifier != nil {
+			key = *childIdentifier
+		}
+		if key == "" {
+			keys = append(keys, key)
+			continue
+		}
+		if usedKeys[key] {
+			return nil, fmt.Errorf("duplicate key %q, provide distinct keys using the `keys` argument", key)
+		}
+		usedKeys[key] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (collectMiddleware Middleware) failureError(failedResult *childResult, results []*childResult, failFast bool) error {
+	if failFast {
+		return fmt.Errorf("aborted, as %v failed (exit code %v)", failedResult.key, failedResult.exitCode)
+	}
+	failures := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.failed {
+			failures = append(failures, fmt.Sprintf("%v (exit code %v)", result.key, result.exitCode))
+		}
+	}
+	return fmt.Errorf("values failed: %v", strings.Join(failures, ", "))
+}
+
+// orderedValues is a map that remembers the order of its keys
+type orderedValues struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func collectedValues(results []*childResult, collectArguments middlewareArguments, run *pipeline.Run) orderedValues {
+	collected := orderedValues{
+		keys:   make([]string, 0, len(results)),
+		values: make(map[string]interface{}, len(results)),
+	}
+	for _, result := range results {
+		var value interface{} = string(result.stdout)
+		if collectArguments.Nested != nil && *collectArguments.Nested {
+			var nestedValue interface{}
+			err := yaml.Unmarshal(result.stdout, &nestedValue)
+			run.Log.PossibleError(err)
+			value = nestedValue
+		}
+		if collectArguments.Details {
+			value = map[string]interface{}{
+				"stdout":   value,
+				"stderr":   string(result.stderr),
+				"exitCode": result.exitCode,
+			}
+		}
+		collected.keys = append(collected.keys, result.key)
+		collected.values[result.key] = value
+	}
+	if !collectArguments.Ordered {
+		sort.Strings(collected.keys)
+	}
+	return collected
+}
+
+func marshaller(format string) (func(orderedValues) ([]byte, error), error) {
+	switch format {
+	case "yaml":
+		return marshalYaml, nil
+	case "json":
+		return marshalJSON, nil
+	case "toml":
+		return func(collected orderedValues) ([]byte, error) {
+			return toml.MarshalOrdered(collected.keys, collected.values)
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid format %q, expected one of `yaml`, `json`, `toml`", format)
+	}
+}
+
+// marshalYaml marshals each value separately, as yaml maps do not preserve the order of their keys
+func marshalYaml(collected orderedValues) ([]byte, error) {
+	if len(collected.keys) == 0 {
+		return yaml.Marshal(collected.values)
+	}
+	buffer := new(bytes.Buffer)
+	for _, key := range collected.keys {
+		result, err := yaml.Marshal(map[string]interface{}{key: collected.values[key]})
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(result)
+	}
+	return buffer.Bytes(), nil
+}
+
+func marshalJSON(collected orderedValues) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	buffer.WriteString("{")
+	for index, key := range collected.keys {
+		if index > 0 {
+			buffer.WriteString(",")
+		}
+		keyData, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueData, err := json.Marshal(collected.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(keyData)
+		buffer.WriteString(":")
+		buffer.Write(valueData)
+	}
+	buffer.WriteString("}")
+	result := new(bytes.Buffer)
+	if err := json.Indent(result, buffer.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	result.WriteString("\n")
+	return result.Bytes(), nil
+}
+
 func defaultWriteToFile(fileName string, data string) error {
 	file, err := os.Create(fileName)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCollect_Apply(t *testing.T) {
@@ -59,6 +60,7 @@ func TestCollect_ApplyWithFile(t *testing.T) {
 			},
 		},
 	}, nil, nil)
+	run.SetWorkingDirectory("/test-dir")
 
 	run.Log.SetLevel(logrus.DebugLevel)
 	filePath := ""
@@ -68,7 +70,6 @@ func TestCollect_ApplyWithFile(t *testing.T) {
 		result = data
 		return nil
 	},
-		WorkingDir: "test-dir",
 	}.Apply(
 		run,
 		func(pipelineRun *pipeline.Run) {
@@ -85,9 +86,318 @@ func TestCollect_ApplyWithFile(t *testing.T) {
 	require.Equal(t, 0, run.Log.ErrorCount())
 	require.Equal(t, "pipe1: |\n  result of pipeline `pipe1`\npipe2: |\n  result of pipeline `pipe2`\n", result)
 	require.Equal(t, "", run.Stdout.String())
-	require.Equal(t, "test-dir/test.yaml", filePath)
+	require.Equal(t, "/test-dir/test.yaml", filePath)
 
 	logString := run.Log.String()
 	require.Contains(t, logString, "collect")
 	require.Contains(t, logString, "pipe1, pipe2")
 }
+
+func TestCollect_DryRun(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"collect": map[string]interface{}{
+			"file":   "test.yaml",
+			"values": []interface{}{"pipe1"},
+		},
+	}, nil, nil)
+	run.Log.SetLevel(logrus.DebugLevel)
+	fileWritten := false
+	Middleware{fileWriter: func(file string, data string) error {
+		fileWritten = true
+		return nil
+	},
+	}.Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithDryRun(true),
+			middleware.WithExecutionFunction(func(childRun *pipeline.Run) {}),
+		))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.False(t, fileWritten)
+	require.Contains(t, run.Log.String(), "skipped in dry run")
+}
+
+func TestCollect_OrderedJsonWithKeys(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"collect": map[string]interface{}{
+			"format":  "json",
+			"ordered": true,
+			"keys":    []interface{}{"", "version"},
+			"values": []interface{}{
+				"zebra",
+				map[interface{}]interface{}{nil: map[string]interface{}{
+					"arg": "value",
+				}},
+				"aardvark",
+			},
+		},
+	}, nil, nil)
+
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					identifier := "anonymous"
+					if childRun.Identifier != nil {
+						identifier = *childRun.Identifier
+					}
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("result of %v", identifier)))
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, `{
+  "zebra": "result of zebra",
+  "version": "result of anonymous",
+  "aardvark": "result of aardvark"
+}
+`, run.Stdout.String())
+}
+
+func TestCollect_DetailsAsToml(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"collect": map[string]interface{}{
+			"format":  "toml",
+			"details": true,
+			"values": []interface{}{
+				"second",
+				"first",
+			},
+		},
+	}, nil, nil)
+
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("result of %v", *childRun.Identifier)))
+					childRun.Stderr.Replace(strings.NewReader(fmt.Sprintf("warning from %v", *childRun.Identifier)))
+					if *childRun.Identifier == "second" {
+						exitCode := 2
+						childRun.ExitCode = &exitCode
+					}
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, `[first]
+exitCode = 0
+stderr = "warning from first"
+stdout = "result of first"
+
+[second]
+exitCode = 2
+stderr = "warning from second"
+stdout = "result of second"
+`, run.Stdout.String())
+}
+
+func TestCollect_FailedChild(t *testing.T) {
+	filesWritten := 0
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"collect": map[string]interface{}{
+			"file": "results.yaml",
+			"values": []interface{}{
+				"success",
+				"failure",
+				"other-failure",
+			},
+		},
+	}, nil, nil)
+
+	Middleware{fileWriter: func(file string, data string) error {
+		filesWritten++
+		return nil
+	}}.Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("result of %v", *childRun.Identifier)))
+					exitCodes := map[string]int{"success": 0, "failure": 1, "other-failure": 3}
+					exitCode := exitCodes[*childRun.Identifier]
+					childRun.ExitCode = &exitCode
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, 1, filesWritten)
+}
+
+func TestCollect_ContinueOnError(t *testing.T) {
+	result := ""
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"collect": map[string]interface{}{
+			"continueOnError": true,
+			"file":            "results.yaml",
+			"values": []interface{}{
+				"success",
+				"failure",
+				"other-failure",
+			},
+		},
+	}, nil, nil)
+
+	Middleware{fileWriter: func(file string, data string) error {
+		result = data
+		return nil
+	}}.Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("result of %v", *childRun.Identifier)))
+					exitCodes := map[string]int{"success": 0, "failure": 1, "other-failure": 3}
+					exitCode := exitCodes[*childRun.Identifier]
+					childRun.ExitCode = &exitCode
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Equal(t, "values failed: failure (exit code 1), other-failure (exit code 3)", run.Log.LastError().Error())
+	require.Equal(t, "failure: result of failure\nother-failure: result of other-failure\nsuccess: result of success\n", result)
+}
+
+func TestCollect_AnonymousValueWithoutKey(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"collect": map[string]interface{}{
+			"values": []interface{}{
+				map[interface{}]interface{}{nil: map[string]interface{}{}},
+				"named",
+			},
+		},
+	}, nil, nil)
+
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("result of %v", *childRun.Identifier)))
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "named: result of named\n", run.Stdout.String())
+}
+
+func TestCollect_FailFast(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"collect": map[string]interface{}{
+			"failFast": true,
+			"values": []interface{}{
+				"slow",
+				"failure",
+			},
+		},
+	}, nil, nil)
+
+	slowRunCancelled := make(chan bool, 1)
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					if *childRun.Identifier == "slow" {
+						childRun.DontCompleteBefore(func() {
+							select {
+							case <-childRun.CancellationSignal():
+								slowRunCancelled <- true
+							case <-time.After(5 * time.Second):
+								slowRunCancelled <- false
+							}
+						})
+						return
+					}
+					exitCode := 1
+					childRun.ExitCode = &exitCode
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.True(t, <-slowRunCancelled)
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Equal(t, "aborted, as failure failed (exit code 1)", run.Log.LastError().Error())
+	require.Equal(t, "", run.Stdout.String())
+}
+
+func TestCollect_InvalidArguments(t *testing.T) {
+	for name, testCase := range map[string]struct {
+		arguments map[string]interface{}
+		error     string
+	}{
+		"duplicate keys": {
+			arguments: map[string]interface{}{
+				"keys":   []interface{}{"first", "first"},
+				"values": []interface{}{"a", "b"},
+			},
+			error: "duplicate key \"first\", provide distinct keys using the `keys` argument",
+		},
+		"too many keys": {
+			arguments: map[string]interface{}{
+				"keys":   []interface{}{"first", "second"},
+				"values": []interface{}{"a"},
+			},
+			error: "2 keys provided for 1 values",
+		},
+		"invalid format": {
+			arguments: map[string]interface{}{
+				"format": "xml",
+				"values": []interface{}{"a"},
+			},
+			error: "invalid format \"xml\", expected one of `yaml`, `json`, `toml`",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run, _ := pipeline.NewRun(nil, map[string]interface{}{
+				"collect": testCase.arguments,
+			}, nil, nil)
+
+			nextCalled := false
+			NewMiddleware().Apply(
+				run,
+				func(pipelineRun *pipeline.Run) {
+					nextCalled = true
+				},
+				middleware.NewExecutionContext(
+					middleware.WithExecutionFunction(func(childRun *pipeline.Run) {}),
+				),
+			)
+			run.Start()
+			run.Wait()
+
+			require.True(t, nextCalled)
+			require.Equal(t, 1, run.Log.ErrorCount())
+			require.Equal(t, testCase.error, run.Log.LastError().Error())
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// Package cond provides a middleware that executes the first of several branches whose condition is satisfied
+package cond
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/logging/fields"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io"
+	"io/ioutil"
+)
+
+// Middleware is a multi-way conditional executor
+type Middleware struct {
+}
+
+// String is a human-readable description
+func (Middleware) String() string {
+	return "cond"
+}
+
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"cond": {&[]branch{}},
+	}
+}
+
+// NewMiddleware creates a new Middleware instance
+func NewMiddleware() Middleware {
+	return Middleware{}
+}
+
+type branch struct {
+	Pipe   pipeline.Reference
+	Unless string
+	When   string
+}
+
+// Apply is where the middleware's logic resides
+//
+// It adapts the run based on its slice of the run's arguments.
+// It may also trigger side effects such as executing shell commands or full runs of other pipelines.
+// When done, this function should call next in order to continue unwinding the stack.
+func (condMiddleware Middleware) Apply(
+	run *pipeline.Run,
+	next func(*pipeline.Run),
+	executionContext *middleware.ExecutionContext,
+) {
+	branches := make([]branch, 0, 4)
+	if !pipeline.ParseArguments(&branches, "cond", run) || len(branches) == 0 {
+		next(run)
+		return
+	}
+
+	conditions, err := parseConditions(branches, run)
+	if err != nil {
+		run.Log.Error(err, fields.Middleware(condMiddleware))
+		next(run)
+		return
+	}
+
+	next(run)
+
+	run.Log.Debug(
+		fields.Symbol("🔀"),
+		fields.Message("cond"),
+		fields.Info(fmt.Sprintf("%v branch(es)", len(branches))),
+		fields.Middleware(condMiddleware),
+	)
+	run.Log.Trace(
+		fields.DataStream(condMiddleware, "copying stdin")...,
+	)
+	stdinCopy := run.Stdin.Copy()
+	run.Log.Trace(
+		fields.DataStream(condMiddleware, "creating stdout writer")...,
+	)
+	stdoutAppender := run.Stdout.WriteCloser()
+	run.Log.Trace(
+		fields.DataStream(condMiddleware, "creating stderr writer")...,
+	)
+	stderrAppender := run.Stderr.WriteCloser()
+	parentLogWriter := run.Log.AddWriteCloserEntry()
+	closeWriters := func() {
+		run.Log.PossibleError(stdoutAppender.Close())
+		run.Log.PossibleError(stderrAppender.Close())
+		run.Log.PossibleError(parentLogWriter.Close())
+	}
+	go func() {
+		// the input is only read if a condition requires it,
+		// otherwise it is streamed to the selected branch
+		var input *string = nil
+		readInput := func() *string {
+			if input == nil {
+				inputData, err := ioutil.ReadAll(stdinCopy)
+				run.Log.PossibleError(err)
+				inputString := string(inputData)
+				input = &inputString
+			}
+			return input
+		}
+		remainingInput := func() io.Reader {
+			if input != nil {
+				return bytes.NewReader([]byte(*input))
+			}
+			return stdinCopy
+		}
+
+		for index, branch := range branches {
+			satisfied := true
+			if condition := conditions[index]; condition != nil {
+				var conditionInput *string = nil
+				if condition.NeedsInput {
+					conditionInput = readInput()
+				}
+				conditionSatisfied, err := condition.Evaluate(run, conditionInput, executionContext)
+				if err != nil {
+					run.Log.Error(err, fields.Middleware(condMiddleware))
+					_, _ = io.Copy(ioutil.Discard, remainingInput())
+					closeWriters()
+					return
+				}
+				// `unless` conditions are negated
+				satisfied = conditionSatisfied != (branch.Unless != "")
+			}
+			if satisfied {
+				condMiddleware.executeBranch(
+					run,
+					executionContext,
+					branch.Pipe,
+					index,
+					remainingInput(),
+					stdoutAppender,
+					stderrAppender,
+					parentLogWriter,
+				)
+				return
+			}
+		}
+
+		run.MarkSkipped()
+		run.Log.Debug(
+			fields.Symbol("🔀"),
+			fields.Message("no branch satisfied"),
+			fields.Color("lightgrey"),
+			fields.Middleware(condMiddleware),
+		)
+		// the provided input should not be discarded, but passed through
+		// (there might be a next invocation in the chain)
+		_, err := io.Copy(stdoutAppender, remainingInput())
+		run.Log.PossibleError(err)
+		closeWriters()
+	}()
+}
+
+func parseConditions(branches []branch, run *pipeline.Run) ([]*middleware.Condition, error) {
+	conditions := make([]*middleware.Condition, len(branches))
+	for index, branch := range branches {
+		if len(branch.Pipe) == 0 {
+			return nil, fmt.Errorf("missing `pipe` argument in branch %v", index)
+		}
+		if branch.When != "" && branch.Unless != "" {
+			return nil, fmt.Errorf("branch %v has both a `when` and an `unless` condition", index)
+		}
+		source := branch.When
+		if branch.Unless != "" {
+			source = branch.Unless
+		}
+		if source == "" {
+			// branches without a condition are always executed, if reached
+			continue
+		}
+		condition, err := middleware.NewCondition(source, run)
+		if err != nil {
+			return nil, err
+		}
+		conditions[index] = condition
+	}
+	return conditions, nil
+}
+
+func (condMiddleware Middleware) executeBranch(
+	run *pipeline.Run,
+	executionContext *middleware.ExecutionContext,
+	reference pipeline.Reference,
+	index int,
+	input io.Reader,
+	stdoutAppender io.WriteCloser,
+	stderrAppender io.WriteCloser,
+	parentLogWriter io.WriteCloser,
+) {
+	identifiers, arguments, _ := pipeline.CollectReferences([]pipeline.Reference{reference})
+	branchName := "~"
+	if identifiers[0] != nil {
+		branchName = *identifiers[0]
+	}
+	run.Log.Debug(
+		fields.Symbol("🔀"),
+		fields.Message(fmt.Sprintf("branch %v satisfied", index)),
+		fields.Info(branchName),
+		fields.Middleware(condMiddleware),
+	)
+	executionContext.FullRun(
+		middleware.WithIdentifier(identifiers[0]),
+		middleware.WithParentRun(run),
+		middleware.WithLogWriter(parentLogWriter),
+		middleware.WithArguments(arguments[0]),
+		middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+			childRun.Log.Trace(
+				fields.DataStream(condMiddleware, "merging parent stdin into child stdin")...,
+			)
+			childRun.Stdin.MergeWith(input)
+		}),
+		middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+			childRun.Log.Trace(
+				fields.DataStream(condMiddleware, "merging child stdout into parent stdout")...,
+			)
+			childRun.Stdout.StartCopyingInto(stdoutAppender)
+			childRun.Log.Trace(
+				fields.DataStream(condMiddleware, "merging child stderr into parent stderr")...,
+			)
+			childRun.Stderr.StartCopyingInto(stderrAppender)
+			executionContext.AddConnection(run, childRun, fmt.Sprintf("cond %v", index))
+			go func() {
+				childRun.Wait()
+				// need to clean up by closing the writers we created
+				childRun.Log.PossibleError(stdoutAppender.Close())
+				childRun.Log.PossibleError(stderrAppender.Close())
+			}()
+		}))
+}
@@ -0,0 +1,256 @@
+package cond
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCond_FirstSatisfiedBranch(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"level": 2,
+		"cond": []interface{}{
+			map[string]interface{}{
+				"when": "level > 2",
+				"pipe": "high",
+			},
+			map[string]interface{}{
+				"when": "level > 1",
+				"pipe": map[string]interface{}{
+					"medium": map[string]interface{}{
+						"arg": "value",
+					},
+				},
+			},
+			map[string]interface{}{
+				"pipe": "low",
+			},
+		},
+	}, nil, nil)
+	run.Log.SetLevel(logrus.DebugLevel)
+	run.Stdin.Replace(strings.NewReader("input"))
+
+	executedBranches := make([]string, 0, 1)
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					executedBranches = append(executedBranches, *childRun.Identifier)
+					arguments := childRun.ArgumentsCopy()
+					stdinCopy := childRun.Stdin.Copy()
+					stdoutWriter := childRun.Stdout.WriteCloser()
+					childRun.DontCompleteBefore(func() {
+						childInput, _ := ioutil.ReadAll(stdinCopy)
+						_, _ = stdoutWriter.Write([]byte(fmt.Sprintf("%v %v: %v", *childRun.Identifier, arguments["arg"], string(childInput))))
+						_ = stdoutWriter.Close()
+					})
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, []string{"medium"}, executedBranches)
+	require.Equal(t, "medium value: input", run.Stdout.String())
+	require.Contains(t, run.Log.String(), "branch 1 satisfied")
+}
+
+func TestCond_UnlessAndDefault(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"cond": []interface{}{
+			map[string]interface{}{
+				"unless": "contains(input, 'ok')",
+				"pipe":   "failure",
+			},
+			map[string]interface{}{
+				"when": "matches(input, '^ok, but')",
+				"pipe": "warning",
+			},
+			map[string]interface{}{
+				"pipe": "success",
+			},
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("ok"))
+
+	executedBranches := make([]string, 0, 1)
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					executedBranches = append(executedBranches, *childRun.Identifier)
+					arguments := childRun.ArgumentsCopy()
+					stdinCopy := childRun.Stdin.Copy()
+					stdoutWriter := childRun.Stdout.WriteCloser()
+					childRun.DontCompleteBefore(func() {
+						childInput, _ := ioutil.ReadAll(stdinCopy)
+						_, _ = stdoutWriter.Write([]byte(fmt.Sprintf("%v %v: %v", *childRun.Identifier, arguments["arg"], string(childInput))))
+						_ = stdoutWriter.Close()
+					})
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, []string{"success"}, executedBranches)
+	require.Equal(t, "success <nil>: ok", run.Stdout.String())
+}
+
+func TestCond_NoBranchSatisfied(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"cond": []interface{}{
+			map[string]interface{}{
+				"when": "input == 'something else'",
+				"pipe": "never",
+			},
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("input"))
+
+	executedBranches := make([]string, 0, 1)
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					executedBranches = append(executedBranches, *childRun.Identifier)
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Empty(t, executedBranches)
+	require.Equal(t, "input", run.Stdout.String())
+	require.True(t, run.Skipped())
+}
+
+func TestCond_UnavailableRun(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"cond": []interface{}{
+			map[string]interface{}{
+				"when": "runs.unknown.exitCode == 0",
+				"pipe": "never",
+			},
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("input"))
+
+	executedBranches := make([]string, 0, 1)
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					executedBranches = append(executedBranches, *childRun.Identifier)
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), `referenced pipe "unknown" was not started`)
+	require.Empty(t, executedBranches)
+}
+
+func TestCond_EvaluationError(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"cond": []interface{}{
+			map[string]interface{}{
+				"when": "undefined == 'value'",
+				"pipe": "never",
+			},
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("input"))
+
+	executedBranches := make([]string, 0, 1)
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					executedBranches = append(executedBranches, *childRun.Identifier)
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "No parameter 'undefined' found")
+	require.Empty(t, executedBranches)
+	require.Equal(t, "", run.Stdout.String())
+}
+
+func TestCond_InvalidBranches(t *testing.T) {
+	for name, branch := range map[string]map[string]interface{}{
+		"missing pipe": {
+			"when": "true",
+		},
+		"when and unless": {
+			"when":   "true",
+			"unless": "false",
+			"pipe":   "test",
+		},
+		"unparseable condition": {
+			"when": "(",
+			"pipe": "test",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run, _ := pipeline.NewRun(nil, map[string]interface{}{
+				"cond": []interface{}{branch},
+			}, nil, nil)
+
+			nextCalled := false
+			NewMiddleware().Apply(
+				run,
+				func(pipelineRun *pipeline.Run) {
+					nextCalled = true
+				},
+				nil,
+			)
+			run.Start()
+			run.Wait()
+
+			require.True(t, nextCalled)
+			require.Equal(t, 1, run.Log.ErrorCount())
+		})
+	}
+}
+
+func TestCond_Inactive(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+
+	nextCalled := false
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			nextCalled = true
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.True(t, nextCalled)
+	require.Equal(t, 0, run.Log.ErrorCount())
+}
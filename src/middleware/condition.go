@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/custom/evaluate"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"sort"
+	"strings"
+)
+
+// Condition is a boolean expression evaluated against a run's arguments, environment, input and the results of other runs
+//
+// Arguments are available by name, with nested values separated by dots.
+// Environment variables, the input and the results of other runs are available as `env.<name>`, `input`
+// and `runs.<pipe>.<field>` respectively and take precedence over arguments with the same name.
+type Condition struct {
+	// Source is the condition as provided by the user
+	Source string
+	// NeedsInput indicates whether the condition references the run's input
+	NeedsInput bool
+	// RunIdentifiers lists the identifiers of the runs whose results are referenced in the condition
+	RunIdentifiers []string
+
+	expression *evaluate.Expression
+}
+
+// NewCondition parses a condition, resolving file paths relative to the run's working directory
+func NewCondition(source string, run *pipeline.Run) (*Condition, error) {
+	expression, err := evaluate.Parse(source, run.ResolvePath)
+	if err != nil {
+		return nil, err
+	}
+	condition := &Condition{
+		Source:         source,
+		NeedsInput:     false,
+		RunIdentifiers: make([]string, 0, 2),
+		expression:     expression,
+	}
+	for _, variable := range expression.Variables() {
+		if variable == "input" {
+			condition.NeedsInput = true
+		}
+		if strings.HasPrefix(variable, "runs.") {
+			runIdentifier, err := parseRunVariable(variable)
+			if err != nil {
+				return nil, err
+			}
+			condition.RunIdentifiers = appendUnique(condition.RunIdentifiers, runIdentifier)
+		}
+	}
+	sort.Strings(condition.RunIdentifiers)
+	return condition, nil
+}
+
+// Deferred indicates whether the condition can only be evaluated once the input is complete or other runs have finished
+func (condition *Condition) Deferred() bool {
+	return condition.NeedsInput || len(condition.RunIdentifiers) > 0
+}
+
+// Evaluate determines whether the condition is satisfied
+//
+// The input is only required if the condition references it.
+// If the condition references other runs, Evaluate blocks until they have completed (see ExecutionContext.WaitForRun).
+// The result is recorded in the run, so that it can be included in the execution plan.
+func (condition *Condition) Evaluate(
+	run *pipeline.Run,
+	input *string,
+	executionContext *ExecutionContext,
+) (bool, error) {
+	if condition.NeedsInput && input == nil {
+		return false, fmt.Errorf("condition %q references the input, but it is not available", condition.Source)
+	}
+	if len(condition.RunIdentifiers) > 0 && executionContext == nil {
+		return false, fmt.Errorf("condition %q references runs, but no execution context is available", condition.Source)
+	}
+
+	parameters := make(map[string]interface{}, 16)
+	evaluate.AddParameters(parameters, run.ArgumentsCopy())
+	for _, variable := range condition.expression.Variables() {
+		if strings.HasPrefix(variable, "env.") {
+			// undefined environment variables evaluate to the empty string, as in the shell
+			value, _ := run.Getenv(strings.TrimPrefix(variable, "env."))
+			parameters[variable] = value
+		}
+	}
+	if input != nil {
+		parameters["input"] = *input
+	}
+	for _, runIdentifier := range condition.RunIdentifiers {
+		referencedRun, err := executionContext.WaitForRun(runIdentifier, run)
+		if err != nil {
+			return false, fmt.Errorf("condition %q cannot be evaluated: %w", condition.Source, err)
+		}
+		executionContext.AddConnection(referencedRun, run, "condition")
+		exitCode := 0
+		if referencedRun.ExitCode != nil {
+			exitCode = *referencedRun.ExitCode
+		}
+		parameters[fmt.Sprintf("runs.%v.exitCode", runIdentifier)] = exitCode
+		parameters[fmt.Sprintf("runs.%v.stdout", runIdentifier)] = string(referencedRun.Stdout.Bytes())
+		parameters[fmt.Sprintf("runs.%v.stderr", runIdentifier)] = string(referencedRun.Stderr.Bytes())
+	}
+	satisfied, err := condition.expression.Bool(parameters)
+	if err == nil {
+		run.AddConditionResult(condition.Source, satisfied)
+	}
+	return satisfied, err
+}
+
+func parseRunVariable(variable string) (string, error) {
+	separatorIndex := strings.LastIndex(variable, ".")
+	if separatorIndex <= len("runs.") {
+		return "", fmt.Errorf("invalid run reference %q, expected `runs.<pipe>.<field>`", variable)
+	}
+	switch variable[separatorIndex+1:] {
+	case "exitCode", "stdout", "stderr":
+		return variable[len("runs."):separatorIndex], nil
+	default:
+		return "", fmt.Errorf("invalid run reference %q, expected one of the fields `exitCode`, `stdout`, `stderr`", variable)
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existingValue := range values {
+		if existingValue == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
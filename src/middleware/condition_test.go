@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestCondition_Requirements(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+	condition, err := NewCondition("input != '' && runs.build.exitCode == 0 && [runs.some::pipe.stdout] == '' && runs.build.stderr == ''", run)
+	require.Nil(t, err)
+	require.True(t, condition.NeedsInput)
+	require.Equal(t, []string{"build", "some::pipe"}, condition.RunIdentifiers)
+	require.True(t, condition.Deferred())
+
+	condition, err = NewCondition("arg == 'value'", run)
+	require.Nil(t, err)
+	require.False(t, condition.Deferred())
+}
+
+func TestCondition_InvalidRunReference(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+	_, err := NewCondition("runs.build == 0", run)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "expected `runs.<pipe>.<field>`")
+
+	_, err = NewCondition("runs.build.output == ''", run)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "expected one of the fields")
+}
+
+func TestCondition_Evaluate(t *testing.T) {
+	executionContext := NewExecutionContext(WithExecutionFunction(func(run *pipeline.Run) {
+		exitCode := 1
+		run.ExitCode = &exitCode
+		run.Stderr.Replace(strings.NewReader("build failed"))
+	}))
+	buildIdentifier := "build"
+	executionContext.FullRun(WithIdentifier(&buildIdentifier))
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"input": "overridden",
+		"nested": map[string]interface{}{
+			"key": "value",
+		},
+	}, nil, nil)
+	run.Setenv("PIPEDREAM_CONDITION_TEST", "set")
+	condition, err := NewCondition(
+		"input == 'actual' && nested.key == 'value' && env.PIPEDREAM_CONDITION_TEST == 'set' && runs.build.exitCode == 1 && runs.build.stderr == 'build failed'",
+		run,
+	)
+	require.Nil(t, err)
+	input := "actual"
+	result, err := condition.Evaluate(run, &input, executionContext)
+	require.Nil(t, err)
+	require.True(t, result)
+
+	_, err = condition.Evaluate(run, nil, executionContext)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "references the input, but it is not available")
+
+	// only successful evaluations are recorded
+	require.Equal(t, []pipeline.ConditionResult{
+		{Source: condition.Source, Satisfied: true},
+	}, run.ConditionResults())
+}
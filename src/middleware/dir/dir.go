@@ -1,7 +1,8 @@
-// Package dir provides a middleware for changing the current working directory
+// Package dir provides a middleware for changing the working directory of a run
 package dir
 
 import (
+	"fmt"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
@@ -10,8 +11,7 @@ import (
 
 // Middleware is a directory navigator
 type Middleware struct {
-	WorkingDir string
-	DirChanger func(string) error
+	dirChecker func(string) error
 }
 
 // String is a human-readable description
@@ -19,13 +19,17 @@ func (Middleware) String() string {
 	return "dir"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"dir": {new(string)},
+	}
+}
+
 // NewMiddleware creates a new Middleware instance
 func NewMiddleware() Middleware {
-	// @TODO: do we need to resolve symlinks here?
-	workingDir, _ := os.Getwd()
 	return Middleware{
-		DirChanger: os.Chdir,
-		WorkingDir: workingDir,
+		dirChecker: checkDirectory,
 	}
 }
 
@@ -40,27 +44,35 @@ func (dirMiddleware Middleware) Apply(
 	_ *middleware.ExecutionContext,
 ) {
 	dirArgument := ""
-	pipeline.ParseArgumentsIncludingParents(&dirArgument, "dir", run)
+	pipeline.ParseArguments(&dirArgument, "dir", run)
 
 	if dirArgument != "" {
+		// @TODO: provide a way of resolving the path relative to the pipeline file's location
+		// the working directory is stored on the run rather than changed for the whole process,
+		// so that runs executing in parallel don't interfere with each other
+		run.SetWorkingDirectory(dirArgument)
 		run.Log.Debug(
 			fields.Symbol("📂"),
 			fields.Message(dirArgument),
+			fields.Info(run.WorkingDirectory()),
 			fields.Middleware(dirMiddleware),
 		)
-		// @TODO: provide a way of resolving the path relative to the pipeline file's location
-		dirMiddleware.changeDirectory(dirArgument, run)
+		err := dirMiddleware.dirChecker(run.WorkingDirectory())
+		if err != nil {
+			run.Log.Error(err, fields.Middleware(dirMiddleware))
+		}
 	}
 
 	next(run)
-
-	// now change back to initial working directory to make relative links work for next run
-	dirMiddleware.changeDirectory(dirMiddleware.WorkingDir, run)
 }
 
-func (dirMiddleware Middleware) changeDirectory(directory string, run *pipeline.Run) {
-	err := dirMiddleware.DirChanger(directory)
+func checkDirectory(directory string) error {
+	fileInfo, err := os.Stat(directory)
 	if err != nil {
-		run.Log.Error(err)
+		return err
+	}
+	if !fileInfo.IsDir() {
+		return fmt.Errorf("%q is not a directory", directory)
 	}
+	return nil
 }
@@ -5,50 +5,49 @@ import (
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
-var currentDir = "/test"
-
 func TestDir_ChangeDir(t *testing.T) {
 	dirMiddleware := Middleware{
-		DirChanger: func(newDir string) error {
-			currentDir = newDir
+		dirChecker: func(newDir string) error {
 			return nil
 		},
-		WorkingDir: "working-dir",
 	}
-	currentDir = "pre-test"
+	currentDir, _ := os.Getwd()
 	runDir := ""
 	run, _ := pipeline.NewRun(nil, map[string]interface{}{
-		"dir": "changed",
+		"dir": "/changed",
 	}, nil, nil)
 
 	run.Log.SetLevel(logrus.DebugLevel)
 	dirMiddleware.Apply(
 		run,
 		func(run *pipeline.Run) {
-			runDir = currentDir
+			runDir = run.WorkingDirectory()
 		},
 		nil,
 	)
 	run.Start()
 	run.Wait()
 
-	require.Equal(t, "working-dir", currentDir)
-	require.Equal(t, "changed", runDir)
+	processDir, _ := os.Getwd()
+	require.Equal(t, currentDir, processDir)
+	require.Equal(t, "/changed", runDir)
+	require.Equal(t, "/changed", run.WorkingDirectory())
 	require.Contains(t, run.Log.String(), "dir")
 }
 
 func TestDir_DontChangeDir(t *testing.T) {
 	dirMiddleware := Middleware{
-		DirChanger: func(newDir string) error {
-			currentDir = newDir
+		dirChecker: func(newDir string) error {
 			return nil
 		},
-		WorkingDir: "working-dir",
 	}
-	currentDir = "pre-test"
+	currentDir, _ := os.Getwd()
 	runDir := ""
 	run, _ := pipeline.NewRun(nil, map[string]interface{}{}, nil, nil)
 
@@ -56,52 +55,85 @@ func TestDir_DontChangeDir(t *testing.T) {
 	dirMiddleware.Apply(
 		run,
 		func(run *pipeline.Run) {
-			runDir = currentDir
+			runDir = run.WorkingDirectory()
 		},
 		nil,
 	)
 	run.Start()
 	run.Wait()
 
-	require.Equal(t, "working-dir", currentDir)
-	require.Equal(t, "pre-test", runDir)
+	require.Equal(t, currentDir, runDir)
 	require.NotContains(t, run.Log.String(), "dir")
 }
 
+func TestDir_RelativeToParentDir(t *testing.T) {
+	dirMiddleware := Middleware{
+		dirChecker: func(newDir string) error {
+			return nil
+		},
+	}
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.SetWorkingDirectory("/parent")
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"dir": "child",
+	}, nil, parentRun)
+	siblingRun, _ := pipeline.NewRun(nil, nil, nil, parentRun)
+
+	dirMiddleware.Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, "/parent/child", run.WorkingDirectory())
+	require.Equal(t, "/parent", siblingRun.WorkingDirectory())
+}
+
 func TestDir_ErrorChangingDir(t *testing.T) {
 	dirMiddleware := Middleware{
-		DirChanger: func(newDir string) error {
+		dirChecker: func(newDir string) error {
 			return fmt.Errorf("error changing directory")
 		},
-		WorkingDir: "working-dir",
 	}
 
-	currentDir = "pre-test"
 	run, _ := pipeline.NewRun(nil, map[string]interface{}{
-		"dir": "changed",
+		"dir": "/changed",
 	}, nil, nil)
 
 	run.Log.SetLevel(logrus.DebugLevel)
-	runDir := ""
+	nextCalled := false
 	dirMiddleware.Apply(
 		run,
 		func(invocation *pipeline.Run) {
-			runDir = currentDir
+			nextCalled = true
 		},
 		nil,
 	)
 	run.Start()
 	run.Wait()
 
-	require.Equal(t, "pre-test", currentDir)
-	require.Equal(t, "pre-test", runDir)
-	require.Equal(t, 2, run.Log.ErrorCount())
+	require.True(t, nextCalled)
+	require.Equal(t, 1, run.Log.ErrorCount())
 	require.Equal(t, "error changing directory", run.Log.LastError().Error())
 	require.Contains(t, run.Log.String(), "dir")
 }
 
+func TestDir_CheckDirectory(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-dir-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	filePath := filepath.Join(directory, "file")
+	require.Nil(t, ioutil.WriteFile(filePath, []byte("test"), 0644))
+
+	require.Nil(t, checkDirectory(directory))
+	require.NotNil(t, checkDirectory(filePath))
+	require.NotNil(t, checkDirectory(filepath.Join(directory, "missing")))
+}
+
 func TestDir_CreateNewDirMiddleware(t *testing.T) {
 	dirMiddleware := NewMiddleware()
 	require.NotNil(t, dirMiddleware)
-	require.NotNil(t, dirMiddleware.DirChanger)
+	require.NotNil(t, dirMiddleware.dirChecker)
 }
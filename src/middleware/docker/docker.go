@@ -17,11 +17,22 @@ func (Middleware) String() string {
 	return "docker"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"docker": {&middlewareArguments{}},
+	}
+}
+
 // NewMiddleware create a new Middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
 }
 
+type middlewareArguments struct {
+	Service *string
+}
+
 // Apply is where the middleware's logic resides
 //
 // It adapts the run based on its slice of the run's arguments.
@@ -32,9 +43,7 @@ func (dockerMiddleware Middleware) Apply(
 	next func(*pipeline.Run),
 	_ *middleware.ExecutionContext,
 ) {
-	arguments := struct {
-		Service *string
-	}{}
+	arguments := middlewareArguments{}
 	pipeline.ParseArgumentsIncludingParents(&arguments, "docker", run)
 
 	if arguments.Service != nil {
@@ -18,11 +18,23 @@ func (Middleware) String() string {
 	return "each"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"each": {&[]pipeline.Reference{}, &middlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new Middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
 }
 
+type middlewareArguments struct {
+	Items    []pipeline.Reference
+	Parallel int
+}
+
 // Apply is where the middleware's logic resides
 //
 // It adapts the run based on its slice of the run's arguments.
@@ -33,14 +45,27 @@ func (eachMiddleware Middleware) Apply(
 	next func(*pipeline.Run),
 	executionContext *middleware.ExecutionContext,
 ) {
-	arguments := make([]pipeline.Reference, 0, 10)
-	pipeline.ParseArguments(&arguments, "each", run)
+	arguments := middlewareArguments{
+		Items:    make([]pipeline.Reference, 0, 10),
+		Parallel: 0,
+	}
+	// the children can be provided either directly as a list or under the `items` key, together with further options
+	if rawArguments, err := run.ArgumentAtPath("each"); err == nil {
+		if _, argumentsAreList := rawArguments.([]interface{}); argumentsAreList {
+			pipeline.ParseArguments(&arguments.Items, "each", run)
+		} else {
+			pipeline.ParseArguments(&arguments, "each", run)
+		}
+	}
 
-	haveChildren := len(arguments) > 0
+	haveChildren := len(arguments.Items) > 0
 	if haveChildren {
-		childIdentifiers := make([]*string, 0, len(arguments))
-		childArguments := make([]map[string]interface{}, 0, len(arguments))
-		for _, childReference := range arguments {
+		if arguments.Parallel > 0 {
+			run.LimitParallelism(arguments.Parallel)
+		}
+		childIdentifiers := make([]*string, 0, len(arguments.Items))
+		childArguments := make([]map[string]interface{}, 0, len(arguments.Items))
+		for _, childReference := range arguments.Items {
 			for pipelineIdentifier, pipelineArguments := range childReference {
 				childIdentifiers = append(childIdentifiers, pipelineIdentifier)
 				childArguments = append(childArguments, stringmap.CopyMap(pipelineArguments))
@@ -62,12 +87,12 @@ func (eachMiddleware Middleware) Apply(
 			fields.Middleware(eachMiddleware),
 		)
 		for index, childIdentifier := range childIdentifiers {
-			arguments := childArguments[index]
+			childArguments := childArguments[index]
 			identifier := childIdentifier
 			executionContext.FullRun(
 				middleware.WithParentRun(run),
 				middleware.WithIdentifier(identifier),
-				middleware.WithArguments(arguments),
+				middleware.WithArguments(childArguments),
 				middleware.WithSetupFunc(func(childRun *pipeline.Run) {
 					run.Log.Trace(
 						fields.DataStream(eachMiddleware, "copy parent stdin into child stdin")...,
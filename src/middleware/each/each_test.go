@@ -69,6 +69,48 @@ func TestEach_Apply(t *testing.T) {
 	require.Contains(t, logString, "pipe1, pipe2, ~")
 }
 
+func TestEach_ApplyWithParallelLimit(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"each": map[string]interface{}{
+			"parallel": 2,
+			"items": []interface{}{
+				"pipe1",
+				map[string]interface{}{"pipe2": map[string]interface{}{
+					"arg": "value",
+				}},
+			},
+		},
+	}, nil, nil)
+
+	childRuns := make([]*pipeline.Run, 0, 2)
+	childRunsMutex := &sync.Mutex{}
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+		},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childRunsMutex.Lock()
+					defer childRunsMutex.Unlock()
+					childRuns = append(childRuns, childRun)
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("output of pipeline `%v`\n", *childRun.Identifier)))
+				}),
+		))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "output of pipeline `pipe1`\noutput of pipeline `pipe2`\n", run.Stdout.String())
+	require.Len(t, childRuns, 2)
+	for _, childRun := range childRuns {
+		limits := childRun.ParallelismLimits()
+		require.Len(t, limits, 1)
+		require.Equal(t, 2, limits[0].Size())
+	}
+	require.Equal(t, "value", childRuns[1].ArgumentsCopy()["arg"])
+}
+
 func TestEach_ApplyWithInvalidArguments(t *testing.T) {
 	run, _ := pipeline.NewRun(nil, map[string]interface{}{
 		"each": []interface{}{
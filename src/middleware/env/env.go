@@ -8,17 +8,20 @@ import (
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/ryankurte/go-structparse"
 	"os"
+	"sort"
 )
 
 type envMiddlewareArguments struct {
 	Interpolate string
 	Save        *string
+	Scope       string
+	Set         map[string]interface{}
+	Unset       []string
 }
 
 // Middleware is an environment variable manager
 type Middleware struct {
-	Setenv    func(string, string) error
-	ExpandEnv func(string) string
+	Setenv func(string, string) error
 }
 
 // String is a human-readable description
@@ -26,19 +29,24 @@ func (envMiddleware Middleware) String() string {
 	return "env"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (envMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"env": {&envMiddlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
-	return NewMiddlewareWithProvider(os.Setenv, os.ExpandEnv)
+	return NewMiddlewareWithProvider(os.Setenv)
 }
 
-// NewMiddlewareWithProvider creates a new middleware instance with the specified env functions
+// NewMiddlewareWithProvider creates a new middleware instance with the specified function to set global env vars
 func NewMiddlewareWithProvider(
 	setenv func(string, string) error,
-	expandEnv func(string) string,
 ) Middleware {
 	return Middleware{
-		Setenv:    setenv,
-		ExpandEnv: expandEnv,
+		Setenv: setenv,
 	}
 }
 
@@ -55,10 +63,43 @@ func (envMiddleware Middleware) Apply(
 	arguments := envMiddlewareArguments{
 		Interpolate: "shallow",
 		Save:        nil,
+		Scope:       "global",
+		Set:         nil,
+		Unset:       nil,
 	}
 	pipeline.ParseArguments(&arguments, "env", run)
 
-	envInterpolator := newInterpolator(envMiddleware)
+	if arguments.Save != nil && arguments.Scope != "parent" && arguments.Scope != "global" {
+		run.Log.Error(
+			fmt.Errorf("invalid scope %q, expected one of `global`, `parent`", arguments.Scope),
+			fields.Middleware(envMiddleware),
+		)
+		return
+	}
+
+	for _, key := range arguments.Unset {
+		run.Unsetenv(key)
+	}
+	if len(arguments.Set) > 0 {
+		// sort keys for predictable results
+		keys := make([]string, 0, len(arguments.Set))
+		for key := range arguments.Set {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// values may refer to other variables, e.g. to extend the $PATH
+			run.Setenv(key, run.ExpandEnv(fmt.Sprint(arguments.Set[key])))
+		}
+		run.Log.Debug(
+			fields.Symbol("💲"),
+			fields.Message(fmt.Sprintf("set %v env var(s)", len(keys))),
+			fields.Info(keys),
+			fields.Middleware(envMiddleware),
+		)
+	}
+
+	envInterpolator := newInterpolator(run)
 	interpolatedArguments := run.ArgumentsCopy()
 	switch arguments.Interpolate {
 	case "deep":
@@ -96,15 +137,25 @@ func (envMiddleware Middleware) Apply(
 	next(run)
 
 	if arguments.Save != nil {
+		key := *arguments.Save
+		scope := arguments.Scope
 		run.DontCompleteBefore(func() {
 			run.Stdout.Wait()
-			err := envMiddleware.Setenv(*arguments.Save, run.Stdout.String())
-			run.Log.PossibleError(err)
+			value := run.Stdout.String()
+			switch {
+			case scope == "global":
+				run.Log.PossibleError(envMiddleware.Setenv(key, value))
+			case run.Parent != nil:
+				// make the value available to the parent and all of its descendants, i.e. this run's siblings
+				run.Parent.Setenv(key, value)
+			default:
+				run.Setenv(key, value)
+			}
 		})
 		run.Log.Debug(
 			fields.Symbol("💲"),
 			fields.Message(fmt.Sprintf("saving output")),
-			fields.Info("$"+*arguments.Save),
+			fields.Info(fmt.Sprintf("$%v (%v)", key, scope)),
 			fields.Middleware(envMiddleware),
 		)
 	}
@@ -115,10 +166,13 @@ type interpolator struct {
 	ExpandEnv     func(string) string
 }
 
-func newInterpolator(envMiddleware Middleware) *interpolator {
+// newInterpolator creates an interpolator expanding references to environment variables as seen by the run
+//
+// References to undefined variables are replaced by the empty string.
+func newInterpolator(run *pipeline.Run) *interpolator {
 	return &interpolator{
 		Substitutions: make(map[string]interface{}, 10),
-		ExpandEnv:     envMiddleware.ExpandEnv,
+		ExpandEnv:     run.ExpandEnv,
 	}
 }
 
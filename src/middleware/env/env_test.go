@@ -33,15 +33,11 @@ func TestEnv_InterpolateShallow(t *testing.T) {
 		env[key] = value
 		return nil
 	}
-	expandEnv := func(value string) string {
-		result := value
-		for key, newValue := range env {
-			result = strings.Replace(value, "$"+key, newValue, -1)
-		}
-		return result
+	for key, value := range env {
+		run.Setenv(key, value)
 	}
 	var calledRun *pipeline.Run = nil
-	NewMiddlewareWithProvider(setenv, expandEnv).Apply(
+	NewMiddlewareWithProvider(setenv).Apply(
 		run,
 		func(pipelineRun *pipeline.Run) {
 			calledRun = pipelineRun
@@ -97,15 +93,11 @@ func TestEnv_InterpolateDeep(t *testing.T) {
 		env[key] = value
 		return nil
 	}
-	expandEnv := func(value string) string {
-		result := value
-		for key, newValue := range env {
-			result = strings.Replace(value, "$"+key, newValue, -1)
-		}
-		return result
+	for key, value := range env {
+		run.Setenv(key, value)
 	}
 	var calledRun *pipeline.Run = nil
-	NewMiddlewareWithProvider(setenv, expandEnv).Apply(
+	NewMiddlewareWithProvider(setenv).Apply(
 		run,
 		func(pipelineRun *pipeline.Run) {
 			calledRun = pipelineRun
@@ -144,16 +136,16 @@ func TestEnv_NoSubstitutions(t *testing.T) {
 		},
 		"test": []interface{}{
 			map[string]interface{}{"test1": map[string]interface{}{
-				"value": "$TEST",
+				"value": "test",
 			}},
 			map[string]interface{}{"test2": map[string]interface{}{
 				"test": map[string]interface{}{"test2": map[string]interface{}{
-					"value": "another $TEST",
+					"value": "another test",
 				}},
 			}},
-			"yet another $TEST",
+			"yet another test",
 		},
-		"test2": "shallow $TEST",
+		"test2": "shallow test",
 	}, nil, nil)
 
 	run.Log.SetLevel(logrus.DebugLevel)
@@ -164,15 +156,11 @@ func TestEnv_NoSubstitutions(t *testing.T) {
 		env[key] = value
 		return nil
 	}
-	expandEnv := func(value string) string {
-		result := value
-		for key, newValue := range env {
-			result = strings.Replace(value, "$"+key, newValue, -1)
-		}
-		return result
+	for key, value := range env {
+		run.Setenv(key, value)
 	}
 	var calledRun *pipeline.Run = nil
-	NewMiddlewareWithProvider(setenv, expandEnv).Apply(
+	NewMiddlewareWithProvider(setenv).Apply(
 		run,
 		func(pipelineRun *pipeline.Run) {
 			calledRun = pipelineRun
@@ -190,16 +178,16 @@ func TestEnv_NoSubstitutions(t *testing.T) {
 		},
 		"test": []interface{}{
 			map[string]interface{}{"test1": map[string]interface{}{
-				"value": "$TEST",
+				"value": "test",
 			}},
 			map[string]interface{}{"test2": map[string]interface{}{
 				"test": map[string]interface{}{"test2": map[string]interface{}{
-					"value": "another $TEST",
+					"value": "another test",
 				}},
 			}},
-			"yet another $TEST",
+			"yet another test",
 		},
-		"test2": "shallow $TEST",
+		"test2": "shallow test",
 	}, calledRun.ArgumentsCopy())
 	require.NotContains(t, run.Log.String(), "env")
 }
@@ -231,15 +219,11 @@ func TestEnv_InterpolateNone(t *testing.T) {
 		env[key] = value
 		return nil
 	}
-	expandEnv := func(value string) string {
-		result := value
-		for key, newValue := range env {
-			result = strings.Replace(value, "$"+key, newValue, -1)
-		}
-		return result
+	for key, value := range env {
+		run.Setenv(key, value)
 	}
 	var calledRun *pipeline.Run = nil
-	NewMiddlewareWithProvider(setenv, expandEnv).Apply(
+	NewMiddlewareWithProvider(setenv).Apply(
 		run,
 		func(pipelineRun *pipeline.Run) {
 			calledRun = pipelineRun
@@ -286,15 +270,11 @@ func TestEnv_Save(t *testing.T) {
 		env[key] = value
 		return nil
 	}
-	expandEnv := func(value string) string {
-		result := value
-		for key, newValue := range env {
-			result = strings.Replace(value, "$"+key, newValue, -1)
-		}
-		return result
+	for key, value := range env {
+		run.Setenv(key, value)
 	}
 	var calledRun *pipeline.Run = nil
-	NewMiddlewareWithProvider(setenv, expandEnv).Apply(
+	NewMiddlewareWithProvider(setenv).Apply(
 		run,
 		func(pipelineRun *pipeline.Run) {
 			calledRun = pipelineRun
@@ -312,14 +292,140 @@ func TestEnv_Save(t *testing.T) {
 	require.Contains(t, run.Log.String(), "env")
 }
 
+func TestEnv_Save_ParentScope(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"env": map[string]interface{}{
+			"save":  "PIPEDREAM_TEST_KEY",
+			"scope": "parent",
+		},
+	}, nil, parentRun)
+	siblingRun, _ := pipeline.NewRun(nil, nil, nil, parentRun)
+	otherRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+
+	globalEnv := map[string]string{}
+	NewMiddlewareWithProvider(func(key string, value string) error {
+		globalEnv[key] = value
+		return nil
+	}).Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			pipelineRun.Stdout.Replace(strings.NewReader("test"))
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	value, found := siblingRun.Getenv("PIPEDREAM_TEST_KEY")
+	require.True(t, found)
+	require.Equal(t, "test", value)
+	_, found = otherRun.Getenv("PIPEDREAM_TEST_KEY")
+	require.False(t, found)
+	require.Empty(t, globalEnv)
+}
+
+func TestEnv_Save_GlobalScope(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"env": map[string]interface{}{
+			"save":  "PIPEDREAM_TEST_KEY",
+			"scope": "global",
+		},
+	}, nil, parentRun)
+
+	globalEnv := map[string]string{}
+	NewMiddlewareWithProvider(func(key string, value string) error {
+		globalEnv[key] = value
+		return nil
+	}).Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			pipelineRun.Stdout.Replace(strings.NewReader("test"))
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, map[string]string{"PIPEDREAM_TEST_KEY": "test"}, globalEnv)
+}
+
+func TestEnv_Save_InvalidScope(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"env": map[string]interface{}{
+			"save":  "KEY",
+			"scope": "everywhere",
+		},
+	}, nil, nil)
+
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			t.Fatal("next should not be called")
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "invalid scope \"everywhere\"")
+}
+
+func TestEnv_SetAndUnset(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.Setenv("PIPEDREAM_TEST_PATH", "/bin")
+	parentRun.Setenv("PIPEDREAM_TEST_REMOVED", "value")
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"env": map[string]interface{}{
+			"set": map[string]interface{}{
+				"PIPEDREAM_TEST_PATH":   "$PIPEDREAM_TEST_PATH:/opt/bin",
+				"PIPEDREAM_TEST_NUMBER": 3,
+			},
+			"unset": []interface{}{"PIPEDREAM_TEST_REMOVED"},
+		},
+		"value": "$PIPEDREAM_TEST_PATH $PIPEDREAM_TEST_NUMBER$PIPEDREAM_TEST_REMOVED",
+	}, nil, parentRun)
+	childRun, _ := pipeline.NewRun(nil, nil, nil, run)
+
+	var calledRun *pipeline.Run = nil
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			calledRun = pipelineRun
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.NotNil(t, calledRun)
+	require.Equal(t, 0, run.Log.ErrorCount())
+	// references to undefined variables are replaced by the empty string
+	require.Equal(t, "/bin:/opt/bin 3", calledRun.ArgumentsCopy()["value"])
+	value, _ := childRun.Getenv("PIPEDREAM_TEST_PATH")
+	require.Equal(t, "/bin:/opt/bin", value)
+	_, found := childRun.Getenv("PIPEDREAM_TEST_REMOVED")
+	require.False(t, found)
+	value, _ = parentRun.Getenv("PIPEDREAM_TEST_PATH")
+	require.Equal(t, "/bin", value)
+	value, _ = parentRun.Getenv("PIPEDREAM_TEST_REMOVED")
+	require.Equal(t, "value", value)
+	require.Equal(t, "", os.Getenv("PIPEDREAM_TEST_PATH"))
+}
+
 func TestEnv_NewEnvMiddleware(t *testing.T) {
 	envMiddleware := NewMiddleware()
 	key := "PIPEDREAM_TEST_TEMP"
 	err := envMiddleware.Setenv(key, "value")
 	require.Nil(t, err)
 	require.Equal(t, "value", os.Getenv(key))
-	require.Equal(t, "test value", envMiddleware.ExpandEnv("test $"+key))
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+	require.Equal(t, "test value", newInterpolator(run).ExpandEnv("test $"+key))
 	_ = os.Unsetenv(key)
 	require.Equal(t, "", os.Getenv(key))
-	require.Equal(t, "test ", envMiddleware.ExpandEnv("test $"+key))
+	require.Equal(t, "test ", newInterpolator(run).ExpandEnv("test $"+key))
 }
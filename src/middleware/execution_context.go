@@ -4,6 +4,7 @@ import (
 	"fmt"
 	customio "github.com/Layer9Berlin/pipedream/src/custom/io"
 	"github.com/Layer9Berlin/pipedream/src/custom/math"
+	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
 	"github.com/Layer9Berlin/pipedream/src/logging"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/parsing"
@@ -18,7 +19,6 @@ import (
 	"strings"
 	"sync"
 	"syscall"
-	"time"
 )
 
 // ExecutionContext is the data model keeping track of everything required to execute a pipeline file
@@ -35,9 +35,7 @@ type ExecutionContext struct {
 	MiddlewareStack []Middleware
 	// Defaults contains some execution options that can be set at file level
 	Defaults pipeline.DefaultSettings
-	// Hooks can execute certain functions before or after pipeline execution
-	//
-	// Currently not implemented or used.
+	// Hooks are pipes executed before or after the pipe selected for execution
 	Hooks pipeline.HookDefinitions
 
 	// Log is the execution context's logger
@@ -47,6 +45,12 @@ type ExecutionContext struct {
 	ProjectPath string
 	// RootFileName is the name of the file selected for execution
 	RootFileName string
+	// RootArguments are passed to the pipe selected for execution as invocation arguments
+	RootArguments map[string]interface{}
+	// CacheDisabled indicates that cached results should not be used, forcing all pipes to execute
+	CacheDisabled bool
+	// DryRun indicates that shell commands are recorded instead of being executed and that no files should be written
+	DryRun bool
 
 	rootRun *pipeline.Run
 
@@ -58,7 +62,15 @@ type ExecutionContext struct {
 	errors      *multierror.Error
 	errorsMutex *sync.RWMutex
 
-	interruptChannel chan os.Signal
+	interruptChannel  chan os.Signal
+	cancelled         bool
+	cancelledMutex    *sync.RWMutex
+	cancellationMutex *sync.Mutex
+	killedRuns        []*pipeline.Run
+	killedRunsMutex   *sync.RWMutex
+
+	// parallelismLimit restricts the number of shell commands executed simultaneously, if set
+	parallelismLimit *pipeline.Semaphore
 
 	preCallback       func(*pipeline.Run)
 	postCallback      func(*pipeline.Run)
@@ -82,8 +94,11 @@ type ExecutionContext struct {
 // NewExecutionContext creates a new ExecutionContext with the specified options
 func NewExecutionContext(options ...ExecutionContextOption) *ExecutionContext {
 	executionContext := &ExecutionContext{
+		cancelledMutex:           &sync.RWMutex{},
+		cancellationMutex:        &sync.Mutex{},
 		connectionsMutex:         &sync.RWMutex{},
 		errorsMutex:              &sync.RWMutex{},
+		killedRunsMutex:          &sync.RWMutex{},
 		Log:                      logrus.New(),
 		parser:                   parsing.NewParser(),
 		runsMutex:                &sync.RWMutex{},
@@ -123,11 +138,8 @@ func (executionContext *ExecutionContext) FullRun(options ...FullRunOption) *pip
 	if err != nil {
 		panic(fmt.Errorf("failed to create pipeline run: %w", err))
 	}
-	pipelineRun.Log.ErrorCallback = func(err error) {
-		executionContext.errorsMutex.Lock()
-		defer executionContext.errorsMutex.Unlock()
-		executionContext.errors = multierror.Append(executionContext.errors, err)
-	}
+	pipelineRun.InvocationPosition = findInvocationPosition(runOptions.pipelineIdentifier, runOptions.arguments, runOptions.parentRun)
+	pipelineRun.Log.ErrorCallback = executionContext.addError
 	if runOptions.logWriter == nil {
 		if runOptions.parentRun != nil {
 			runOptions.parentRun.Log.Debug(
@@ -257,6 +269,31 @@ func LookUpPipelineDefinition(definitionsLookup pipeline.DefinitionsLookup, iden
 	return nil, false
 }
 
+// findInvocationPosition finds the location of the inline invocation a run is created from, if any
+//
+// Middleware re-running the same pipe (e.g. to retry or interpolate) does not correspond to an invocation of its own,
+// so such runs share the position of their parent. Otherwise, the definitions of the parent and its ancestors are searched,
+// as inline arguments (including nested invocations) are passed down from one run to the next.
+func findInvocationPosition(identifier *string, arguments map[string]interface{}, parentRun *pipeline.Run) *pipeline.SourcePosition {
+	if parentRun == nil {
+		return nil
+	}
+	if (identifier == nil && parentRun.Identifier == nil) ||
+		(identifier != nil && parentRun.Identifier != nil && *identifier == *parentRun.Identifier) {
+		return parentRun.InvocationPosition
+	}
+	for ancestor := parentRun; ancestor != nil; ancestor = ancestor.Parent {
+		if ancestor.Definition == nil {
+			continue
+		}
+		if invocation := ancestor.Definition.FindInvocation(identifier, arguments); invocation != nil {
+			position := invocation.Position
+			return &position
+		}
+	}
+	return nil
+}
+
 func (executionContext *ExecutionContext) unwindStack(
 	pipelineRun *pipeline.Run,
 	currentIndex int,
@@ -270,26 +307,146 @@ func (executionContext *ExecutionContext) unwindStack(
 }
 
 // Execute runs a pipeline and outputs the result
+//
+// Any hooks are executed around the pipeline: `before` hooks first, then the pipeline itself,
+// `onCancel` or `onError` hooks if the execution was cancelled or failed, and finally all `after` hooks.
 func (executionContext *ExecutionContext) Execute(pipelineIdentifier string, stdoutWriter io.Writer, stderrWriter io.Writer) {
-	executionContext.SetUpCancelHandler(stdoutWriter, stderrWriter, nil)
-
-	fullRun := executionContext.FullRun(WithIdentifier(&pipelineIdentifier))
-	fullRun.Start()
-	waitGroup := &sync.WaitGroup{}
-	waitGroup.Add(1)
-	go func() {
-		_, _ = io.Copy(stdoutWriter, fullRun.Log)
-		waitGroup.Done()
-	}()
-	fullRun.Wait()
-
-	waitGroup.Wait()
+	executionContext.SetUpCancelHandler(stdoutWriter, stderrWriter, func() {
+		executionContext.cancelledMutex.Lock()
+		defer executionContext.cancelledMutex.Unlock()
+		executionContext.cancelled = true
+	})
+
+	var fullRun *pipeline.Run = nil
+	if !executionContext.runHooks("before", executionContext.Hooks.Before, nil, stdoutWriter, true) {
+		executionContext.addError(fmt.Errorf("skipping execution of %q, as a `before` hook failed", pipelineIdentifier))
+	} else if !executionContext.Cancelled() {
+		fullRun = executionContext.FullRun(
+			WithIdentifier(&pipelineIdentifier),
+			WithArguments(executionContext.RootArguments),
+		)
+		// hooks may have been run before, so we need to make sure that this is considered the root run
+		executionContext.rootRun = fullRun
+		fullRun.Start()
+		waitGroup := &sync.WaitGroup{}
+		waitGroup.Add(1)
+		go func() {
+			_, _ = io.Copy(stdoutWriter, fullRun.Log)
+			waitGroup.Done()
+		}()
+		fullRun.Wait()
+
+		waitGroup.Wait()
+	}
+
+	// if the execution is being cancelled, the runs' cancel hooks might still be terminating processes,
+	// so we wait for the cancellation to complete by briefly acquiring its lock
+	executionContext.cancellationMutex.Lock()
+	executionContext.cancellationMutex.Unlock()
+
+	if executionContext.Cancelled() {
+		executionContext.runHooks("onCancel", executionContext.Hooks.OnCancel, nil, stdoutWriter, false)
+	} else if exitCode := executionContext.ExitCode(); exitCode != 0 {
+		errorMessages := make([]string, 0, 10)
+		for _, err := range executionContext.Errors() {
+			errorMessages = append(errorMessages, err.Error())
+		}
+		failureArguments := map[string]interface{}{
+			"error":      strings.Join(errorMessages, "\n"),
+			"exitCode":   exitCode,
+			"failedPipe": pipelineIdentifier,
+		}
+		executionContext.runHooks("onError", executionContext.Hooks.OnError, failureArguments, stdoutWriter, false)
+	}
+	executionContext.runHooks("after", executionContext.Hooks.After, nil, stdoutWriter, false)
+
 	outputResult(fullRun, stdoutWriter)
+	outputKilledRuns(executionContext.KilledRuns(), stderrWriter)
 	executionContext.errorsMutex.Lock()
 	defer executionContext.errorsMutex.Unlock()
 	outputErrors(executionContext.errors, stderrWriter)
 }
 
+// Cancelled indicates whether the execution has been cancelled by the user
+func (executionContext *ExecutionContext) Cancelled() bool {
+	executionContext.cancelledMutex.RLock()
+	defer executionContext.cancelledMutex.RUnlock()
+	return executionContext.cancelled
+}
+
+// runHooks executes the referenced hook pipes in sequence, waiting for each one to complete
+//
+// A hook fails if it logs an error, exits with a non-zero exit code or is cancelled.
+// If haltOnFailure is true, no further hooks will be executed after a failure.
+// The return value indicates whether all hooks succeeded.
+func (executionContext *ExecutionContext) runHooks(
+	hookType string,
+	references []pipeline.Reference,
+	additionalArguments map[string]interface{},
+	logWriter io.Writer,
+	haltOnFailure bool,
+) bool {
+	succeeded := true
+	hookIdentifiers, hookArguments, info := pipeline.CollectReferences(references)
+	for index, hookIdentifier := range hookIdentifiers {
+		arguments := hookArguments[index]
+		err := stringmap.MergeIntoMap(arguments, additionalArguments)
+		if err != nil {
+			executionContext.addError(fmt.Errorf("`%v` hook %q: %w", hookType, info[index], err))
+			succeeded = false
+		} else {
+			errorCount := len(executionContext.Errors())
+			hookRun := executionContext.FullRun(
+				WithIdentifier(hookIdentifier),
+				WithArguments(arguments),
+			)
+			_, _ = io.Copy(logWriter, hookRun.Log)
+			hookRun.Wait()
+			if hookRun.Cancelled() ||
+				len(executionContext.Errors()) > errorCount ||
+				(hookRun.ExitCode != nil && *hookRun.ExitCode != 0) {
+				executionContext.addError(fmt.Errorf("`%v` hook %q failed", hookType, info[index]))
+				succeeded = false
+			}
+		}
+		if !succeeded && haltOnFailure {
+			break
+		}
+	}
+	return succeeded
+}
+
+func (executionContext *ExecutionContext) addError(err error) {
+	executionContext.errorsMutex.Lock()
+	defer executionContext.errorsMutex.Unlock()
+	executionContext.errors = multierror.Append(executionContext.errors, err)
+}
+
+// ExitCode summarizes the outcome of the execution as a process exit code
+//
+// It is the root run's exit code, if that is non-zero, 1 if any errors have been logged and 0 otherwise.
+func (executionContext *ExecutionContext) ExitCode() int {
+	if executionContext.rootRun != nil && executionContext.rootRun.ExitCode != nil && *executionContext.rootRun.ExitCode != 0 {
+		return *executionContext.rootRun.ExitCode
+	}
+	executionContext.errorsMutex.RLock()
+	defer executionContext.errorsMutex.RUnlock()
+	if executionContext.errors != nil && executionContext.errors.Len() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Errors lists all errors logged by any run
+func (executionContext *ExecutionContext) Errors() []error {
+	executionContext.errorsMutex.RLock()
+	defer executionContext.errorsMutex.RUnlock()
+	if executionContext.errors == nil {
+		return []error{}
+	}
+	return append([]error{}, executionContext.errors.Errors...)
+}
+
 // SetUpPipelines collects and parses all relevant pipeline files
 func (executionContext *ExecutionContext) SetUpPipelines(fileFlag string) error {
 	executionContext.Log.Tracef("Setting up pipelines...")
@@ -354,6 +511,8 @@ func (executionContext *ExecutionContext) SetUpCancelHandler(stdoutWriter io.Wri
 		signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
 		go func() {
 			<-signalChannel
+			executionContext.cancellationMutex.Lock()
+			defer executionContext.cancellationMutex.Unlock()
 			_, _ = io.WriteString(stdoutWriter, "\nExecution cancelled...\n")
 			err := executionContext.CancelAll()
 			if err != nil {
@@ -369,17 +528,36 @@ func (executionContext *ExecutionContext) SetUpCancelHandler(stdoutWriter io.Wri
 	}
 }
 
-// WaitForRun blocks until a run with the specified identifier is found and completes
-func (executionContext *ExecutionContext) WaitForRun(identifier string) *pipeline.Run {
-	for {
-		for _, run := range executionContext.Runs() {
-			if run != nil && run.Identifier != nil && *run.Identifier == identifier {
-				run.Wait()
-				return run
-			}
+// WaitForRun blocks until the run with the specified identifier that is referenced by the requesting run completes
+//
+// Only runs started before the requesting run by one of its ancestors (i.e. its siblings and those of its ancestors) can be referenced,
+// as there is no way of knowing whether any other run will ever be started or complete.
+// If there are several such runs, the most recently started one is used.
+// An error is returned if there is no such run or if its pipe was skipped, as it then has no results.
+func (executionContext *ExecutionContext) WaitForRun(identifier string, requestingRun *pipeline.Run) (*pipeline.Run, error) {
+	ancestors := map[*pipeline.Run]bool{nil: true}
+	for ancestor := requestingRun; ancestor != nil; ancestor = ancestor.Parent {
+		ancestors[ancestor] = true
+	}
+	runs := executionContext.Runs()
+	for index, run := range runs {
+		if run == requestingRun {
+			runs = runs[:index]
+			break
+		}
+	}
+	for index := len(runs) - 1; index >= 0; index-- {
+		run := runs[index]
+		if run.Identifier == nil || *run.Identifier != identifier || ancestors[run] || !ancestors[run.Parent] {
+			continue
+		}
+		run.Wait()
+		if run.Skipped() {
+			return nil, fmt.Errorf("referenced pipe %q was skipped", identifier)
 		}
-		time.Sleep(250)
+		return run, nil
 	}
+	return nil, fmt.Errorf("referenced pipe %q was not started before %q by the same or an ancestor pipe", identifier, requestingRun.Name())
 }
 
 // UserRuns lists all runs of pipes that are not built-in
@@ -394,6 +572,61 @@ func (executionContext *ExecutionContext) UserRuns() []*pipeline.Run {
 	return result
 }
 
+// AddKilledRun records a run whose processes had to be killed forcibly, as they did not terminate when asked to
+func (executionContext *ExecutionContext) AddKilledRun(run *pipeline.Run) {
+	executionContext.killedRunsMutex.Lock()
+	defer executionContext.killedRunsMutex.Unlock()
+	executionContext.killedRuns = append(executionContext.killedRuns, run)
+}
+
+// KilledRuns lists all runs whose processes had to be killed forcibly
+func (executionContext *ExecutionContext) KilledRuns() []*pipeline.Run {
+	executionContext.killedRunsMutex.RLock()
+	defer executionContext.killedRunsMutex.RUnlock()
+	return executionContext.killedRuns
+}
+
+// ParallelismLimits returns the semaphores that a run needs to acquire before executing a shell command
+//
+// The execution context's global limit comes first, followed by the limits set by the run and its ancestors.
+func (executionContext *ExecutionContext) ParallelismLimits(run *pipeline.Run) []*pipeline.Semaphore {
+	limits := run.ParallelismLimits()
+	if executionContext.parallelismLimit != nil {
+		limits = append([]*pipeline.Semaphore{executionContext.parallelismLimit}, limits...)
+	}
+	return limits
+}
+
+// Descendants lists all runs that have been started by the specified run, directly or indirectly
+func (executionContext *ExecutionContext) Descendants(ancestor *pipeline.Run) []*pipeline.Run {
+	runs := executionContext.Runs()
+	result := make([]*pipeline.Run, 0, len(runs))
+	for _, run := range runs {
+		for parent := run.Parent; parent != nil; parent = parent.Parent {
+			if parent == ancestor {
+				result = append(result, run)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// CancelWithDescendants cancels the run and all runs that it started, directly or indirectly,
+// executing their cancel hooks (the most recently started runs first)
+func (executionContext *ExecutionContext) CancelWithDescendants(run *pipeline.Run) error {
+	var err *multierror.Error
+	descendants := executionContext.Descendants(run)
+	for index := len(descendants) - 1; index >= 0; index-- {
+		descendant := descendants[index]
+		if !descendant.Completed() {
+			err = multierror.Append(err, descendant.Cancel())
+		}
+	}
+	err = multierror.Append(err, run.Cancel())
+	return err.ErrorOrNil()
+}
+
 func (executionContext *ExecutionContext) AddConnection(sourceRun *pipeline.Run, targetRun *pipeline.Run, label string) {
 	executionContext.connectionsMutex.Lock()
 	defer executionContext.connectionsMutex.Unlock()
@@ -71,3 +71,34 @@ func WithUserPromptImplementation(implementation func(
 		executionContext.UserPromptImplementation = implementation
 	}
 }
+
+// WithMaxParallel limits the number of shell commands executed simultaneously
+//
+// A limit of zero or less means that the number of shell commands is not restricted.
+func WithMaxParallel(limit int) ExecutionContextOption {
+	return func(executionContext *ExecutionContext) {
+		if limit > 0 {
+			executionContext.parallelismLimit = pipeline.NewSemaphore(limit)
+		} else {
+			executionContext.parallelismLimit = nil
+		}
+	}
+}
+
+// WithCacheDisabled prevents cached results from being used
+//
+// Results will still be written to the cache, replacing previously cached values.
+func WithCacheDisabled(disabled bool) ExecutionContextOption {
+	return func(executionContext *ExecutionContext) {
+		executionContext.CacheDisabled = disabled
+	}
+}
+
+// WithDryRun indicates that no side effects should occur
+//
+// Note that shell commands are only prevented from executing by a dry-run middleware stack.
+func WithDryRun(dryRun bool) ExecutionContextOption {
+	return func(executionContext *ExecutionContext) {
+		executionContext.DryRun = dryRun
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"io"
@@ -62,3 +63,30 @@ func TestExecutionContext_WithUserPromptImplementation(t *testing.T) {
 	_, _, _ = executionContext.UserPromptImplementation("test", nil, 0, 0, nil, nil)
 	waitGroup.Wait()
 }
+
+func TestExecutionContext_WithMaxParallel(t *testing.T) {
+	executionContext := NewExecutionContext(
+		WithMaxParallel(3),
+	)
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+	run.LimitParallelism(2)
+	limits := executionContext.ParallelismLimits(run)
+	require.Len(t, limits, 2)
+	require.Equal(t, 3, limits[0].Size())
+	require.Equal(t, 2, limits[1].Size())
+
+	executionContext = NewExecutionContext(
+		WithMaxParallel(0),
+	)
+	require.Len(t, executionContext.ParallelismLimits(run), 1)
+}
+
+func TestExecutionContext_WithCacheDisabled(t *testing.T) {
+	require.False(t, NewExecutionContext().CacheDisabled)
+	require.True(t, NewExecutionContext(WithCacheDisabled(true)).CacheDisabled)
+}
+
+func TestExecutionContext_WithDryRun(t *testing.T) {
+	require.False(t, NewExecutionContext().DryRun)
+	require.True(t, NewExecutionContext(WithDryRun(true)).DryRun)
+}
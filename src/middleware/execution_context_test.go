@@ -38,6 +38,33 @@ func TestExecutionContext_CancelAll(t *testing.T) {
 	require.True(t, executionContext.runs[2].Cancelled())
 }
 
+func TestExecutionContext_Descendants(t *testing.T) {
+	executionContext := NewExecutionContext()
+	parent, _ := pipeline.NewRun(nil, nil, nil, nil)
+	child, _ := pipeline.NewRun(nil, nil, nil, parent)
+	grandchild, _ := pipeline.NewRun(nil, nil, nil, child)
+	unrelated, _ := pipeline.NewRun(nil, nil, nil, nil)
+	executionContext.runs = []*pipeline.Run{parent, child, unrelated, grandchild}
+	require.Equal(t, []*pipeline.Run{child, grandchild}, executionContext.Descendants(parent))
+	require.Equal(t, []*pipeline.Run{grandchild}, executionContext.Descendants(child))
+	require.Equal(t, []*pipeline.Run{}, executionContext.Descendants(grandchild))
+}
+
+func TestExecutionContext_CancelWithDescendants(t *testing.T) {
+	executionContext := NewExecutionContext()
+	parent, _ := pipeline.NewRun(nil, nil, nil, nil)
+	child, _ := pipeline.NewRun(nil, nil, nil, parent)
+	unrelated, _ := pipeline.NewRun(nil, nil, nil, nil)
+	executionContext.runs = []*pipeline.Run{parent, child, unrelated}
+	parent.Start()
+	child.Start()
+	unrelated.Start()
+	require.Nil(t, executionContext.CancelWithDescendants(parent))
+	require.True(t, parent.Cancelled())
+	require.True(t, child.Cancelled())
+	require.False(t, unrelated.Cancelled())
+}
+
 func TestExecutionContext_FullRun_WithoutOptions(t *testing.T) {
 	executionContext := NewExecutionContext()
 	run := executionContext.FullRun()
@@ -62,6 +89,40 @@ func TestExecutionContext_FullRun_WithDefinitionsLookupOption(t *testing.T) {
 	require.Equal(t, arguments, run.ArgumentsCopy())
 }
 
+func TestExecutionContext_FullRun_invocationPosition(t *testing.T) {
+	parentIdentifier := "parent"
+	childIdentifier := "child"
+	executionContext := NewExecutionContext(WithDefinitionsLookup(map[string][]pipeline.Definition{
+		"parent": {
+			{
+				Invocations: []pipeline.Invocation{
+					{
+						Identifier: &childIdentifier,
+						Position:   pipeline.SourcePosition{Column: 7, Line: 42, Path: "test.pipe"},
+					},
+				},
+				Position: &pipeline.SourcePosition{Column: 3, Line: 40, Path: "test.pipe"},
+			},
+		},
+	}))
+	parentRun := executionContext.FullRun(WithIdentifier(&parentIdentifier))
+	require.Nil(t, parentRun.InvocationPosition)
+	require.Equal(t, "test.pipe:40:3", parentRun.SourcePosition().String())
+
+	childRun := executionContext.FullRun(WithIdentifier(&childIdentifier), WithParentRun(parentRun))
+	require.Equal(t, "test.pipe:42:7", childRun.SourcePosition().String())
+
+	// re-running the same pipe (e.g. to retry it) keeps the position
+	retryRun := executionContext.FullRun(WithIdentifier(&childIdentifier), WithParentRun(childRun))
+	require.Equal(t, childRun.InvocationPosition, retryRun.InvocationPosition)
+
+	// invocations written in the definitions of further ancestors are found as well
+	anonymousRun := executionContext.FullRun(WithParentRun(parentRun))
+	require.Nil(t, anonymousRun.SourcePosition())
+	nestedRun := executionContext.FullRun(WithIdentifier(&childIdentifier), WithParentRun(anonymousRun))
+	require.Equal(t, "test.pipe:42:7", nestedRun.SourcePosition().String())
+}
+
 func TestExecutionContext_FullRun_WithUnmergeableArguments(t *testing.T) {
 	defer func() {
 		if recover() == nil {
@@ -261,6 +322,111 @@ func TestExecutionContext_Execute(t *testing.T) {
 	require.Contains(t, buffer.String(), "===== RESULT =====")
 }
 
+func TestExecutionContext_Execute_WithRootArguments(t *testing.T) {
+	var rootRun *pipeline.Run
+	executionContext := NewExecutionContext(WithExecutionFunction(func(run *pipeline.Run) {
+		rootRun = run
+	}))
+	executionContext.RootArguments = map[string]interface{}{
+		"key": "value",
+	}
+	executionContext.Execute("test", new(bytes.Buffer), new(bytes.Buffer))
+	require.NotNil(t, rootRun)
+	require.Equal(t, map[string]interface{}{"key": "value"}, rootRun.InvocationArguments)
+	require.Equal(t, map[string]interface{}{"key": "value"}, rootRun.ArgumentsCopy())
+}
+
+func newHookTestExecutionContext(executionFunction func(run *pipeline.Run)) *ExecutionContext {
+	setUp := "set-up"
+	tearDown := "tear-down"
+	handleError := "handle-error"
+	handleCancel := "handle-cancel"
+	executionContext := NewExecutionContext(WithExecutionFunction(executionFunction))
+	executionContext.Hooks = pipeline.HookDefinitions{
+		Before:   []pipeline.Reference{{&setUp: {}}},
+		After:    []pipeline.Reference{{&tearDown: {"key": "value"}}},
+		OnError:  []pipeline.Reference{{&handleError: {}}},
+		OnCancel: []pipeline.Reference{{&handleCancel: {}}},
+	}
+	return executionContext
+}
+
+func TestExecutionContext_Execute_WithHooks(t *testing.T) {
+	executedRuns := make([]string, 0, 4)
+	executionContext := newHookTestExecutionContext(func(run *pipeline.Run) {
+		executedRuns = append(executedRuns, run.Name())
+	})
+	executionContext.Execute("test", new(bytes.Buffer), new(bytes.Buffer))
+	require.Equal(t, []string{"set-up", "test", "tear-down"}, executedRuns)
+	require.Equal(t, "test", executionContext.rootRun.Name())
+	require.Equal(t, 0, executionContext.ExitCode())
+	tearDownArguments := executionContext.Runs()[2].ArgumentsCopy()
+	require.Equal(t, "value", tearDownArguments["key"])
+}
+
+func TestExecutionContext_Execute_WithFailingBeforeHook(t *testing.T) {
+	executedRuns := make([]string, 0, 4)
+	executionContext := newHookTestExecutionContext(func(run *pipeline.Run) {
+		executedRuns = append(executedRuns, run.Name())
+		if run.Name() == "set-up" {
+			exitCode := 1
+			run.ExitCode = &exitCode
+		}
+	})
+	stderrBuffer := new(bytes.Buffer)
+	executionContext.Execute("test", new(bytes.Buffer), stderrBuffer)
+	require.Equal(t, []string{"set-up", "handle-error", "tear-down"}, executedRuns)
+	require.Equal(t, 1, executionContext.ExitCode())
+	require.Contains(t, stderrBuffer.String(), "`before` hook \"set-up\" failed")
+	require.Contains(t, stderrBuffer.String(), "skipping execution of \"test\"")
+}
+
+func TestExecutionContext_Execute_WithOnErrorHook(t *testing.T) {
+	executedRuns := make([]string, 0, 4)
+	var errorRun *pipeline.Run
+	executionContext := newHookTestExecutionContext(func(run *pipeline.Run) {
+		executedRuns = append(executedRuns, run.Name())
+		switch run.Name() {
+		case "test":
+			exitCode := 3
+			run.ExitCode = &exitCode
+		case "handle-error":
+			errorRun = run
+		}
+	})
+	executionContext.Execute("test", new(bytes.Buffer), new(bytes.Buffer))
+	require.Equal(t, []string{"set-up", "test", "handle-error", "tear-down"}, executedRuns)
+	require.Equal(t, 3, executionContext.ExitCode())
+	require.NotNil(t, errorRun)
+	errorArguments := errorRun.ArgumentsCopy()
+	require.Equal(t, 3, errorArguments["exitCode"])
+	require.Equal(t, "test", errorArguments["failedPipe"])
+	require.Equal(t, "", errorArguments["error"])
+}
+
+func TestExecutionContext_Execute_WithFailingAfterHook(t *testing.T) {
+	executionContext := newHookTestExecutionContext(func(run *pipeline.Run) {
+		if run.Name() == "tear-down" {
+			run.Log.Error(fmt.Errorf("test error"))
+		}
+	})
+	stderrBuffer := new(bytes.Buffer)
+	executionContext.Execute("test", new(bytes.Buffer), stderrBuffer)
+	require.Equal(t, 1, executionContext.ExitCode())
+	require.Contains(t, stderrBuffer.String(), "test error")
+	require.Contains(t, stderrBuffer.String(), "`after` hook \"tear-down\" failed")
+}
+
+func TestExecutionContext_Execute_WithOnCancelHook(t *testing.T) {
+	executedRuns := make([]string, 0, 4)
+	executionContext := newHookTestExecutionContext(func(run *pipeline.Run) {
+		executedRuns = append(executedRuns, run.Name())
+	})
+	executionContext.cancelled = true
+	executionContext.Execute("test", new(bytes.Buffer), new(bytes.Buffer))
+	require.Equal(t, []string{"set-up", "handle-cancel", "tear-down"}, executedRuns)
+}
+
 func TestExecutionContext_SetUpPipelines(t *testing.T) {
 	executionContext := NewExecutionContext(
 		WithParser(
@@ -500,21 +666,96 @@ func TestExecutionContext_CollectErrors(t *testing.T) {
 	require.Equal(t, "anonymous:\ntest error", executionContext.errors.Errors[0].Error())
 }
 
+func TestExecutionContext_ExitCode(t *testing.T) {
+	executionContext := NewExecutionContext()
+	require.Equal(t, 0, executionContext.ExitCode())
+
+	run := executionContext.FullRun()
+	run.Wait()
+	require.Equal(t, 0, executionContext.ExitCode())
+
+	exitCode := 3
+	run.ExitCode = &exitCode
+	require.Equal(t, 3, executionContext.ExitCode())
+}
+
+func TestExecutionContext_ExitCode_NestedRun(t *testing.T) {
+	executionContext := NewExecutionContext()
+	parent := executionContext.FullRun()
+	child := executionContext.FullRun(WithParentRun(parent))
+	parent.Wait()
+	child.Wait()
+
+	// nested runs may fail as expected, e.g. when probing for a command
+	exitCode := 1
+	child.ExitCode = &exitCode
+	require.Equal(t, 0, executionContext.ExitCode())
+}
+
+func TestExecutionContext_ExitCode_WithErrors(t *testing.T) {
+	executionContext := NewExecutionContext()
+	run := executionContext.FullRun(
+		WithTearDownFunc(func(run *pipeline.Run) {
+			run.Log.Error(fmt.Errorf("test error"))
+		}),
+	)
+	run.Wait()
+	require.Equal(t, 1, executionContext.ExitCode())
+}
+
 func TestExecutionContext_WaitForRun(t *testing.T) {
 	executionContext := NewExecutionContext()
-	runIdentifier := "test"
-	var run *pipeline.Run
-	go func() {
-		time.Sleep(500)
-		executionContext.FullRun(
-			WithIdentifier(&runIdentifier),
-			WithSetupFunc(func(calledRun *pipeline.Run) {
-				run = calledRun
-			}),
-		)
-	}()
-	waitedRun := executionContext.WaitForRun("test")
-	require.Equal(t, run, waitedRun)
+	buildIdentifier := "build"
+	parent := executionContext.FullRun()
+	build := executionContext.FullRun(WithParentRun(parent), WithIdentifier(&buildIdentifier))
+	sibling := executionContext.FullRun(WithParentRun(parent))
+	nephew := executionContext.FullRun(WithParentRun(sibling))
+
+	waitedRun, err := executionContext.WaitForRun("build", sibling)
+	require.Nil(t, err)
+	require.Equal(t, build, waitedRun)
+	require.True(t, waitedRun.Completed())
+
+	// siblings of ancestors can be referenced as well
+	waitedRun, err = executionContext.WaitForRun("build", nephew)
+	require.Nil(t, err)
+	require.Equal(t, build, waitedRun)
+
+	// the most recently started run is used
+	rebuild := executionContext.FullRun(WithParentRun(parent), WithIdentifier(&buildIdentifier))
+	lastSibling := executionContext.FullRun(WithParentRun(parent))
+	waitedRun, err = executionContext.WaitForRun("build", lastSibling)
+	require.Nil(t, err)
+	require.Equal(t, rebuild, waitedRun)
+}
+
+func TestExecutionContext_WaitForRun_Errors(t *testing.T) {
+	executionContext := NewExecutionContext()
+	buildIdentifier := "build"
+	parent := executionContext.FullRun(WithIdentifier(&buildIdentifier))
+	sibling := executionContext.FullRun(WithParentRun(parent))
+	skipped := executionContext.FullRun(WithParentRun(sibling), WithIdentifier(&buildIdentifier))
+	skipped.MarkSkipped()
+	requestingRun := executionContext.FullRun(WithParentRun(parent))
+	requestingChild := executionContext.FullRun(WithParentRun(sibling))
+	later := executionContext.FullRun(WithParentRun(parent), WithIdentifier(&buildIdentifier))
+
+	// neither ancestors, nor runs started later or by other pipes can be referenced
+	_, err := executionContext.WaitForRun("build", requestingRun)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `referenced pipe "build" was not started before "anonymous" by the same or an ancestor pipe`)
+
+	_, err = executionContext.WaitForRun("unknown", requestingRun)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `referenced pipe "unknown" was not started`)
+
+	_, err = executionContext.WaitForRun("build", requestingChild)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `referenced pipe "build" was skipped`)
+
+	waitedRun, err := executionContext.WaitForRun("build", executionContext.FullRun(WithParentRun(parent)))
+	require.Nil(t, err)
+	require.Equal(t, later, waitedRun)
 }
 
 func TestExecutionContext_UserRuns(t *testing.T) {
@@ -2,22 +2,21 @@
 package extract
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
-	"github.com/ghodss/yaml"
 	"io/ioutil"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
-// Middleware is a directory navigator
+// Middleware is a value extractor
 type Middleware struct {
 	fileReader func(fileName string) ([]byte, error)
-	WorkingDir string
 }
 
 // String is a human-readable description
@@ -25,18 +24,25 @@ func (Middleware) String() string {
 	return "extract"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"extract": {&middlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new Middleware instance
 func NewMiddleware() Middleware {
-	workingDir, _ := os.Getwd()
 	return Middleware{
 		fileReader: ioutil.ReadFile,
-		WorkingDir: workingDir,
 	}
 }
 
 type middlewareArguments struct {
-	File   string
-	Values map[string][]string
+	Defaults map[string]interface{}
+	File     string
+	Format   string
+	Values   map[string][]string
 }
 
 // Apply is where the middleware's logic resides
@@ -47,44 +53,167 @@ type middlewareArguments struct {
 func (extractMiddleware Middleware) Apply(
 	run *pipeline.Run,
 	next func(*pipeline.Run),
-	_ *middleware.ExecutionContext,
+	executionContext *middleware.ExecutionContext,
 ) {
-	collectArguments := middlewareArguments{}
-	pipeline.ParseArguments(&collectArguments, "extract", run)
+	extractArguments := middlewareArguments{}
+	pipeline.ParseArguments(&extractArguments, "extract", run)
+
+	if len(extractArguments.Values) > 0 {
+		if extractArguments.File == "" {
+			extractMiddleware.extractFromInput(run, extractArguments, executionContext)
+			return
+		}
 
-	if len(collectArguments.Values) > 0 {
-		fileName := filepath.Base(collectArguments.File)
+		fileName := filepath.Base(extractArguments.File)
 		run.Log.Debug(
 			fields.Symbol("🔍"),
 			fields.Message(fileName),
-			fields.Info(fmt.Sprintf("%v values", len(collectArguments.Values))),
+			fields.Info(fmt.Sprintf("%v values", len(extractArguments.Values))),
 			fields.Middleware(extractMiddleware),
 		)
 
-		fileData, err := extractMiddleware.fileReader(collectArguments.File)
-		run.Log.PossibleError(err)
-		fileDataAsYaml := make(map[string]interface{}, 10)
-		err = yaml.Unmarshal(fileData, &fileDataAsYaml)
-		run.Log.PossibleError(err)
-		for key, valuePath := range collectArguments.Values {
-			value, err := stringmap.GetValueInMap(fileDataAsYaml, valuePath...)
-			if err != nil {
-				run.Log.PossibleError(fmt.Errorf("failed to find value at path `%v`: %w", strings.Join(valuePath, "."), err))
-			}
-			if valueAsString, valueIsString := value.(string); valueIsString {
-				run.Log.Trace(
-					fields.Symbol("🔍"),
-					fields.Message(fileName),
-					fields.Info(fmt.Sprintf("%q: %q", key, valueAsString)),
-					fields.Middleware(extractMiddleware),
+		format := extractArguments.Format
+		if format == "" {
+			format = formatForFile(extractArguments.File)
+		}
+		fileData, err := extractMiddleware.fileReader(run.ResolvePath(extractArguments.File))
+		if err != nil {
+			run.Log.Error(err, fields.Middleware(extractMiddleware))
+		} else {
+			values, err := extractValues(fileData, format, extractArguments)
+			run.Log.PossibleError(err)
+			extractMiddleware.setValues(run, fileName, values)
+		}
+	}
+
+	next(run)
+}
+
+// extractFromInput extracts the values from the run's input
+//
+// As we cannot wait for the input before unwinding the rest of the stack, the pipe is executed in a separate full run.
+func (extractMiddleware Middleware) extractFromInput(
+	run *pipeline.Run,
+	extractArguments middlewareArguments,
+	executionContext *middleware.ExecutionContext,
+) {
+	if executionContext == nil {
+		run.Log.Error(
+			fmt.Errorf("values cannot be extracted from the input without an execution context"),
+			fields.Middleware(extractMiddleware),
+		)
+		return
+	}
+	format := extractArguments.Format
+	if format == "" {
+		format = "yaml"
+	}
+	run.Log.Debug(
+		fields.Symbol("💤"),
+		fields.Message("waiting for input to extract values from..."),
+		fields.Info(fmt.Sprintf("%v values", len(extractArguments.Values))),
+		fields.Middleware(extractMiddleware),
+	)
+	run.Log.Trace(
+		fields.DataStream(extractMiddleware, "copying stdin")...,
+	)
+	stdinCopy := run.Stdin.Copy()
+	run.Log.Trace(
+		fields.DataStream(extractMiddleware, "creating stdout writer")...,
+	)
+	stdoutAppender := run.Stdout.WriteCloser()
+	run.Log.Trace(
+		fields.DataStream(extractMiddleware, "creating stderr writer")...,
+	)
+	stderrAppender := run.Stderr.WriteCloser()
+	parentLogWriter := run.Log.AddWriteCloserEntry()
+	go func() {
+		inputData, inputErr := ioutil.ReadAll(stdinCopy)
+		values, err := extractValues(inputData, format, extractArguments)
+		executionContext.FullRun(
+			middleware.WithIdentifier(run.Identifier),
+			middleware.WithParentRun(run),
+			middleware.WithLogWriter(parentLogWriter),
+			middleware.WithArguments(run.ArgumentsCopy()),
+			middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+				// need to remove the argument to prevent infinite recursion
+				// we can only do this within the full run, as the pipe's definition might contain an `extract` argument
+				childRun.Log.PossibleError(childRun.RemoveArgumentAtPath("extract"))
+				childRun.Log.PossibleError(inputErr)
+				childRun.Log.PossibleError(err)
+				extractMiddleware.setValues(childRun, "input", values)
+				childRun.Log.Trace(
+					fields.DataStream(extractMiddleware, "merging parent stdin into child stdin")...,
+				)
+				childRun.Stdin.MergeWith(bytes.NewReader(inputData))
+			}),
+			middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+				executionContext.AddConnection(run, childRun, "extract")
+				childRun.Log.Trace(
+					fields.DataStream(extractMiddleware, "merging child stdout into parent stdout")...,
 				)
-				err = run.SetArgumentAtPath(valueAsString, key)
-				run.Log.PossibleError(err)
-			} else {
-				run.Log.Error(fmt.Errorf("extracted value not a string, but of unexpected type: `%T`", value))
+				childRun.Stdout.StartCopyingInto(stdoutAppender)
+				childRun.Log.Trace(
+					fields.DataStream(extractMiddleware, "merging child stderr into parent stderr")...,
+				)
+				childRun.Stderr.StartCopyingInto(stderrAppender)
+				go func() {
+					childRun.Wait()
+					// need to clean up by closing the writers we created
+					childRun.Log.PossibleError(stdoutAppender.Close())
+					childRun.Log.PossibleError(stderrAppender.Close())
+				}()
+			}))
+	}()
+}
+
+// extractValues parses the data and looks up the requested values
+//
+// Values that cannot be found are replaced by their defaults, if any, otherwise an error is returned for them.
+// Values found are returned nonetheless.
+func extractValues(data []byte, format string, extractArguments middlewareArguments) (map[string]interface{}, error) {
+	parsedData, err := parse(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %v data: %w", format, err)
+	}
+	values := make(map[string]interface{}, len(extractArguments.Values))
+	missingPaths := make([]string, 0, len(extractArguments.Values))
+	for key, valuePath := range extractArguments.Values {
+		if len(valuePath) == 0 {
+			missingPaths = append(missingPaths, fmt.Sprintf("`%v` (empty path)", key))
+			continue
+		}
+		value, err := stringmap.GetValueInMap(parsedData, valuePath...)
+		if err != nil {
+			defaultValue, haveDefault := extractArguments.Defaults[key]
+			if !haveDefault {
+				missingPaths = append(missingPaths, fmt.Sprintf("`%v`", strings.Join(valuePath, ".")))
+				continue
 			}
+			value = defaultValue
 		}
+		values[key] = normalize(value)
 	}
+	if len(missingPaths) > 0 {
+		sort.Strings(missingPaths)
+		return values, fmt.Errorf("failed to find value at path %v", strings.Join(missingPaths, ", "))
+	}
+	return values, nil
+}
 
-	next(run)
+func (extractMiddleware Middleware) setValues(run *pipeline.Run, source string, values map[string]interface{}) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		run.Log.Trace(
+			fields.Symbol("🔍"),
+			fields.Message(source),
+			fields.Info(fmt.Sprintf("%q: %v", key, values[key])),
+			fields.Middleware(extractMiddleware),
+		)
+		run.Log.PossibleError(run.SetArgumentAtPath(values[key], key))
+	}
 }
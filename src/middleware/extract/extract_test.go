@@ -1 +1,210 @@
 package extract
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestExtract_ResolvesFileRelativeToWorkingDirectory(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.SetWorkingDirectory("/project")
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"extract": map[string]interface{}{
+			"file": "config/values.yml",
+			"values": map[string]interface{}{
+				"result": []interface{}{"nested", "value"},
+			},
+		},
+	}, nil, parentRun)
+
+	readFileName := ""
+	var calledRun *pipeline.Run = nil
+	Middleware{
+		fileReader: func(fileName string) ([]byte, error) {
+			readFileName = fileName
+			return []byte("nested:\n  value: test\n"), nil
+		},
+	}.Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			calledRun = pipelineRun
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.NotNil(t, calledRun)
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "/project/config/values.yml", readFileName)
+	require.Equal(t, "test", calledRun.ArgumentsCopy()["result"])
+}
+
+func TestExtract_TypedValuesAndDefaults(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"extract": map[string]interface{}{
+			"file": "package.json",
+			"values": map[string]interface{}{
+				"version":  []interface{}{"version"},
+				"private":  []interface{}{"private"},
+				"files":    []interface{}{"files"},
+				"engines":  []interface{}{"engines"},
+				"major":    []interface{}{"major"},
+				"registry": []interface{}{"publishConfig", "registry"},
+			},
+			"defaults": map[string]interface{}{
+				"registry": "https://registry.npmjs.org",
+			},
+		},
+	}, nil, nil)
+
+	var calledRun *pipeline.Run = nil
+	Middleware{
+		fileReader: func(fileName string) ([]byte, error) {
+			return []byte(`{"version": "1.2.3", "private": true, "major": 1, "files": ["dist"], "engines": {"node": ">=12"}}`), nil
+		},
+	}.Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			calledRun = pipelineRun
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	arguments := calledRun.ArgumentsCopy()
+	require.Equal(t, "1.2.3", arguments["version"])
+	require.Equal(t, true, arguments["private"])
+	require.Equal(t, 1, arguments["major"])
+	require.Equal(t, []interface{}{"dist"}, arguments["files"])
+	require.Equal(t, map[string]interface{}{"node": ">=12"}, arguments["engines"])
+	require.Equal(t, "https://registry.npmjs.org", arguments["registry"])
+}
+
+func TestExtract_MissingValue(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"extract": map[string]interface{}{
+			"file": "go.mod",
+			"values": map[string]interface{}{
+				"module":  []interface{}{"module"},
+				"missing": []interface{}{"require", "github.com/missing/module"},
+			},
+		},
+	}, nil, nil)
+
+	var calledRun *pipeline.Run = nil
+	Middleware{
+		fileReader: func(fileName string) ([]byte, error) {
+			return []byte("module github.com/test/module\n"), nil
+		},
+	}.Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			calledRun = pipelineRun
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "failed to find value at path `require.github.com/missing/module`")
+	require.Equal(t, "github.com/test/module", calledRun.ArgumentsCopy()["module"])
+}
+
+func TestExtract_FileReadError(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"extract": map[string]interface{}{
+			"file": "missing.yml",
+			"values": map[string]interface{}{
+				"value": []interface{}{"value"},
+			},
+		},
+	}, nil, nil)
+
+	nextCalled := false
+	Middleware{
+		fileReader: func(fileName string) ([]byte, error) {
+			return nil, fmt.Errorf("test error")
+		},
+	}.Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			nextCalled = true
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.True(t, nextCalled)
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Equal(t, "test error", run.Log.LastError().Error())
+}
+
+func TestExtract_FromInput(t *testing.T) {
+	identifier := "test"
+	run, _ := pipeline.NewRun(&identifier, map[string]interface{}{
+		"extract": map[string]interface{}{
+			"format": "toml",
+			"values": map[string]interface{}{
+				"version": []interface{}{"package", "version"},
+			},
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("[package]\nversion = \"0.3.1\"\n"))
+
+	var childArguments map[string]interface{}
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+				childArguments = childRun.ArgumentsCopy()
+				stdinCopy := childRun.Stdin.Copy()
+				stdoutWriter := childRun.Stdout.WriteCloser()
+				childRun.DontCompleteBefore(func() {
+					_, _ = io.Copy(stdoutWriter, stdinCopy)
+					_ = stdoutWriter.Close()
+				})
+			}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, map[string]interface{}{"version": "0.3.1"}, childArguments)
+	require.Equal(t, "[package]\nversion = \"0.3.1\"\n", run.Stdout.String())
+}
+
+func TestExtract_FromInputWithoutExecutionContext(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"extract": map[string]interface{}{
+			"values": map[string]interface{}{
+				"value": []interface{}{"value"},
+			},
+		},
+	}, nil, nil)
+
+	nextCalled := false
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			nextCalled = true
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.False(t, nextCalled)
+	require.Equal(t, 1, run.Log.ErrorCount())
+}
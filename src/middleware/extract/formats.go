@@ -0,0 +1,240 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/custom/toml"
+	"github.com/ghodss/yaml"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// formatForFile determines the format of a file from its name
+func formatForFile(fileName string) string {
+	baseName := filepath.Base(fileName)
+	switch {
+	case baseName == "go.mod":
+		return "gomod"
+	case baseName == ".env" || strings.HasPrefix(baseName, ".env."):
+		return "env"
+	}
+	switch strings.ToLower(filepath.Ext(baseName)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	case ".env":
+		return "env"
+	case ".ini", ".cfg":
+		return "ini"
+	default:
+		return "yaml"
+	}
+}
+
+func parse(data []byte, format string) (map[string]interface{}, error) {
+	switch format {
+	case "yaml":
+		result := make(map[string]interface{}, 10)
+		err := yaml.Unmarshal(data, &result)
+		return result, err
+	case "json":
+		result := make(map[string]interface{}, 10)
+		err := json.Unmarshal(data, &result)
+		return result, err
+	case "toml":
+		return toml.Unmarshal(data)
+	case "env":
+		return parseEnv(data)
+	case "ini":
+		return parseIni(data)
+	case "gomod":
+		return parseGoMod(data)
+	default:
+		return nil, fmt.Errorf("invalid format %q, expected one of `yaml`, `json`, `toml`, `env`, `ini`, `gomod`", format)
+	}
+}
+
+// normalize converts whole numbers to integers, as yaml and json numbers are always parsed as floats
+func normalize(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case float64:
+		if typedValue == float64(int(typedValue)) {
+			return int(typedValue)
+		}
+		return typedValue
+	case int64:
+		return int(typedValue)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typedValue))
+		for key, nestedValue := range typedValue {
+			result[key] = normalize(nestedValue)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(typedValue))
+		for _, item := range typedValue {
+			result = append(result, normalize(item))
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// parseEnv reads `KEY=value` lines, as used in .env files
+func parseEnv(data []byte) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, 10)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line %v, expected `KEY=value`", lineNumber)
+		}
+		value, err := unquote(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in line %v: %w", lineNumber, err)
+		}
+		result[strings.TrimSpace(parts[0])] = value
+	}
+	return result, scanner.Err()
+}
+
+// parseIni reads `key = value` lines, grouped by `[section]` headers
+//
+// Values before the first section header are not nested.
+func parseIni(data []byte) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, 10)
+	section := result
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			sectionName := strings.TrimSpace(line[1 : len(line)-1])
+			existingSection, haveSection := result[sectionName].(map[string]interface{})
+			if !haveSection {
+				existingSection = make(map[string]interface{}, 10)
+				result[sectionName] = existingSection
+			}
+			section = existingSection
+			continue
+		}
+		separatorIndex := strings.IndexAny(line, "=:")
+		if separatorIndex < 0 {
+			return nil, fmt.Errorf("invalid line %v, expected `key = value`", lineNumber)
+		}
+		value, err := unquote(strings.TrimSpace(line[separatorIndex+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in line %v: %w", lineNumber, err)
+		}
+		section[strings.TrimSpace(line[:separatorIndex])] = value
+	}
+	return result, scanner.Err()
+}
+
+// unquote removes quotes from a value, stripping trailing comments from unquoted values
+func unquote(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "\""):
+		closingIndex := closingQuoteIndex(value)
+		if closingIndex < 0 {
+			return "", fmt.Errorf("missing closing quote in %v", value)
+		}
+		return strconv.Unquote(value[:closingIndex+1])
+	case strings.HasPrefix(value, "'"):
+		closingIndex := strings.Index(value[1:], "'")
+		if closingIndex < 0 {
+			return "", fmt.Errorf("missing closing quote in %v", value)
+		}
+		return value[1 : closingIndex+1], nil
+	default:
+		if commentIndex := strings.Index(value, " #"); commentIndex >= 0 {
+			value = value[:commentIndex]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
+
+func closingQuoteIndex(value string) int {
+	for index := 1; index < len(value); index++ {
+		switch value[index] {
+		case '\\':
+			index++
+		case '"':
+			return index
+		}
+	}
+	return -1
+}
+
+// parseGoMod reads the module path, go version and requirements from a go.mod file
+//
+// Requirements and replacements are keyed by module path, e.g. `require` > `github.com/spf13/cobra`.
+func parseGoMod(data []byte) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, 4)
+	require := make(map[string]interface{}, 10)
+	replace := make(map[string]interface{}, 2)
+	result["require"] = require
+	result["replace"] = replace
+	block := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if commentIndex := strings.Index(line, "//"); commentIndex >= 0 {
+			line = line[:commentIndex]
+		}
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+		if block != "" {
+			if words[0] == ")" {
+				block = ""
+				continue
+			}
+			words = append([]string{block}, words...)
+		} else if len(words) == 2 && words[1] == "(" {
+			block = words[0]
+			continue
+		}
+		switch words[0] {
+		case "module", "go":
+			if len(words) > 1 {
+				result[words[0]] = strings.Trim(words[1], "\"")
+			}
+		case "require":
+			if len(words) > 2 {
+				require[words[1]] = words[2]
+			}
+		case "replace":
+			if arrowIndex := indexOf(words, "=>"); arrowIndex > 0 && arrowIndex < len(words)-1 {
+				replace[words[1]] = strings.Join(words[arrowIndex+1:], " ")
+			}
+		}
+	}
+	return result, scanner.Err()
+}
+
+func indexOf(values []string, value string) int {
+	for index, existingValue := range values {
+		if existingValue == value {
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,114 @@
+package extract
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFormats_FormatForFile(t *testing.T) {
+	require.Equal(t, "json", formatForFile("/project/package.json"))
+	require.Equal(t, "gomod", formatForFile("go.mod"))
+	require.Equal(t, "toml", formatForFile("Cargo.toml"))
+	require.Equal(t, "env", formatForFile(".env"))
+	require.Equal(t, "env", formatForFile(".env.production"))
+	require.Equal(t, "env", formatForFile("config/test.env"))
+	require.Equal(t, "ini", formatForFile("setup.cfg"))
+	require.Equal(t, "yaml", formatForFile(".metadata.current.yaml"))
+	require.Equal(t, "yaml", formatForFile("README"))
+}
+
+func TestFormats_InvalidFormat(t *testing.T) {
+	_, err := parse([]byte(""), "xml")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid format \"xml\"")
+}
+
+func TestFormats_Normalize(t *testing.T) {
+	require.Equal(t, map[string]interface{}{
+		"integer": 3,
+		"float":   0.5,
+		"list":    []interface{}{1, "text"},
+	}, normalize(map[string]interface{}{
+		"integer": 3.0,
+		"float":   0.5,
+		"list":    []interface{}{1.0, "text"},
+	}))
+}
+
+func TestFormats_ParseEnv(t *testing.T) {
+	result, err := parseEnv([]byte(`# comment
+export NAME=pipedream
+EMPTY=
+QUOTED="line\nbreak" # comment
+LITERAL='$HOME # not a comment'
+UNQUOTED=value # comment
+`))
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"NAME":     "pipedream",
+		"EMPTY":    "",
+		"QUOTED":   "line\nbreak",
+		"LITERAL":  "$HOME # not a comment",
+		"UNQUOTED": "value",
+	}, result)
+
+	_, err = parseEnv([]byte("INVALID"))
+	require.NotNil(t, err)
+	_, err = parseEnv([]byte("UNCLOSED=\"value"))
+	require.NotNil(t, err)
+}
+
+func TestFormats_ParseIni(t *testing.T) {
+	result, err := parseIni([]byte(`global = value
+; comment
+[metadata]
+name = pipedream
+version: 1.0
+
+[options]
+zip_safe = "false"
+`))
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"global": "value",
+		"metadata": map[string]interface{}{
+			"name":    "pipedream",
+			"version": "1.0",
+		},
+		"options": map[string]interface{}{
+			"zip_safe": "false",
+		},
+	}, result)
+
+	_, err = parseIni([]byte("[section]\ninvalid"))
+	require.NotNil(t, err)
+}
+
+func TestFormats_ParseGoMod(t *testing.T) {
+	result, err := parseGoMod([]byte(`module github.com/Layer9Berlin/pipedream
+
+go 1.15
+
+require github.com/ghodss/yaml v1.0.0
+
+require (
+	github.com/hashicorp/errwrap v1.1.0 // indirect
+	github.com/spf13/cobra v1.1.1
+)
+
+replace github.com/old/module => ../module
+`))
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"module": "github.com/Layer9Berlin/pipedream",
+		"go":     "1.15",
+		"require": map[string]interface{}{
+			"github.com/ghodss/yaml":       "v1.0.0",
+			"github.com/hashicorp/errwrap": "v1.1.0",
+			"github.com/spf13/cobra":       "v1.1.1",
+		},
+		"replace": map[string]interface{}{
+			"github.com/old/module": "../module",
+		},
+	}, result)
+}
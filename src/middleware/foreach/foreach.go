@@ -0,0 +1,304 @@
+// Package foreach provides a middleware that invokes a child pipe for each item of a list taken from the input or the arguments
+package foreach
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	customstrings "github.com/Layer9Berlin/pipedream/src/custom/strings"
+	"github.com/Layer9Berlin/pipedream/src/logging/fields"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/ghodss/yaml"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Middleware is an item iterator
+type Middleware struct {
+}
+
+// String is a human-readable description
+func (Middleware) String() string {
+	return "foreach"
+}
+
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"foreach": {&arguments},
+	}
+}
+
+// NewMiddleware creates a new Middleware instance
+func NewMiddleware() Middleware {
+	return Middleware{}
+}
+
+type middlewareArguments struct {
+	Index     string
+	Item      string
+	Items     []interface{}
+	Output    string
+	Parallel  int
+	Pipe      pipeline.Reference
+	Separator string
+	Split     string
+}
+
+func newMiddlewareArguments() middlewareArguments {
+	return middlewareArguments{
+		Index:     "index",
+		Item:      "item",
+		Items:     nil,
+		Output:    "concat",
+		Parallel:  0,
+		Pipe:      nil,
+		Separator: "",
+		Split:     "lines",
+	}
+}
+
+// Apply is where the middleware's logic resides
+//
+// It adapts the run based on its slice of the run's arguments.
+// It may also trigger side effects such as executing shell commands or full runs of other pipelines.
+// When done, this function should call next in order to continue unwinding the stack.
+func (foreachMiddleware Middleware) Apply(
+	run *pipeline.Run,
+	next func(*pipeline.Run),
+	executionContext *middleware.ExecutionContext,
+) {
+	arguments := newMiddlewareArguments()
+	if !pipeline.ParseArguments(&arguments, "foreach", run) {
+		next(run)
+		return
+	}
+
+	childIdentifier, childArguments, err := parseChildReference(arguments.Pipe)
+	if err != nil {
+		run.Log.Error(err, fields.Middleware(foreachMiddleware))
+		next(run)
+		return
+	}
+	splitItems, err := itemSplitter(arguments)
+	if err != nil {
+		run.Log.Error(err, fields.Middleware(foreachMiddleware))
+		next(run)
+		return
+	}
+	if arguments.Output != "concat" && arguments.Output != "yaml" {
+		run.Log.Error(
+			fmt.Errorf("invalid output %q, expected one of `concat`, `yaml`", arguments.Output),
+			fields.Middleware(foreachMiddleware),
+		)
+		next(run)
+		return
+	}
+
+	next(run)
+
+	source := arguments.Split
+	if arguments.Items != nil {
+		source = "arguments"
+	}
+	run.Log.Debug(
+		fields.Symbol("🔁"),
+		fields.Message("foreach"),
+		fields.Info(fmt.Sprintf("%v (items from %v)", childIdentifierString(childIdentifier), source)),
+		fields.Middleware(foreachMiddleware),
+	)
+	if arguments.Parallel > 0 {
+		run.LimitParallelism(arguments.Parallel)
+	}
+
+	stdinCopy := run.Stdin.Copy()
+	stdoutAppender := run.Stdout.WriteCloser()
+	stderrAppender := run.Stderr.WriteCloser()
+	run.DontCompleteBefore(func() {
+		defer func() {
+			run.Log.PossibleError(stdoutAppender.Close())
+			run.Log.PossibleError(stderrAppender.Close())
+		}()
+
+		completeInput, err := ioutil.ReadAll(stdinCopy)
+		run.Log.PossibleError(err)
+		items := arguments.Items
+		if items == nil {
+			items, err = splitItems(completeInput)
+			if err != nil {
+				run.Log.Error(err, fields.Middleware(foreachMiddleware))
+				return
+			}
+		}
+		run.Log.Trace(
+			fields.Symbol("🔁"),
+			fields.Message(fmt.Sprintf("%v item(s)", len(items))),
+			fields.Middleware(foreachMiddleware),
+		)
+
+		childRuns := make([]*pipeline.Run, len(items))
+		stdoutResults := make([][]byte, len(items))
+		stderrResults := make([][]byte, len(items))
+		waitGroup := &sync.WaitGroup{}
+		waitGroup.Add(len(items))
+		for index, item := range items {
+			index := index
+			itemArguments := itemArguments(childArguments, arguments.Item, item, arguments.Index, index)
+			childInput := completeInput
+			if arguments.Items == nil {
+				// when iterating over the input, each child only receives its own item
+				childInput = []byte(customstrings.FromValue(item))
+			}
+			childRuns[index] = executionContext.FullRun(
+				middleware.WithParentRun(run),
+				middleware.WithIdentifier(childIdentifier),
+				middleware.WithArguments(itemArguments),
+				middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+					childRun.Stdin.Replace(bytes.NewReader(childInput))
+					executionContext.AddConnection(run, childRun, fmt.Sprintf("foreach %v", index))
+				}),
+				middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+					stdoutCopy := childRun.Stdout.Copy()
+					stderrCopy := childRun.Stderr.Copy()
+					go func() {
+						defer waitGroup.Done()
+						readWaitGroup := &sync.WaitGroup{}
+						readWaitGroup.Add(1)
+						go func() {
+							defer readWaitGroup.Done()
+							stderrResults[index], _ = ioutil.ReadAll(stderrCopy)
+						}()
+						stdoutResults[index], _ = ioutil.ReadAll(stdoutCopy)
+						readWaitGroup.Wait()
+					}()
+				}))
+		}
+		waitGroup.Wait()
+
+		// merge the results in the order of the items, irrespective of the order in which the children completed
+		for index := range items {
+			_, err = stderrAppender.Write(stderrResults[index])
+			run.Log.PossibleError(err)
+		}
+		output, err := mergeOutputs(stdoutResults, arguments.Output)
+		run.Log.PossibleError(err)
+		_, err = stdoutAppender.Write(output)
+		run.Log.PossibleError(err)
+	})
+}
+
+func parseChildReference(reference pipeline.Reference) (*string, map[string]interface{}, error) {
+	if len(reference) == 0 {
+		return nil, nil, fmt.Errorf("missing `pipe` argument specifying the pipe to invoke for each item")
+	}
+	identifiers, arguments, _ := pipeline.CollectReferences([]pipeline.Reference{reference})
+	return identifiers[0], arguments[0], nil
+}
+
+func childIdentifierString(identifier *string) string {
+	if identifier == nil {
+		return "anonymous"
+	}
+	return *identifier
+}
+
+func itemSplitter(arguments middlewareArguments) (func([]byte) ([]interface{}, error), error) {
+	switch arguments.Split {
+	case "lines":
+		return splitLines, nil
+	case "regex":
+		if arguments.Separator == "" {
+			return nil, fmt.Errorf("missing `separator` argument required to split input by regex")
+		}
+		separator, err := regexp.Compile(arguments.Separator)
+		if err != nil {
+			return nil, fmt.Errorf("invalid separator %q: %w", arguments.Separator, err)
+		}
+		return func(input []byte) ([]interface{}, error) {
+			return nonEmptyItems(separator.Split(string(input), -1)), nil
+		}, nil
+	case "yaml":
+		return func(input []byte) ([]interface{}, error) {
+			items := make([]interface{}, 0, 10)
+			err := yaml.Unmarshal(input, &items)
+			if err != nil {
+				return nil, fmt.Errorf("input is not a yaml array: %w", err)
+			}
+			return items, nil
+		}, nil
+	case "json":
+		return func(input []byte) ([]interface{}, error) {
+			items := make([]interface{}, 0, 10)
+			err := json.Unmarshal(input, &items)
+			if err != nil {
+				return nil, fmt.Errorf("input is not a json array: %w", err)
+			}
+			return items, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid split %q, expected one of `lines`, `regex`, `yaml`, `json`", arguments.Split)
+	}
+}
+
+func splitLines(input []byte) ([]interface{}, error) {
+	lines := customstrings.NonEmptyLines(string(input))
+	items := make([]interface{}, len(lines))
+	for index, line := range lines {
+		items[index] = line
+	}
+	return items, nil
+}
+
+func nonEmptyItems(values []string) []interface{} {
+	items := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			items = append(items, value)
+		}
+	}
+	return items
+}
+
+// itemArguments binds the item and its index to the child's arguments
+//
+// Map items are additionally bound by key, e.g. as `item.name`, since maps cannot be interpolated.
+func itemArguments(
+	childArguments map[string]interface{},
+	itemKey string,
+	item interface{},
+	indexKey string,
+	index int,
+) map[string]interface{} {
+	result := make(map[string]interface{}, len(childArguments)+2)
+	for key, value := range childArguments {
+		result[key] = value
+	}
+	result[itemKey] = customstrings.FromValue(item)
+	result[indexKey] = index
+	if itemAsMap, itemIsMap := item.(map[string]interface{}); itemIsMap {
+		for key, value := range itemAsMap {
+			result[fmt.Sprintf("%v.%v", itemKey, key)] = customstrings.FromValue(value)
+		}
+	}
+	return result
+}
+
+func mergeOutputs(outputs [][]byte, format string) ([]byte, error) {
+	if format == "yaml" {
+		values := make([]string, 0, len(outputs))
+		for _, output := range outputs {
+			values = append(values, strings.TrimSuffix(string(output), "\n"))
+		}
+		return yaml.Marshal(values)
+	}
+	buffer := new(bytes.Buffer)
+	for _, output := range outputs {
+		_, _ = io.Copy(buffer, bytes.NewReader(output))
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,310 @@
+package foreach
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForeach_Lines(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"foreach": map[string]interface{}{
+			"pipe": "child",
+		},
+	}, nil, nil)
+	run.Log.SetLevel(logrus.DebugLevel)
+	run.Stdin.Replace(strings.NewReader("first\n\nsecond\nthird\n"))
+
+	childInputs := make([]string, 0, 3)
+	childInputsMutex := &sync.Mutex{}
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					arguments := childRun.ArgumentsCopy()
+					index := arguments["index"].(int)
+					stdinCopy := childRun.Stdin.Copy()
+					stdoutWriter := childRun.Stdout.WriteCloser()
+					childRun.DontCompleteBefore(func() {
+						completeInput, err := ioutil.ReadAll(stdinCopy)
+						require.Nil(t, err)
+						childInputsMutex.Lock()
+						childInputs = append(childInputs, string(completeInput))
+						childInputsMutex.Unlock()
+						// complete in reverse order to check that the output order is preserved
+						time.Sleep(time.Duration(10-index) * 5 * time.Millisecond)
+						_, _ = stdoutWriter.Write([]byte(fmt.Sprintf("%v: %v\n", index, arguments["item"])))
+						_ = stdoutWriter.Close()
+					})
+					childRun.Stderr.Replace(strings.NewReader(fmt.Sprintf("stderr %v\n", index)))
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "0: first\n1: second\n2: third\n", run.Stdout.String())
+	require.Equal(t, "stderr 0\nstderr 1\nstderr 2\n", run.Stderr.String())
+	require.ElementsMatch(t, []string{"first", "second", "third"}, childInputs)
+	require.Contains(t, run.Log.String(), "foreach")
+}
+
+func TestForeach_Regex(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"foreach": map[string]interface{}{
+			"pipe":      "child",
+			"split":     "regex",
+			"separator": ",\\s*",
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("a, b,c"))
+
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					arguments := childRun.ArgumentsCopy()
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("%v: %v\n", arguments["index"], arguments["item"])))
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "0: a\n1: b\n2: c\n", run.Stdout.String())
+}
+
+func TestForeach_Json(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"foreach": map[string]interface{}{
+			"pipe": map[string]interface{}{
+				"child": map[string]interface{}{
+					"arg": "value",
+				},
+			},
+			"split": "json",
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader(`[{"name": "a", "version": 1}, "b", 3]`))
+
+	childArguments := make([]map[string]interface{}, 0, 3)
+	childArgumentsMutex := &sync.Mutex{}
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childArgumentsMutex.Lock()
+					defer childArgumentsMutex.Unlock()
+					childArguments = append(childArguments, childRun.ArgumentsCopy())
+					childRun.Stdout.Replace(strings.NewReader(fmt.Sprint(childRun.ArgumentsCopy()["item"])))
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, `{"name":"a","version":1}b3`, run.Stdout.String())
+	require.ElementsMatch(t, []map[string]interface{}{
+		{
+			"arg":          "value",
+			"item":         `{"name":"a","version":1}`,
+			"item.name":    "a",
+			"item.version": "1",
+			"index":        0,
+		},
+		{
+			"arg":   "value",
+			"item":  "b",
+			"index": 1,
+		},
+		{
+			"arg":   "value",
+			"item":  "3",
+			"index": 2,
+		},
+	}, childArguments)
+}
+
+func TestForeach_InvalidYaml(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"foreach": map[string]interface{}{
+			"pipe":  "child",
+			"split": "yaml",
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("key: value"))
+
+	childRunCount := 0
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childRunCount++
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "input is not a yaml array")
+	require.Equal(t, 0, childRunCount)
+	require.Equal(t, "", run.Stdout.String())
+}
+
+func TestForeach_ItemsWithYamlOutput(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"foreach": map[string]interface{}{
+			"pipe":     "child",
+			"items":    []interface{}{"a", "b"},
+			"item":     "package",
+			"index":    "position",
+			"output":   "yaml",
+			"parallel": 1,
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("parent input"))
+
+	childInputs := make([]string, 0, 2)
+	childInputsMutex := &sync.Mutex{}
+	var childRun *pipeline.Run
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(newChildRun *pipeline.Run) {
+					childRun = newChildRun
+					arguments := newChildRun.ArgumentsCopy()
+					stdinCopy := newChildRun.Stdin.Copy()
+					stdoutWriter := newChildRun.Stdout.WriteCloser()
+					newChildRun.DontCompleteBefore(func() {
+						completeInput, _ := ioutil.ReadAll(stdinCopy)
+						childInputsMutex.Lock()
+						childInputs = append(childInputs, string(completeInput))
+						childInputsMutex.Unlock()
+						_, _ = stdoutWriter.Write([]byte(fmt.Sprintf("%v %v\n", arguments["position"], arguments["package"])))
+						_ = stdoutWriter.Close()
+					})
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "- 0 a\n- 1 b\n", run.Stdout.String())
+	require.Equal(t, []string{"parent input", "parent input"}, childInputs)
+	require.Len(t, childRun.ParallelismLimits(), 1)
+}
+
+func TestForeach_EmptyInput(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"foreach": map[string]interface{}{
+			"pipe":   "child",
+			"output": "yaml",
+		},
+	}, nil, nil)
+
+	childRunCount := 0
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(
+				func(childRun *pipeline.Run) {
+					childRunCount++
+				}),
+		),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "[]\n", run.Stdout.String())
+	require.Equal(t, 0, childRunCount)
+}
+
+func TestForeach_InvalidArguments(t *testing.T) {
+	for name, arguments := range map[string]map[string]interface{}{
+		"missing pipe": {
+			"split": "lines",
+		},
+		"invalid split": {
+			"pipe":  "child",
+			"split": "words",
+		},
+		"missing separator": {
+			"pipe":  "child",
+			"split": "regex",
+		},
+		"invalid separator": {
+			"pipe":      "child",
+			"split":     "regex",
+			"separator": "(",
+		},
+		"invalid output": {
+			"pipe":   "child",
+			"output": "xml",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run, _ := pipeline.NewRun(nil, map[string]interface{}{
+				"foreach": arguments,
+			}, nil, nil)
+
+			nextCalled := false
+			NewMiddleware().Apply(
+				run,
+				func(pipelineRun *pipeline.Run) {
+					nextCalled = true
+				},
+				nil,
+			)
+			run.Start()
+			run.Wait()
+
+			require.True(t, nextCalled)
+			require.Equal(t, 1, run.Log.ErrorCount())
+		})
+	}
+}
+
+func TestForeach_Inactive(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+	run.Stdin.Replace(strings.NewReader("input"))
+
+	nextCalled := false
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			nextCalled = true
+			pipelineRun.Stdout.Replace(strings.NewReader("output"))
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.True(t, nextCalled)
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "output", run.Stdout.String())
+}
@@ -16,6 +16,13 @@ func (Middleware) String() string {
 	return "inherit"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"inherit": {&[]string{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
@@ -2,6 +2,7 @@
 package _input
 
 import (
+	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
@@ -18,18 +19,38 @@ func (inputMiddleware Middleware) String() string {
 	return "input"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (inputMiddleware Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"input": {&arguments},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
 }
 
 type middlewareArguments struct {
-	Text *string
+	File      *string
+	From      string
+	Glob      *string
+	Pipe      pipeline.Reference
+	Separator string
+	Template  *string
+	Text      *string
 }
 
 func newMiddlewareArguments() middlewareArguments {
 	return middlewareArguments{
-		Text: nil,
+		File:      nil,
+		From:      "args",
+		Glob:      nil,
+		Pipe:      nil,
+		Separator: "",
+		Template:  nil,
+		Text:      nil,
 	}
 }
 
@@ -41,32 +62,122 @@ func newMiddlewareArguments() middlewareArguments {
 func (inputMiddleware Middleware) Apply(
 	run *pipeline.Run,
 	next func(pipelineRun *pipeline.Run),
-	_ *middleware.ExecutionContext,
+	executionContext *middleware.ExecutionContext,
 ) {
 	arguments := newMiddlewareArguments()
 	pipeline.ParseArguments(&arguments, "input", run)
 
 	next(run)
 
-	if arguments.Text != nil {
+	sources, err := inputSources(arguments, run)
+	if err != nil {
+		run.Log.Error(err, fields.Middleware(inputMiddleware))
+		return
+	}
+	if len(sources) > 0 {
+		inputMiddleware.replaceInput(run, sources)
+	}
+
+	if len(arguments.Pipe) > 0 {
+		inputMiddleware.processInput(run, arguments.Pipe, executionContext)
+	}
+}
+
+// replaceInput discards the previous input, replacing it with the concatenated sources
+func (inputMiddleware Middleware) replaceInput(run *pipeline.Run, sources []inputSource) {
+	for _, source := range sources {
 		run.Log.Debug(
 			fields.Symbol("↘️"),
-			fields.Message(*arguments.Text),
+			fields.Message(source.description),
 			fields.Middleware(inputMiddleware),
 		)
-		stdinIntercept := run.Stdin.Intercept()
-		waitGroup := &sync.WaitGroup{}
-		waitGroup.Add(1)
-		go func() {
-			defer waitGroup.Done()
-			_, err := ioutil.ReadAll(stdinIntercept)
-			run.Log.PossibleError(err)
-		}()
-		go func() {
-			_, err := stdinIntercept.Write([]byte(*arguments.Text))
+	}
+	stdinIntercept := run.Stdin.Intercept()
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(1)
+	go func() {
+		defer waitGroup.Done()
+		_, err := ioutil.ReadAll(stdinIntercept)
+		run.Log.PossibleError(err)
+	}()
+	go func() {
+		// sources are read lazily, so that files written by previous pipes can be used
+		for _, source := range sources {
+			data, err := source.read()
+			if err != nil {
+				run.Log.Error(err, fields.Middleware(inputMiddleware))
+				continue
+			}
+			_, err = stdinIntercept.Write(data)
 			run.Log.PossibleError(err)
-			waitGroup.Wait()
-			run.Log.PossibleError(stdinIntercept.Close())
-		}()
+		}
+		waitGroup.Wait()
+		run.Log.PossibleError(stdinIntercept.Close())
+	}()
+}
+
+// processInput replaces the input with the output of another pipe, which receives the previous input
+func (inputMiddleware Middleware) processInput(
+	run *pipeline.Run,
+	reference pipeline.Reference,
+	executionContext *middleware.ExecutionContext,
+) {
+	if executionContext == nil {
+		run.Log.Error(
+			errMissingExecutionContext,
+			fields.Middleware(inputMiddleware),
+		)
+		return
+	}
+	run.Log.Debug(
+		fields.Symbol("↘️"),
+		fields.Message("pipe"),
+		fields.Middleware(inputMiddleware),
+	)
+
+	run.Log.Trace(
+		fields.DataStream(inputMiddleware, "intercepting stdin")...,
+	)
+	stdinIntercept := run.Stdin.Intercept()
+	run.Log.Trace(
+		fields.DataStream(inputMiddleware, "creating stderr writer")...,
+	)
+	stderrAppender := run.Stderr.WriteCloser()
+	parentLogWriter := run.Log.AddWriteCloserEntry()
+
+	var childIdentifier *string
+	childArguments := make(stringmap.StringMap, 10)
+	for pipeIdentifier, pipeArguments := range reference {
+		childIdentifier = pipeIdentifier
+		childArguments = pipeArguments
+		break
 	}
+	executionContext.FullRun(
+		middleware.WithIdentifier(childIdentifier),
+		middleware.WithParentRun(run),
+		middleware.WithLogWriter(parentLogWriter),
+		middleware.WithArguments(childArguments),
+		middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+			childRun.Log.Trace(
+				fields.DataStream(inputMiddleware, "merging parent's previous stdin into child stdin")...,
+			)
+			childRun.Stdin.MergeWith(stdinIntercept)
+		}),
+		middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+			childRun.Log.Trace(
+				fields.DataStream(inputMiddleware, "merging child stdout into parent's new stdin")...,
+			)
+			childRun.Stdout.StartCopyingInto(stdinIntercept)
+			childRun.Log.Trace(
+				fields.DataStream(inputMiddleware, "merging child stderr into parent stderr")...,
+			)
+			childRun.Stderr.StartCopyingInto(stderrAppender)
+			executionContext.AddConnection(run, childRun, "input")
+			go func() {
+				childRun.Wait()
+				// need to clean up by closing the writers we created
+				childRun.Log.PossibleError(stdinIntercept.Close())
+				childRun.Log.PossibleError(stderrAppender.Close())
+			}()
+		}))
 }
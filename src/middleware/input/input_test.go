@@ -1,9 +1,15 @@
 package _input
 
 import (
+	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -27,3 +33,255 @@ func TestInput_Apply(t *testing.T) {
 	require.Contains(t, run.Log.String(), "↘️ input | overwritten input")
 	require.Equal(t, "overwritten input", run.Stdin.String())
 }
+
+func TestInput_File(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-input-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "data.txt"), []byte("file content\n"), 0644))
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"input": map[string]interface{}{
+			"file": "data.txt",
+		},
+	}, nil, nil)
+	run.SetWorkingDirectory(directory)
+	run.Stdin.Replace(strings.NewReader("previous input"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "file content\n", run.Stdin.String())
+}
+
+func TestInput_Glob(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-input-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "b.txt"), []byte("second"), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "a.txt"), []byte("first"), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "c.log"), []byte("ignored"), 0644))
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"input": map[string]interface{}{
+			"glob":      "*.txt",
+			"separator": "\n---\n",
+		},
+	}, nil, nil)
+	run.SetWorkingDirectory(directory)
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "first\n---\nsecond", run.Stdin.String())
+}
+
+func TestInput_Template(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"input": map[string]interface{}{
+			"template": "Hello {{.name}}, {{range .items}}[{{.}}]{{end}}",
+		},
+	}, nil, nil)
+	run.Stdin.Replace(strings.NewReader("previous input"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+	require.Equal(t, "previous input", run.Stdin.String())
+	require.Contains(t, run.Log.String(), "failed to render template")
+
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	run, err := pipeline.NewRun(nil, map[string]interface{}{
+		"name":  "World",
+		"items": []interface{}{"a", "b"},
+		"input": map[string]interface{}{
+			"template": "Hello {{.name}}, {{range .items}}[{{.}}]{{end}}",
+		},
+	}, nil, parentRun)
+	require.Nil(t, err)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {}, nil)
+	run.Start()
+	run.Wait()
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "Hello World, [a][b]", run.Stdin.String())
+}
+
+func TestInput_TemplateFromEnv(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.Setenv("PIPEDREAM_INPUT_TEST", "from env")
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"input": map[string]interface{}{
+			"template": "{{.PIPEDREAM_INPUT_TEST}}",
+			"from":     "env",
+		},
+	}, nil, nil)
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+	require.Contains(t, run.Log.String(), "failed to render template")
+
+	run, _ = pipeline.NewRun(nil, map[string]interface{}{
+		"input": map[string]interface{}{
+			"template": "{{.PIPEDREAM_INPUT_TEST}}",
+			"from":     "env",
+		},
+	}, nil, parentRun)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {}, nil)
+	run.Start()
+	run.Wait()
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "from env", run.Stdin.String())
+}
+
+func TestInput_CombinedSources(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-input-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "data.txt"), []byte("file\n"), 0644))
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"input": map[string]interface{}{
+			"text": "text\n",
+			"file": "data.txt",
+		},
+	}, nil, nil)
+	run.SetWorkingDirectory(directory)
+	run.Stdin.Replace(strings.NewReader("previous input"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "text\nfile\n", run.Stdin.String())
+}
+
+func TestInput_Errors(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-input-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+
+	for name, testCase := range map[string]struct {
+		arguments map[string]interface{}
+		error     string
+		stdin     string
+	}{
+		"missing file": {
+			arguments: map[string]interface{}{
+				"file": "missing.txt",
+			},
+			error: "failed to read input file",
+			stdin: "",
+		},
+		"no matching files": {
+			arguments: map[string]interface{}{
+				"glob": "*.txt",
+			},
+			error: "no files match the pattern",
+			stdin: "",
+		},
+		"invalid glob pattern": {
+			arguments: map[string]interface{}{
+				"glob": "[",
+			},
+			error: "invalid glob pattern",
+			stdin: "previous input",
+		},
+		"invalid template": {
+			arguments: map[string]interface{}{
+				"template": "{{.name",
+			},
+			error: "failed to parse template",
+			stdin: "previous input",
+		},
+		"invalid template data source": {
+			arguments: map[string]interface{}{
+				"template": "text",
+				"from":     "somewhere",
+			},
+			error: "invalid template data source",
+			stdin: "previous input",
+		},
+		"pipe without execution context": {
+			arguments: map[string]interface{}{
+				"pipe": "some-pipe",
+			},
+			error: "cannot use `pipe` without an execution context",
+			stdin: "previous input",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run, _ := pipeline.NewRun(nil, map[string]interface{}{
+				"input": testCase.arguments,
+			}, nil, nil)
+			run.SetWorkingDirectory(directory)
+			run.Stdin.Replace(strings.NewReader("previous input"))
+			NewMiddleware().Apply(
+				run,
+				func(run *pipeline.Run) {},
+				nil,
+			)
+			run.Start()
+			run.Wait()
+
+			require.Equal(t, 1, run.Log.ErrorCount())
+			require.Contains(t, run.Log.String(), testCase.error)
+			require.Equal(t, testCase.stdin, run.Stdin.String())
+		})
+	}
+}
+
+func TestInput_Pipe(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"input": map[string]interface{}{
+			"text": "sources",
+			"pipe": "input-provider",
+		},
+	}, nil, nil)
+
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(1)
+	run.Log.SetLevel(logrus.DebugLevel)
+	run.Stdin.Replace(strings.NewReader("previous input"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(run *pipeline.Run) {
+				go func() {
+					run.Wait()
+					require.Equal(t, "sources", run.Stdin.String())
+					waitGroup.Done()
+				}()
+				require.Equal(t, "input-provider", *run.Identifier)
+				run.Stdout.Replace(strings.NewReader("provided input"))
+			})),
+	)
+	run.Start()
+	run.Wait()
+	waitGroup.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Contains(t, run.Log.String(), "↘️ input | pipe")
+	require.Equal(t, "provided input", run.Stdin.String())
+}
@@ -0,0 +1,131 @@
+package _input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+var errMissingExecutionContext = errors.New("cannot use `pipe` without an execution context")
+
+// inputSource provides data that replaces a pipe's input
+type inputSource struct {
+	description string
+	read        func() ([]byte, error)
+}
+
+// inputSources collects the sources specified in the arguments
+//
+// If several sources are specified, their data is concatenated in the order
+// `text`, `file`, `glob`, `template`.
+func inputSources(arguments middlewareArguments, run *pipeline.Run) ([]inputSource, error) {
+	sources := make([]inputSource, 0, 4)
+	if arguments.Text != nil {
+		text := *arguments.Text
+		sources = append(sources, inputSource{
+			description: text,
+			read: func() ([]byte, error) {
+				return []byte(text), nil
+			},
+		})
+	}
+	if arguments.File != nil {
+		path := run.ResolvePath(*arguments.File)
+		sources = append(sources, inputSource{
+			description: *arguments.File,
+			read: func() ([]byte, error) {
+				data, err := ioutil.ReadFile(path)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read input file: %w", err)
+				}
+				return data, nil
+			},
+		})
+	}
+	if arguments.Glob != nil {
+		pattern := *arguments.Glob
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+		resolvedPattern := run.ResolvePath(pattern)
+		separator := arguments.Separator
+		sources = append(sources, inputSource{
+			description: pattern,
+			read: func() ([]byte, error) {
+				return readMatchingFiles(resolvedPattern, separator)
+			},
+		})
+	}
+	if arguments.Template != nil {
+		data, err := renderTemplate(*arguments.Template, arguments.From, run)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, inputSource{
+			description: "template",
+			read: func() ([]byte, error) {
+				return data, nil
+			},
+		})
+	}
+	return sources, nil
+}
+
+// readMatchingFiles concatenates the contents of all files matching the pattern in alphabetical order
+func readMatchingFiles(pattern string, separator string) ([]byte, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match the pattern %q", pattern)
+	}
+	sort.Strings(matches)
+	buffer := new(bytes.Buffer)
+	for index, match := range matches {
+		data, err := ioutil.ReadFile(match)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input file: %w", err)
+		}
+		if index > 0 {
+			buffer.WriteString(separator)
+		}
+		buffer.Write(data)
+	}
+	return buffer.Bytes(), nil
+}
+
+// renderTemplate executes a Go template with the run's arguments or environment variables as data
+func renderTemplate(text string, from string, run *pipeline.Run) ([]byte, error) {
+	var data interface{}
+	switch from {
+	case "args":
+		data = run.ArgumentsCopy()
+	case "env":
+		environment := make(map[string]string, 100)
+		for _, keyValuePair := range run.Environ() {
+			components := strings.SplitN(keyValuePair, "=", 2)
+			if len(components) == 2 {
+				environment[components[0]] = components[1]
+			}
+		}
+		data = environment
+	default:
+		return nil, fmt.Errorf("invalid template data source %q, expected one of `args`, `env`", from)
+	}
+	parsedTemplate, err := template.New("input").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	buffer := new(bytes.Buffer)
+	if err = parsedTemplate.Execute(buffer, data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
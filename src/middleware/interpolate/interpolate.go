@@ -32,6 +32,13 @@ func (interpolateMiddleware Middleware) String() string {
 	return "interpolate"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (interpolateMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"interpolate": {&interpolateMiddlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
@@ -123,7 +130,15 @@ func (interpolateMiddleware Middleware) Apply(
 				var previousRunResults [][]byte
 				if len(arguments.Pipes) > 0 {
 					for _, runIdentifier := range arguments.Pipes {
-						runToWaitFor := executionContext.WaitForRun(runIdentifier)
+						runToWaitFor, err := executionContext.WaitForRun(runIdentifier, run)
+						if err != nil {
+							run.Log.Error(err, fields.Middleware(interpolateMiddleware))
+							waitGroup.Wait()
+							run.Log.PossibleError(stdoutAppender.Close())
+							run.Log.PossibleError(stderrAppender.Close())
+							run.Log.PossibleError(parentLogWriter.Close())
+							return
+						}
 						previousRunResults = append(previousRunResults, runToWaitFor.Stdout.Bytes())
 						executionContext.AddConnection(runToWaitFor, run, "interpolate")
 					}
@@ -389,8 +404,18 @@ func (interpolator *interpolator) interpolateArguments(value string) (string, er
 				stringReplacement = handleQuotes(stringReplacement, interpolator.MiddlewareArguments)
 				interpolator.Substitutions[key] = stringReplacement
 				value = strings.Replace(value, match[0], stringReplacement, 1)
+			case float64:
+				stringReplacement := strconv.FormatFloat(typedReplacement, 'f', -1, 64)
+				stringReplacement = handleQuotes(stringReplacement, interpolator.MiddlewareArguments)
+				interpolator.Substitutions[key] = stringReplacement
+				value = strings.Replace(value, match[0], stringReplacement, 1)
+			case bool:
+				stringReplacement := strconv.FormatBool(typedReplacement)
+				stringReplacement = handleQuotes(stringReplacement, interpolator.MiddlewareArguments)
+				interpolator.Substitutions[key] = stringReplacement
+				value = strings.Replace(value, match[0], stringReplacement, 1)
 			default:
-				return value, fmt.Errorf("value for argument `%v` is not a string, int or array of strings", key)
+				return value, fmt.Errorf("value for argument `%v` is not a string, number, boolean or array of strings", key)
 			}
 		}
 	}
@@ -123,6 +123,34 @@ func TestInterpolate_SingleSubstitution(t *testing.T) {
 	require.Contains(t, logString, "made 1 substitution")
 }
 
+func TestInterpolate_NumberAndBooleanSubstitution(t *testing.T) {
+	identifier := "child identifier"
+	run, _ := pipeline.NewRun(&identifier, map[string]interface{}{
+		"interpolate": map[string]interface{}{
+			"quote": "none",
+		},
+		"float":   1.25,
+		"boolean": true,
+		"arg":     "@{float} @{boolean}",
+	}, nil, nil)
+
+	runArguments := make(map[string]interface{}, 0)
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+				runArguments = childRun.ArgumentsCopy()
+			}),
+		))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, 0, run.Log.WarnCount())
+	require.Equal(t, "1.25 true", runArguments["arg"])
+}
+
 func TestInterpolate_InputAndArgumentSubstitution(t *testing.T) {
 	identifier := "child identifier with @{arg} (not interpolated)"
 	run, _ := pipeline.NewRun(&identifier, map[string]interface{}{
@@ -38,3 +38,9 @@ func outputErrors(errors *multierror.Error, writer io.Writer) {
 		_, _ = fmt.Fprintln(writer, aurora.Red(strings.Join(errorMessages, "\n")))
 	}
 }
+
+func outputKilledRuns(runs []*pipeline.Run, writer io.Writer) {
+	for _, run := range runs {
+		_, _ = fmt.Fprintln(writer, aurora.Yellow(fmt.Sprintf("%v was forcibly killed, as it did not terminate in time", run.DisplayString())))
+	}
+}
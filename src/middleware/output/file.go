@@ -0,0 +1,145 @@
+package _output
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// fileSink describes a file that a data stream is written to
+type fileSink struct {
+	Append bool
+	Atomic bool
+	File   string
+	Mode   string
+	Tee    bool
+}
+
+// permissions returns the file mode to apply, if specified
+func (sink fileSink) permissions() (*os.FileMode, error) {
+	if sink.Mode == "" {
+		return nil, nil
+	}
+	mode, err := strconv.ParseUint(sink.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return nil, fmt.Errorf("invalid file mode %q, expected octal permissions like `0644`", sink.Mode)
+	}
+	fileMode := os.FileMode(mode)
+	return &fileMode, nil
+}
+
+// open creates the file (including its parent directories) and returns a writer for it
+//
+// Atomic writes go to a temporary file in the same directory, which replaces the target when the writer is closed.
+func (sink fileSink) open(path string) (fileWriter, error) {
+	mode, err := sink.permissions()
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if sink.Atomic {
+		return openAtomicFile(path, mode, sink.Append)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if sink.Append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	createMode := os.FileMode(0644)
+	if mode != nil {
+		createMode = *mode
+	}
+	file, err := os.OpenFile(path, flags, createMode)
+	if err != nil {
+		return nil, err
+	}
+	if mode != nil {
+		// the mode passed to `OpenFile` only applies to new files
+		if err = file.Chmod(*mode); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	return &plainFile{File: file}, nil
+}
+
+// fileWriter is a writer that either completes (Close) or discards (Abort) the write
+type fileWriter interface {
+	io.WriteCloser
+	Abort() error
+}
+
+type plainFile struct {
+	*os.File
+}
+
+func (file *plainFile) Abort() error {
+	return file.File.Close()
+}
+
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+func openAtomicFile(path string, mode *os.FileMode, appendToExisting bool) (*atomicFile, error) {
+	targetMode := os.FileMode(0644)
+	existingFile, err := os.Open(path)
+	if err == nil {
+		if info, err := existingFile.Stat(); err == nil {
+			targetMode = info.Mode().Perm()
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if mode != nil {
+		targetMode = *mode
+	}
+	temporaryFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		if existingFile != nil {
+			_ = existingFile.Close()
+		}
+		return nil, err
+	}
+	file := &atomicFile{File: temporaryFile, path: path}
+	if existingFile != nil {
+		defer func() {
+			_ = existingFile.Close()
+		}()
+		if appendToExisting {
+			if _, err = io.Copy(temporaryFile, existingFile); err != nil {
+				_ = file.Abort()
+				return nil, err
+			}
+		}
+	}
+	if err = temporaryFile.Chmod(targetMode); err != nil {
+		_ = file.Abort()
+		return nil, err
+	}
+	return file, nil
+}
+
+// Close replaces the target with the temporary file
+func (file *atomicFile) Close() error {
+	if err := file.File.Sync(); err != nil {
+		_ = file.Abort()
+		return err
+	}
+	if err := file.File.Close(); err != nil {
+		_ = os.Remove(file.File.Name())
+		return err
+	}
+	return os.Rename(file.File.Name(), file.path)
+}
+
+// Abort removes the temporary file, leaving the target untouched
+func (file *atomicFile) Abort() error {
+	_ = file.File.Close()
+	return os.Remove(file.File.Name())
+}
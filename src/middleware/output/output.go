@@ -2,11 +2,15 @@
 package _output
 
 import (
+	"errors"
 	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
+	"github.com/Layer9Berlin/pipedream/src/datastream"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io"
 	"io/ioutil"
+	"path/filepath"
 	"sync"
 )
 
@@ -19,19 +23,33 @@ func (outputMiddleware Middleware) String() string {
 	return "output"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (outputMiddleware Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"output": {&arguments},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
 }
 
 type middlewareArguments struct {
+	File    *string
 	Process pipeline.Reference
+	Stderr  *fileSink
+	Stdout  *fileSink
 	Text    *string
 }
 
 func newMiddlewareArguments() middlewareArguments {
 	return middlewareArguments{
+		File:    nil,
 		Process: nil,
+		Stderr:  nil,
+		Stdout:  nil,
 		Text:    nil,
 	}
 }
@@ -48,6 +66,17 @@ func (outputMiddleware Middleware) Apply(
 ) {
 	arguments := newMiddlewareArguments()
 	pipeline.ParseArguments(&arguments, "output", run)
+	if arguments.File != nil {
+		if arguments.Stdout != nil {
+			run.Log.Error(
+				errors.New("`file` is a shorthand for `stdout.file` and cannot be combined with `stdout`"),
+				fields.Middleware(outputMiddleware),
+			)
+			next(run)
+			return
+		}
+		arguments.Stdout = &fileSink{File: *arguments.File}
+	}
 
 	next(run)
 
@@ -128,4 +157,70 @@ func (outputMiddleware Middleware) Apply(
 				}()
 			}))
 	}
+
+	// the file sinks are set up last, so that they receive the processed output
+	dryRun := executionContext != nil && executionContext.DryRun
+	if arguments.Stdout != nil && arguments.Stdout.File != "" {
+		outputMiddleware.writeToFile(run, run.Stdout, "stdout", dryRun, *arguments.Stdout)
+	}
+	if arguments.Stderr != nil && arguments.Stderr.File != "" {
+		outputMiddleware.writeToFile(run, run.Stderr, "stderr", dryRun, *arguments.Stderr)
+	}
+}
+
+// writeToFile redirects a data stream into a file, optionally passing it through as well
+//
+// During a dry run, the file is not written and the stream is passed through unchanged.
+func (outputMiddleware Middleware) writeToFile(
+	run *pipeline.Run,
+	stream *datastream.ComposableDataStream,
+	streamName string,
+	dryRun bool,
+	sink fileSink,
+) {
+	path := run.ResolvePath(sink.File)
+	if dryRun {
+		run.Log.Debug(
+			fields.Symbol("💾"),
+			fields.Message(filepath.Base(path)),
+			fields.Info(streamName+", skipped in dry run"),
+			fields.Middleware(outputMiddleware),
+		)
+		return
+	}
+	run.Log.Debug(
+		fields.Symbol("💾"),
+		fields.Message(filepath.Base(path)),
+		fields.Info(streamName),
+		fields.Middleware(outputMiddleware),
+	)
+	run.Log.Trace(
+		fields.DataStream(outputMiddleware, "intercepting "+streamName)...,
+	)
+	intercept := stream.Intercept()
+	go func() {
+		file, err := sink.open(path)
+		if err != nil {
+			run.Log.Error(err, fields.Middleware(outputMiddleware))
+		}
+		var destination io.Writer = ioutil.Discard
+		switch {
+		case err == nil && sink.Tee:
+			destination = io.MultiWriter(file, intercept)
+		case err == nil:
+			destination = file
+		case sink.Tee:
+			destination = intercept
+		}
+		_, copyErr := io.Copy(destination, intercept)
+		if err == nil {
+			if copyErr != nil {
+				run.Log.PossibleError(file.Abort())
+			} else {
+				run.Log.PossibleError(file.Close())
+			}
+		}
+		run.Log.PossibleError(copyErr)
+		run.Log.PossibleError(intercept.Close())
+	}()
 }
@@ -5,6 +5,9 @@ import (
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -64,3 +67,246 @@ func TestOutput_Process_stringReference(t *testing.T) {
 	require.Contains(t, run.Log.String(), "⍈ output | process")
 	require.Equal(t, "processor output", run.Stdout.String())
 }
+
+func TestOutput_File(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-output-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"output": map[string]interface{}{
+			"file": "nested/dir/result.txt",
+			"text": "replaced",
+		},
+	}, nil, nil)
+	run.SetWorkingDirectory(directory)
+	run.Stdout.Replace(strings.NewReader("original"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "", run.Stdout.String())
+	content, err := ioutil.ReadFile(filepath.Join(directory, "nested/dir/result.txt"))
+	require.Nil(t, err)
+	require.Equal(t, "replaced", string(content))
+}
+
+func TestOutput_FileAppendWithModeAndTee(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-output-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	filePath := filepath.Join(directory, "log.txt")
+	require.Nil(t, ioutil.WriteFile(filePath, []byte("first\n"), 0644))
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"output": map[string]interface{}{
+			"stdout": map[string]interface{}{
+				"file":   "log.txt",
+				"append": true,
+				"mode":   "0600",
+				"tee":    true,
+			},
+		},
+	}, nil, nil)
+	run.SetWorkingDirectory(directory)
+	run.Stdout.Replace(strings.NewReader("second\n"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "second\n", run.Stdout.String())
+	content, err := ioutil.ReadFile(filePath)
+	require.Nil(t, err)
+	require.Equal(t, "first\nsecond\n", string(content))
+	info, err := os.Stat(filePath)
+	require.Nil(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestOutput_FileAtomic(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-output-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	filePath := filepath.Join(directory, "snapshot.lock")
+	require.Nil(t, ioutil.WriteFile(filePath, []byte("old\n"), 0640))
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"output": map[string]interface{}{
+			"stdout": map[string]interface{}{
+				"file":   "snapshot.lock",
+				"atomic": true,
+				"append": true,
+			},
+		},
+	}, nil, nil)
+	run.SetWorkingDirectory(directory)
+	run.Stdout.Replace(strings.NewReader("new\n"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	content, err := ioutil.ReadFile(filePath)
+	require.Nil(t, err)
+	require.Equal(t, "old\nnew\n", string(content))
+	info, err := os.Stat(filePath)
+	require.Nil(t, err)
+	// the permissions of the replaced file are retained
+	require.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	entries, err := ioutil.ReadDir(directory)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(entries))
+}
+
+func TestOutput_StderrFile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-output-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"output": map[string]interface{}{
+			"stderr": map[string]interface{}{
+				"file": "errors.log",
+			},
+		},
+	}, nil, nil)
+	run.SetWorkingDirectory(directory)
+	run.Stdout.Replace(strings.NewReader("output"))
+	run.Stderr.Replace(strings.NewReader("errors"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "output", run.Stdout.String())
+	require.Equal(t, "", run.Stderr.String())
+	content, err := ioutil.ReadFile(filepath.Join(directory, "errors.log"))
+	require.Nil(t, err)
+	require.Equal(t, "errors", string(content))
+}
+
+func TestOutput_FileErrors(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-output-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(directory, "not-a-directory"), []byte{}, 0644))
+
+	for name, testCase := range map[string]struct {
+		arguments map[string]interface{}
+		error     string
+	}{
+		"invalid mode": {
+			arguments: map[string]interface{}{
+				"stdout": map[string]interface{}{
+					"file": "result.txt",
+					"mode": "rw-r--r--",
+					"tee":  true,
+				},
+			},
+			error: "invalid file mode \"rw-r--r--\", expected octal permissions like `0644`",
+		},
+		"invalid directory": {
+			arguments: map[string]interface{}{
+				"stdout": map[string]interface{}{
+					"file": "not-a-directory/result.txt",
+					"tee":  true,
+				},
+			},
+			error: "not a directory",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run, _ := pipeline.NewRun(nil, map[string]interface{}{
+				"output": testCase.arguments,
+			}, nil, nil)
+			run.SetWorkingDirectory(directory)
+			run.Stdout.Replace(strings.NewReader("output"))
+			NewMiddleware().Apply(
+				run,
+				func(run *pipeline.Run) {},
+				nil,
+			)
+			run.Start()
+			run.Wait()
+
+			require.Equal(t, 1, run.Log.ErrorCount())
+			require.Contains(t, run.Log.LastError().Error(), testCase.error)
+			// with `tee`, the output is still passed through
+			require.Equal(t, "output", run.Stdout.String())
+		})
+	}
+}
+
+func TestOutput_FileAndStdout(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"output": map[string]interface{}{
+			"file": "result.txt",
+			"stdout": map[string]interface{}{
+				"file": "other.txt",
+			},
+		},
+	}, nil, nil)
+	run.Stdout.Replace(strings.NewReader("output"))
+	nextCalled := false
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+			nextCalled = true
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.True(t, nextCalled)
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Equal(t, "`file` is a shorthand for `stdout.file` and cannot be combined with `stdout`", run.Log.LastError().Error())
+	require.Equal(t, "output", run.Stdout.String())
+}
+
+func TestOutput_FileDryRun(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-output-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.SetWorkingDirectory(directory)
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"output": map[string]interface{}{
+			"file": "result.txt",
+		},
+	}, nil, parentRun)
+	run.Log.SetLevel(logrus.DebugLevel)
+	run.Stdout.Replace(strings.NewReader("output"))
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		middleware.NewExecutionContext(middleware.WithDryRun(true)),
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "output", run.Stdout.String())
+	require.Contains(t, run.Log.String(), "skipped in dry run")
+	_, err = os.Stat(filepath.Join(directory, "result.txt"))
+	require.True(t, os.IsNotExist(err))
+}
@@ -45,3 +45,11 @@ func TestRun_Output_outputErrors(t *testing.T) {
 	require.Contains(t, buffer.String(), "test error 1")
 	require.Contains(t, buffer.String(), "test error 2")
 }
+
+func TestRun_Output_outputKilledRuns(t *testing.T) {
+	identifier := "test"
+	run, _ := pipeline.NewRun(&identifier, nil, nil, nil)
+	buffer := new(bytes.Buffer)
+	outputKilledRuns([]*pipeline.Run{run}, buffer)
+	require.Contains(t, buffer.String(), "Test was forcibly killed, as it did not terminate in time")
+}
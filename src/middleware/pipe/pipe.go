@@ -16,6 +16,13 @@ func (pipeMiddleware Middleware) String() string {
 	return "pipe"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (pipeMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"pipe": {&[]pipeline.Reference{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
@@ -0,0 +1,161 @@
+package query
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	customstrings "github.com/Layer9Berlin/pipedream/src/custom/strings"
+	"github.com/ghodss/yaml"
+	"sort"
+)
+
+func parser(format string) (func([]byte) (interface{}, error), error) {
+	switch format {
+	case "yaml":
+		return func(input []byte) (interface{}, error) {
+			var value interface{}
+			err := yaml.Unmarshal(input, &value)
+			if err != nil {
+				return nil, fmt.Errorf("input is not valid yaml: %w", err)
+			}
+			return value, nil
+		}, nil
+	case "json":
+		return func(input []byte) (interface{}, error) {
+			var value interface{}
+			if len(bytes.TrimSpace(input)) == 0 {
+				return nil, nil
+			}
+			err := json.Unmarshal(input, &value)
+			if err != nil {
+				return nil, fmt.Errorf("input is not valid json: %w", err)
+			}
+			return value, nil
+		}, nil
+	case "csv":
+		return parseCsv, nil
+	case "lines":
+		return parseLines, nil
+	default:
+		return nil, fmt.Errorf("invalid input format %q, expected one of `yaml`, `json`, `csv`, `lines`", format)
+	}
+}
+
+// parseCsv converts csv data into a list of maps, using the first record as the keys
+func parseCsv(input []byte) (interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(input)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("input is not valid csv: %w", err)
+	}
+	items := make([]interface{}, 0, len(records))
+	if len(records) == 0 {
+		return items, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		item := make(map[string]interface{}, len(header))
+		for index, key := range header {
+			item[key] = record[index]
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func parseLines(input []byte) (interface{}, error) {
+	lines := customstrings.NonEmptyLines(string(input))
+	items := make([]interface{}, 0, len(lines))
+	for _, line := range lines {
+		items = append(items, line)
+	}
+	return items, nil
+}
+
+func formatter(format string) (func(interface{}) ([]byte, error), error) {
+	switch format {
+	case "yaml":
+		return yaml.Marshal, nil
+	case "json":
+		return func(value interface{}) ([]byte, error) {
+			result, err := json.MarshalIndent(value, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			return append(result, '\n'), nil
+		}, nil
+	case "csv":
+		return formatCsv, nil
+	case "lines":
+		return formatLines, nil
+	default:
+		return nil, fmt.Errorf("invalid output format %q, expected one of `yaml`, `json`, `csv`, `lines`", format)
+	}
+}
+
+// formatCsv converts a list of maps into csv data, with the sorted keys of all maps as header
+//
+// Lists of other values result in a single column without header, a single map in a single record.
+func formatCsv(value interface{}) ([]byte, error) {
+	items, isList := value.([]interface{})
+	if !isList {
+		items = []interface{}{value}
+	}
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	keys := csvKeys(items)
+	if len(keys) > 0 {
+		if err := writer.Write(keys); err != nil {
+			return nil, err
+		}
+	}
+	for _, item := range items {
+		itemAsMap, itemIsMap := item.(map[string]interface{})
+		if len(keys) > 0 && !itemIsMap {
+			return nil, fmt.Errorf("cannot mix maps and values of type `%T` in csv output", item)
+		}
+		record := []string{customstrings.FromValue(item)}
+		if itemIsMap {
+			record = make([]string, 0, len(keys))
+			for _, key := range keys {
+				record = append(record, customstrings.FromValue(itemAsMap[key]))
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+func csvKeys(items []interface{}) []string {
+	keySet := make(map[string]bool, 10)
+	for _, item := range items {
+		if itemAsMap, itemIsMap := item.(map[string]interface{}); itemIsMap {
+			for key := range itemAsMap {
+				keySet[key] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatLines outputs each item of a list on a separate line
+func formatLines(value interface{}) ([]byte, error) {
+	items, isList := value.([]interface{})
+	if !isList {
+		items = []interface{}{value}
+	}
+	buffer := new(bytes.Buffer)
+	for _, item := range items {
+		buffer.WriteString(customstrings.FromValue(item))
+		buffer.WriteString("\n")
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFormats_ParseCsv(t *testing.T) {
+	value, err := parseCsv([]byte("key,value\na,1\nb,2\n"))
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"key": "a", "value": "1"},
+		map[string]interface{}{"key": "b", "value": "2"},
+	}, value)
+
+	value, err = parseCsv([]byte(""))
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{}, value)
+
+	_, err = parseCsv([]byte("key,value\na\n"))
+	require.NotNil(t, err)
+}
+
+func TestFormats_FormatCsv(t *testing.T) {
+	result, err := formatCsv([]interface{}{
+		map[string]interface{}{"b": 2.0, "a": "with, comma"},
+		map[string]interface{}{"c": []interface{}{"x"}},
+	})
+	require.Nil(t, err)
+	require.Equal(t, "a,b,c\n\"with, comma\",2,\n,,\"[\"\"x\"\"]\"\n", string(result))
+
+	result, err = formatCsv([]interface{}{"first", 2.5})
+	require.Nil(t, err)
+	require.Equal(t, "first\n2.5\n", string(result))
+
+	result, err = formatCsv(map[string]interface{}{"key": "value"})
+	require.Nil(t, err)
+	require.Equal(t, "key\nvalue\n", string(result))
+
+	_, err = formatCsv([]interface{}{map[string]interface{}{"key": "value"}, "value"})
+	require.NotNil(t, err)
+}
+
+func TestFormats_FormatLines(t *testing.T) {
+	result, err := formatLines([]interface{}{"text", 1000000.0, nil, map[string]interface{}{"key": true}})
+	require.Nil(t, err)
+	require.Equal(t, "text\n1000000\n\n{\"key\":true}\n", string(result))
+
+	result, err = formatLines("single")
+	require.Nil(t, err)
+	require.Equal(t, "single\n", string(result))
+}
+
+func TestFormats_ParseJsonEmpty(t *testing.T) {
+	parse, err := parser("json")
+	require.Nil(t, err)
+	value, err := parse([]byte("  \n"))
+	require.Nil(t, err)
+	require.Nil(t, value)
+}
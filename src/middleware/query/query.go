@@ -0,0 +1,307 @@
+// Package query provides a middleware that selects, filters and reshapes structured output
+package query
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/custom/evaluate"
+	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
+	"github.com/Layer9Berlin/pipedream/src/logging/fields"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Middleware is a structured data processor
+type Middleware struct {
+}
+
+// String is a human-readable description
+func (Middleware) String() string {
+	return "query"
+}
+
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"query": {&arguments},
+	}
+}
+
+// NewMiddleware creates a new Middleware instance
+func NewMiddleware() Middleware {
+	return Middleware{}
+}
+
+type middlewareArguments struct {
+	From   string
+	Rename map[string]string
+	Select string
+	To     string
+	Where  string
+}
+
+func newMiddlewareArguments() middlewareArguments {
+	return middlewareArguments{
+		From:   "yaml",
+		Rename: nil,
+		Select: "",
+		To:     "",
+		Where:  "",
+	}
+}
+
+// Apply is where the middleware's logic resides
+//
+// It adapts the run based on its slice of the run's arguments.
+// It may also trigger side effects such as executing shell commands or full runs of other pipelines.
+// When done, this function should call next in order to continue unwinding the stack.
+func (queryMiddleware Middleware) Apply(
+	run *pipeline.Run,
+	next func(*pipeline.Run),
+	_ *middleware.ExecutionContext,
+) {
+	arguments := newMiddlewareArguments()
+	if !pipeline.ParseArguments(&arguments, "query", run) {
+		next(run)
+		return
+	}
+	if arguments.To == "" {
+		arguments.To = arguments.From
+	}
+
+	query, err := newQuery(arguments, run)
+	if err != nil {
+		run.Log.Error(err, fields.Middleware(queryMiddleware))
+		next(run)
+		return
+	}
+
+	next(run)
+
+	run.Log.Debug(
+		fields.Symbol("🔎"),
+		fields.Message(fmt.Sprintf("%v → %v", arguments.From, arguments.To)),
+		fields.Info(arguments.Select),
+		fields.Middleware(queryMiddleware),
+	)
+	run.Log.Trace(
+		fields.DataStream(queryMiddleware, "intercepting stdout")...,
+	)
+	stdoutIntercept := run.Stdout.Intercept()
+	go func() {
+		input, err := ioutil.ReadAll(stdoutIntercept)
+		run.Log.PossibleError(err)
+		result, err := query.execute(input)
+		if err != nil {
+			run.Log.Error(err, fields.Middleware(queryMiddleware))
+		} else {
+			_, err = stdoutIntercept.Write(result)
+			run.Log.PossibleError(err)
+		}
+		run.Log.PossibleError(stdoutIntercept.Close())
+	}()
+}
+
+type query struct {
+	parse  func([]byte) (interface{}, error)
+	format func(interface{}) ([]byte, error)
+	path   []string
+	filter *evaluate.Expression
+	rename map[string]string
+}
+
+func newQuery(arguments middlewareArguments, run *pipeline.Run) (*query, error) {
+	parse, err := parser(arguments.From)
+	if err != nil {
+		return nil, err
+	}
+	format, err := formatter(arguments.To)
+	if err != nil {
+		return nil, err
+	}
+	var filter *evaluate.Expression = nil
+	if arguments.Where != "" {
+		filter, err = evaluate.Parse(arguments.Where, run.ResolvePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &query{
+		parse:  parse,
+		format: format,
+		path:   splitPath(arguments.Select),
+		filter: filter,
+		rename: arguments.Rename,
+	}, nil
+}
+
+func (query *query) execute(input []byte) ([]byte, error) {
+	value, err := query.parse(input)
+	if err != nil {
+		return nil, err
+	}
+	value, err = selectValue(value, query.path)
+	if err != nil {
+		return nil, err
+	}
+	if query.filter != nil {
+		value, err = filterItems(value, query.filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(query.rename) > 0 {
+		value, err = renameKeys(value, query.rename)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return query.format(value)
+}
+
+// splitPath splits a selector like `.dependencies.*.name` into its components
+//
+// The empty selector and `.` select the complete document.
+func splitPath(selector string) []string {
+	trimmedSelector := strings.TrimPrefix(strings.TrimSpace(selector), ".")
+	if trimmedSelector == "" {
+		return []string{}
+	}
+	return strings.Split(trimmedSelector, ".")
+}
+
+// selectValue returns the value at the specified path
+//
+// A `*` component selects all values of a list or map, in which case the result is a list.
+// Numeric components select items of a list by their (zero-based) index.
+func selectValue(value interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	component, restOfPath := path[0], path[1:]
+	if component == "*" {
+		return selectAll(value, restOfPath)
+	}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		nestedValue, err := stringmap.GetValueInMap(typedValue, component)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select `%v`: %w", component, err)
+		}
+		return selectValue(nestedValue, restOfPath)
+	case []interface{}:
+		index, err := strconv.Atoi(component)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select `%v`: expected a list index", component)
+		}
+		if index < 0 || index >= len(typedValue) {
+			return nil, fmt.Errorf("failed to select `%v`: index out of range, list has %v items", component, len(typedValue))
+		}
+		return selectValue(typedValue[index], restOfPath)
+	default:
+		return nil, fmt.Errorf("failed to select `%v`: value is neither a map nor a list, but of type `%T`", component, value)
+	}
+}
+
+func selectAll(value interface{}, restOfPath []string) (interface{}, error) {
+	values := make([]interface{}, 0, 10)
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedValue))
+		for key := range typedValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			values = append(values, typedValue[key])
+		}
+	case []interface{}:
+		values = append(values, typedValue...)
+	default:
+		return nil, fmt.Errorf("failed to select `*`: value is neither a map nor a list, but of type `%T`", value)
+	}
+	result := make([]interface{}, 0, len(values))
+	for _, item := range values {
+		selectedValue, err := selectValue(item, restOfPath)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, selectedValue)
+	}
+	return result, nil
+}
+
+// filterItems keeps the items of a list for which the condition is satisfied
+//
+// The item is available in the condition as `item`, the values of map items additionally by key.
+func filterItems(value interface{}, filter *evaluate.Expression) (interface{}, error) {
+	items, isList := value.([]interface{})
+	if !isList {
+		return nil, fmt.Errorf("`where` requires a list, but the selected value is of type `%T`", value)
+	}
+	result := make([]interface{}, 0, len(items))
+	for index, item := range items {
+		parameters := make(map[string]interface{}, 10)
+		if itemAsMap, itemIsMap := item.(map[string]interface{}); itemIsMap {
+			evaluate.AddParameters(parameters, itemAsMap)
+		}
+		parameters["item"] = item
+		satisfied, err := filter.Bool(parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate `where` condition for item %v: %w", index, err)
+		}
+		if satisfied {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// renameKeys moves values from one (possibly nested) key to another
+//
+// If the value is a list, the keys of each of its map items are renamed. Missing keys are ignored.
+func renameKeys(value interface{}, rename map[string]string) (interface{}, error) {
+	switch typedValue := value.(type) {
+	case []interface{}:
+		for _, item := range typedValue {
+			if _, err := renameKeys(item, rename); err != nil {
+				return nil, err
+			}
+		}
+		return typedValue, nil
+	case map[string]interface{}:
+		// apply renames in a deterministic order
+		oldKeys := make([]string, 0, len(rename))
+		for oldKey := range rename {
+			oldKeys = append(oldKeys, oldKey)
+		}
+		sort.Strings(oldKeys)
+		for _, oldKey := range oldKeys {
+			oldPath := splitPath(oldKey)
+			newPath := splitPath(rename[oldKey])
+			if len(oldPath) == 0 || len(newPath) == 0 {
+				return nil, fmt.Errorf("invalid rename from `%v` to `%v`", oldKey, rename[oldKey])
+			}
+			if !stringmap.HaveValueInMap(typedValue, oldPath...) {
+				continue
+			}
+			renamedValue, err := stringmap.GetValueInMap(typedValue, oldPath...)
+			if err != nil {
+				return nil, err
+			}
+			if err = stringmap.RemoveValueInMap(typedValue, oldPath...); err != nil {
+				return nil, err
+			}
+			if err = stringmap.SetValueInMap(typedValue, renamedValue, newPath...); err != nil {
+				return nil, fmt.Errorf("failed to rename `%v` to `%v`: %w", oldKey, rename[oldKey], err)
+			}
+		}
+		return typedValue, nil
+	default:
+		return nil, fmt.Errorf("`rename` requires a map or a list of maps, but the selected value is of type `%T`", value)
+	}
+}
@@ -0,0 +1,209 @@
+package query
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func runQuery(t *testing.T, arguments map[string]interface{}, output string) *pipeline.Run {
+	run, err := pipeline.NewRun(nil, map[string]interface{}{
+		"query": arguments,
+	}, nil, nil)
+	require.Nil(t, err)
+	run.Log.SetLevel(logrus.DebugLevel)
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			pipelineRun.Stdout.Replace(strings.NewReader(output))
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+	return run
+}
+
+const dependencies = `dependencies:
+  - name: logrus
+    version: 1.7.0
+    direct: true
+  - name: yaml
+    version: 1.8.0
+    direct: false
+  - name: testify
+    version: 1.6.1
+    direct: true
+`
+
+func TestQuery_Select(t *testing.T) {
+	run := runQuery(t, map[string]interface{}{
+		"select": ".dependencies.*.name",
+	}, dependencies)
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "- logrus\n- yaml\n- testify\n", run.Stdout.String())
+	require.Contains(t, run.Log.String(), "yaml → yaml")
+}
+
+func TestQuery_SelectIndex(t *testing.T) {
+	run := runQuery(t, map[string]interface{}{
+		"select": "dependencies.1.version",
+		"to":     "lines",
+	}, dependencies)
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "1.8.0\n", run.Stdout.String())
+}
+
+func TestQuery_WhereAndRename(t *testing.T) {
+	run := runQuery(t, map[string]interface{}{
+		"select": "dependencies",
+		"where":  "direct && name != 'testify'",
+		"rename": map[string]interface{}{
+			"name": "module",
+		},
+		"to": "json",
+	}, dependencies)
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, `[
+  {
+    "direct": true,
+    "module": "logrus",
+    "version": "1.7.0"
+  }
+]
+`, run.Stdout.String())
+}
+
+func TestQuery_RenameNested(t *testing.T) {
+	run := runQuery(t, map[string]interface{}{
+		"from": "json",
+		"rename": map[string]interface{}{
+			"meta.id": "id",
+			"missing": "ignored",
+		},
+	}, `{"meta": {"id": 1, "kind": "test"}}`)
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, `{
+  "id": 1,
+  "meta": {
+    "kind": "test"
+  }
+}
+`, run.Stdout.String())
+}
+
+func TestQuery_CsvToYaml(t *testing.T) {
+	run := runQuery(t, map[string]interface{}{
+		"from":  "csv",
+		"to":    "yaml",
+		"where": "contains(name, 'a')",
+	}, "name,age\nalice,31\nbob,42\ncarol,27\n")
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "- age: \"31\"\n  name: alice\n- age: \"27\"\n  name: carol\n", run.Stdout.String())
+}
+
+func TestQuery_YamlToCsv(t *testing.T) {
+	run := runQuery(t, map[string]interface{}{
+		"select": "dependencies",
+		"to":     "csv",
+	}, dependencies)
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "direct,name,version\ntrue,logrus,1.7.0\nfalse,yaml,1.8.0\ntrue,testify,1.6.1\n", run.Stdout.String())
+}
+
+func TestQuery_Lines(t *testing.T) {
+	run := runQuery(t, map[string]interface{}{
+		"from":  "lines",
+		"where": "matches(item, '^v2')",
+	}, "v1.0.0\nv2.0.0\n\nv2.1.0\n")
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "v2.0.0\nv2.1.0\n", run.Stdout.String())
+}
+
+func TestQuery_InvalidArguments(t *testing.T) {
+	for name, arguments := range map[string]map[string]interface{}{
+		"invalid input format": {
+			"from": "xml",
+		},
+		"invalid output format": {
+			"to": "toml",
+		},
+		"invalid condition": {
+			"where": "(",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run := runQuery(t, arguments, "unchanged")
+
+			require.Equal(t, 1, run.Log.ErrorCount())
+			require.Equal(t, "unchanged", run.Stdout.String())
+		})
+	}
+}
+
+func TestQuery_ExecutionErrors(t *testing.T) {
+	for name, testCase := range map[string]struct {
+		arguments map[string]interface{}
+		error     string
+	}{
+		"invalid input": {
+			arguments: map[string]interface{}{
+				"from": "json",
+			},
+			error: "input is not valid json",
+		},
+		"missing key": {
+			arguments: map[string]interface{}{
+				"select": "dependencies.0.license",
+			},
+			error: "failed to select `license`",
+		},
+		"index out of range": {
+			arguments: map[string]interface{}{
+				"select": "dependencies.3",
+			},
+			error: "index out of range, list has 3 items",
+		},
+		"where on map": {
+			arguments: map[string]interface{}{
+				"where": "true",
+			},
+			error: "`where` requires a list",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			run := runQuery(t, testCase.arguments, dependencies)
+
+			require.Equal(t, 1, run.Log.ErrorCount())
+			require.Contains(t, run.Log.LastError().Error(), testCase.error)
+			require.Equal(t, "", run.Stdout.String())
+		})
+	}
+}
+
+func TestQuery_Inactive(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, nil, nil, nil)
+
+	nextCalled := false
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			nextCalled = true
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.True(t, nextCalled)
+	require.Equal(t, 0, run.Log.ErrorCount())
+}
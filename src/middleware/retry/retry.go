@@ -0,0 +1,306 @@
+// Package retry provides a middleware that re-runs a pipe if it fails
+package retry
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/logging/fields"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io/ioutil"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Middleware is a retrier for flaky pipes
+type Middleware struct {
+	after func(duration time.Duration) <-chan time.Time
+}
+
+type middlewareArguments struct {
+	Attempts int
+	Backoff  string
+	Delay    string
+	On       string
+	Pattern  string
+}
+
+func newMiddlewareArguments() middlewareArguments {
+	return middlewareArguments{
+		Attempts: 3,
+		Backoff:  "constant",
+		Delay:    "0s",
+		On:       "exitCode",
+		Pattern:  "",
+	}
+}
+
+// String is a human-readable description
+func (Middleware) String() string {
+	return "retry"
+}
+
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"retry": {&arguments},
+	}
+}
+
+// NewMiddleware creates a new Middleware instance
+func NewMiddleware() Middleware {
+	return NewMiddlewareWithTimerFunction(time.After)
+}
+
+// NewMiddlewareWithTimerFunction creates a new Middleware instance with the specified function to wait between attempts
+//
+// The function should behave like time.After, returning a channel that receives once the duration has elapsed.
+func NewMiddlewareWithTimerFunction(after func(duration time.Duration) <-chan time.Time) Middleware {
+	return Middleware{
+		after: after,
+	}
+}
+
+// Apply is where the middleware's logic resides
+//
+// It adapts the run based on its slice of the run's arguments.
+// It may also trigger side effects such as executing shell commands or full runs of other pipelines.
+// When done, this function should call next in order to continue unwinding the stack.
+func (retryMiddleware Middleware) Apply(
+	run *pipeline.Run,
+	next func(*pipeline.Run),
+	executionContext *middleware.ExecutionContext,
+) {
+	arguments := newMiddlewareArguments()
+	// a single attempt does not need any special treatment
+	if !pipeline.ParseArguments(&arguments, "retry", run) || arguments.Attempts == 1 {
+		next(run)
+		return
+	}
+
+	delay, failureCheck, err := parseMiddlewareArguments(arguments)
+	if err != nil {
+		run.Log.Error(err, fields.Middleware(retryMiddleware))
+		return
+	}
+
+	run.Log.Debug(
+		fields.Symbol("🔁"),
+		fields.Message("retry"),
+		fields.Info(fmt.Sprintf("up to %v attempts on %v", arguments.Attempts, arguments.On)),
+		fields.Middleware(retryMiddleware),
+	)
+
+	// the attempts will be executed as child runs,
+	// so this run is merely a container that passes the data through
+	run.Log.Trace(
+		fields.DataStream(retryMiddleware, "copying stdin")...,
+	)
+	stdinCopy := run.Stdin.Copy()
+	run.Log.Trace(
+		fields.DataStream(retryMiddleware, "creating stdout writer")...,
+	)
+	stdoutAppender := run.Stdout.WriteCloser()
+	run.Log.Trace(
+		fields.DataStream(retryMiddleware, "creating stderr writer")...,
+	)
+	stderrAppender := run.Stderr.WriteCloser()
+
+	// each attempt will execute the same pipe with the same arguments,
+	// but we need to prevent the attempt from retrying itself
+	// (note that the definition's arguments will be merged in again, so we cannot simply remove the key)
+	attemptArguments := run.ArgumentsCopy()
+	attemptArguments["retry"] = map[string]interface{}{
+		"attempts": 1,
+	}
+
+	run.DontCompleteBefore(func() {
+		// buffer the entire input, so that we can pass a fresh copy to each attempt
+		stdin, err := ioutil.ReadAll(stdinCopy)
+		run.Log.PossibleError(err)
+
+		var previousAttempt *pipeline.Run = nil
+		var stdout, stderr []byte
+		for attempt := 1; attempt <= arguments.Attempts; attempt++ {
+			if previousAttempt != nil {
+				attemptDelay := backoffDelay(delay, arguments.Backoff, attempt-1)
+				run.Log.Warn(
+					fields.Symbol("🔁"),
+					fields.Message(fmt.Sprintf("attempt %v of %v failed", attempt-1, arguments.Attempts)),
+					fields.Info(fmt.Sprintf("retrying in %v", attemptDelay)),
+					fields.Middleware(retryMiddleware),
+				)
+				// stop waiting if the run is cancelled in the meantime
+				select {
+				case <-retryMiddleware.after(attemptDelay):
+				case <-run.CancellationSignal():
+				}
+			}
+			if cancelled(run) {
+				break
+			}
+
+			var attemptRun *pipeline.Run
+			stdout, stderr, attemptRun = retryMiddleware.executeAttempt(
+				run,
+				attemptArguments,
+				stdin,
+				previousAttempt,
+				attempt,
+				attempt < arguments.Attempts,
+				executionContext,
+			)
+			run.ExitCode = attemptRun.ExitCode
+			previousAttempt = attemptRun
+
+			if !failureCheck(attemptRun, stdout, stderr) {
+				run.Log.Debug(
+					fields.Symbol("🔁"),
+					fields.Message(fmt.Sprintf("attempt %v of %v succeeded", attempt, arguments.Attempts)),
+					fields.Middleware(retryMiddleware),
+				)
+				break
+			}
+			if attempt == arguments.Attempts {
+				run.Log.Warn(
+					fields.Symbol("🔁"),
+					fields.Message(fmt.Sprintf("attempt %v of %v failed", attempt, arguments.Attempts)),
+					fields.Info("giving up"),
+					fields.Middleware(retryMiddleware),
+				)
+			}
+		}
+
+		// only the last attempt's output is passed on
+		_, err = stdoutAppender.Write(stdout)
+		run.Log.PossibleError(err)
+		_, err = stderrAppender.Write(stderr)
+		run.Log.PossibleError(err)
+		// need to clean up by closing the writers we created
+		run.Log.PossibleError(stdoutAppender.Close())
+		run.Log.PossibleError(stderrAppender.Close())
+	})
+}
+
+func (retryMiddleware Middleware) executeAttempt(
+	run *pipeline.Run,
+	arguments map[string]interface{},
+	stdin []byte,
+	previousAttempt *pipeline.Run,
+	attempt int,
+	retriable bool,
+	executionContext *middleware.ExecutionContext,
+) ([]byte, []byte, *pipeline.Run) {
+	var stdout, stderr []byte
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(2)
+	attemptRun := executionContext.FullRun(
+		middleware.WithParentRun(run),
+		middleware.WithIdentifier(run.Identifier),
+		middleware.WithArguments(arguments),
+		middleware.WithSetupFunc(func(attemptRun *pipeline.Run) {
+			attemptRun.Log.Trace(
+				fields.DataStream(retryMiddleware, "merging buffered parent stdin into child stdin")...,
+			)
+			attemptRun.Stdin.MergeWith(bytes.NewReader(stdin))
+			if retriable {
+				// a failure will be handled by the next attempt
+				attemptRun.Log.SetErrorsHandled(true)
+			}
+			if previousAttempt == nil {
+				executionContext.AddConnection(run, attemptRun, "attempt 1")
+			} else {
+				executionContext.AddConnection(previousAttempt, attemptRun, fmt.Sprintf("attempt %v", attempt))
+			}
+		}),
+		middleware.WithTearDownFunc(func(attemptRun *pipeline.Run) {
+			attemptRun.Log.Trace(
+				fields.DataStream(retryMiddleware, "copying child stdout")...,
+			)
+			stdoutCopy := attemptRun.Stdout.Copy()
+			attemptRun.Log.Trace(
+				fields.DataStream(retryMiddleware, "copying child stderr")...,
+			)
+			stderrCopy := attemptRun.Stderr.Copy()
+			go func() {
+				defer waitGroup.Done()
+				var err error
+				stdout, err = ioutil.ReadAll(stdoutCopy)
+				run.Log.PossibleError(err)
+			}()
+			go func() {
+				defer waitGroup.Done()
+				var err error
+				stderr, err = ioutil.ReadAll(stderrCopy)
+				run.Log.PossibleError(err)
+			}()
+		}))
+	waitGroup.Wait()
+	attemptRun.Wait()
+	return stdout, stderr, attemptRun
+}
+
+// cancelled indicates whether the run has been cancelled
+//
+// Unlike run.Cancelled, this does not wait for the cancellation to complete,
+// which would require the output of the attempts to be passed on first.
+func cancelled(run *pipeline.Run) bool {
+	select {
+	case <-run.CancellationSignal():
+		return true
+	default:
+		return false
+	}
+}
+
+func parseMiddlewareArguments(arguments middlewareArguments) (time.Duration, func(*pipeline.Run, []byte, []byte) bool, error) {
+	if arguments.Attempts < 1 {
+		return 0, nil, fmt.Errorf("invalid number of attempts %v, need at least one", arguments.Attempts)
+	}
+	delay, err := time.ParseDuration(arguments.Delay)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid retry delay %q: %w", arguments.Delay, err)
+	}
+	switch arguments.Backoff {
+	case "constant", "linear", "exponential":
+	default:
+		return 0, nil, fmt.Errorf("unknown backoff %q, expected `constant`, `linear` or `exponential`", arguments.Backoff)
+	}
+	switch arguments.On {
+	case "exitCode":
+		return delay, func(attemptRun *pipeline.Run, _ []byte, _ []byte) bool {
+			return attemptRun.ExitCode != nil && *attemptRun.ExitCode != 0
+		}, nil
+	case "stderr":
+		return delay, func(_ *pipeline.Run, _ []byte, stderr []byte) bool {
+			return len(stderr) > 0
+		}, nil
+	case "pattern":
+		if arguments.Pattern == "" {
+			return 0, nil, fmt.Errorf("retry on `pattern` requires a non-empty `pattern` argument")
+		}
+		pattern, err := regexp.Compile(arguments.Pattern)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid retry pattern %q: %w", arguments.Pattern, err)
+		}
+		return delay, func(_ *pipeline.Run, stdout []byte, stderr []byte) bool {
+			return pattern.Match(stdout) || pattern.Match(stderr)
+		}, nil
+	default:
+		return 0, nil, fmt.Errorf("unknown retry condition %q, expected `exitCode`, `stderr` or `pattern`", arguments.On)
+	}
+}
+
+// backoffDelay returns the delay before the next attempt, given the number of previously failed attempts
+func backoffDelay(delay time.Duration, backoff string, failedAttempts int) time.Duration {
+	switch backoff {
+	case "linear":
+		return delay * time.Duration(failedAttempts)
+	case "exponential":
+		return delay * time.Duration(1<<(failedAttempts-1))
+	default:
+		return delay
+	}
+}
@@ -0,0 +1,231 @@
+package retry
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetry_SucceedsAfterFailedAttempts(t *testing.T) {
+	identifier := "flaky"
+	run, _ := pipeline.NewRun(&identifier, map[string]interface{}{
+		"retry": map[string]interface{}{
+			"attempts": 3,
+			"delay":    "2s",
+			"backoff":  "exponential",
+		},
+		"key": "value",
+	}, nil, nil)
+	run.Log.SetLevel(logrus.DebugLevel)
+	run.Stdin.MergeWith(strings.NewReader("input"))
+
+	delays := make([]time.Duration, 0, 2)
+	inputs := make([]string, 0, 3)
+	attempts := 0
+	executionContext := middleware.NewExecutionContext(
+		middleware.WithExecutionFunction(func(attemptRun *pipeline.Run) {
+			attempts++
+			require.Equal(t, "flaky", *attemptRun.Identifier)
+			require.Equal(t, "value", attemptRun.ArgumentsCopy()["key"])
+			require.Equal(t, map[string]interface{}{"attempts": 1}, attemptRun.ArgumentsCopy()["retry"])
+			exitCode := 1
+			if attempts == 3 {
+				exitCode = 0
+			}
+			attemptRun.ExitCode = &exitCode
+			stdinCopy := attemptRun.Stdin.Copy()
+			attemptRun.DontCompleteBefore(func() {
+				input, err := ioutil.ReadAll(stdinCopy)
+				require.Nil(t, err)
+				inputs = append(inputs, string(input))
+			})
+			attemptRun.Stdout.Replace(strings.NewReader("output"))
+		}))
+	NewMiddlewareWithTimerFunction(func(duration time.Duration) <-chan time.Time {
+		delays = append(delays, duration)
+		return time.After(0)
+	}).Apply(run, func(run *pipeline.Run) {
+		t.Fatal("next should not be called for the retried run")
+	}, executionContext)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 3, attempts)
+	require.Equal(t, []string{"input", "input", "input"}, inputs)
+	require.Equal(t, []time.Duration{2 * time.Second, 4 * time.Second}, delays)
+	require.Equal(t, 0, *run.ExitCode)
+	require.Equal(t, "output", run.Stdout.String())
+	require.Equal(t, 0, run.Log.ErrorCount())
+	logs := run.Log.String()
+	require.Contains(t, logs, "attempt 1 of 3 failed")
+	require.Contains(t, logs, "attempt 3 of 3 succeeded")
+	connections := executionContext.Connections()
+	require.Equal(t, 3, len(connections))
+	require.Equal(t, run, connections[0].Source)
+	require.Equal(t, "attempt 2", *connections[1].Label)
+}
+
+func TestRetry_GivesUpAfterLastAttempt(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"retry": map[string]interface{}{
+			"attempts": 2,
+			"on":       "stderr",
+		},
+	}, nil, nil)
+	run.Log.SetLevel(logrus.DebugLevel)
+
+	attempts := 0
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(attemptRun *pipeline.Run) {
+				attempts++
+				attemptRun.Stderr.Replace(strings.NewReader("test error"))
+			})))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 2, attempts)
+	require.Equal(t, "test error", run.Stderr.String())
+	require.Contains(t, run.Log.String(), "giving up")
+}
+
+func TestRetry_OnPattern(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"retry": map[string]interface{}{
+			"on":      "pattern",
+			"pattern": "time(d )?out",
+		},
+	}, nil, nil)
+
+	attempts := 0
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(attemptRun *pipeline.Run) {
+				attempts++
+				if attempts == 1 {
+					attemptRun.Stdout.Replace(strings.NewReader("connection timed out"))
+				} else {
+					attemptRun.Stdout.Replace(strings.NewReader("1.2.3"))
+				}
+			})))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 2, attempts)
+	require.Equal(t, "1.2.3", run.Stdout.String())
+}
+
+func TestRetry_LogsHandledErrorsAsWarnings(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"retry": map[string]interface{}{
+			"attempts": 2,
+		},
+	}, nil, nil)
+
+	attemptRuns := make([]*pipeline.Run, 0, 2)
+	executionContext := middleware.NewExecutionContext(
+		middleware.WithExecutionFunction(func(attemptRun *pipeline.Run) {
+			attemptRuns = append(attemptRuns, attemptRun)
+			exitCode := 1
+			attemptRun.ExitCode = &exitCode
+			attemptRun.Log.Error(fmt.Errorf("attempt %v failed", len(attemptRuns)))
+		}))
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {}, executionContext)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 2, len(attemptRuns))
+	require.Equal(t, 0, attemptRuns[0].Log.ErrorCount())
+	require.Equal(t, 1, attemptRuns[0].Log.WarnCount())
+	require.Equal(t, 1, attemptRuns[1].Log.ErrorCount())
+	require.Equal(t, 1, len(executionContext.Errors()))
+	require.Contains(t, executionContext.Errors()[0].Error(), "attempt 2 failed")
+}
+
+func TestRetry_CancelledWhileWaiting(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"retry": map[string]interface{}{
+			"delay": "1h",
+		},
+	}, nil, nil)
+
+	attempts := 0
+	NewMiddlewareWithTimerFunction(func(duration time.Duration) <-chan time.Time {
+		go func() {
+			require.Nil(t, run.Cancel())
+		}()
+		// never elapses
+		return make(chan time.Time)
+	}).Apply(
+		run,
+		func(run *pipeline.Run) {},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(attemptRun *pipeline.Run) {
+				attempts++
+				exitCode := 1
+				attemptRun.ExitCode = &exitCode
+			})))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, attempts)
+}
+
+func TestRetry_WithoutArguments(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{}, nil, nil)
+	nextCalled := false
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {
+		nextCalled = true
+	}, nil)
+	require.True(t, nextCalled)
+}
+
+func TestRetry_SingleAttempt(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"retry": map[string]interface{}{
+			"attempts": 1,
+		},
+	}, nil, nil)
+	nextCalled := false
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {
+		nextCalled = true
+	}, nil)
+	require.True(t, nextCalled)
+}
+
+func TestRetry_InvalidArguments(t *testing.T) {
+	for _, arguments := range []map[string]interface{}{
+		{"attempts": 0},
+		{"delay": "soon"},
+		{"backoff": "random"},
+		{"on": "unknown"},
+		{"on": "pattern"},
+		{"on": "pattern", "pattern": "("},
+	} {
+		run, _ := pipeline.NewRun(nil, map[string]interface{}{
+			"retry": arguments,
+		}, nil, nil)
+		NewMiddleware().Apply(run, func(run *pipeline.Run) {
+			t.Fatal("next should not be called")
+		}, nil)
+		run.Start()
+		run.Wait()
+		require.Equal(t, 1, run.Log.ErrorCount())
+	}
+}
+
+func TestRetry_backoffDelay(t *testing.T) {
+	require.Equal(t, time.Second, backoffDelay(time.Second, "constant", 3))
+	require.Equal(t, 3*time.Second, backoffDelay(time.Second, "linear", 3))
+	require.Equal(t, 4*time.Second, backoffDelay(time.Second, "exponential", 3))
+}
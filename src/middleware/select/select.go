@@ -23,6 +23,13 @@ func (selectMiddleware Middleware) String() string {
 	return "select"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (selectMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"select": {&middlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return NewMiddlewareWithStdinAndStdout(os.Stdin, os.Stdout)
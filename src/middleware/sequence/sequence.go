@@ -16,6 +16,13 @@ func (sequenceMiddleware Middleware) String() string {
 	return "sequence"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (sequenceMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"sequence": {&middlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
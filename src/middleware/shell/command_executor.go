@@ -6,11 +6,16 @@ import (
 	"os"
 	"os/exec"
 	"sync"
+	"time"
 )
 
 type commandExecutor interface {
 	Init(name string, arg ...string)
+	SetOwnProcessGroup(ownProcessGroup bool)
+	SetEnvironment(environment []string)
+	SetWorkingDirectory(directory string)
 	Kill() error
+	Terminate(gracePeriod time.Duration) (bool, error)
 	Clear()
 	CmdStdin() io.WriteCloser
 	CmdStdout() io.Reader
@@ -22,7 +27,9 @@ type commandExecutor interface {
 
 type defaultCommandExecutor struct {
 	command *exec.Cmd
+	dir     string
 	env     []string
+	exited  chan struct{}
 	stopped bool
 	mutex   *sync.RWMutex
 }
@@ -39,7 +46,44 @@ func (executor *defaultCommandExecutor) Init(name string, arg ...string) {
 	executor.mutex.Lock()
 	defer executor.mutex.Unlock()
 	executor.command = exec.Command(name, arg...)
+	executor.command.Dir = executor.dir
 	executor.command.Env = executor.env
+	executor.exited = make(chan struct{})
+}
+
+// SetOwnProcessGroup determines whether the command will be started in a new process group
+//
+// Signals will then be sent to all processes in the group, including any child processes spawned by the command.
+// Note that processes outside the terminal's foreground process group cannot read from the terminal.
+func (executor *defaultCommandExecutor) SetOwnProcessGroup(ownProcessGroup bool) {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+	if executor.command == nil {
+		return
+	}
+	setOwnProcessGroup(executor.command, ownProcessGroup)
+}
+
+// SetEnvironment determines the environment variables (in the form "key=value") the command will be started with
+func (executor *defaultCommandExecutor) SetEnvironment(environment []string) {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+	executor.env = environment
+	if executor.command == nil {
+		return
+	}
+	executor.command.Env = environment
+}
+
+// SetWorkingDirectory determines the directory in which the command will be executed
+func (executor *defaultCommandExecutor) SetWorkingDirectory(directory string) {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+	executor.dir = directory
+	if executor.command == nil {
+		return
+	}
+	executor.command.Dir = directory
 }
 
 func (executor *defaultCommandExecutor) Start() error {
@@ -82,12 +126,16 @@ func (executor *defaultCommandExecutor) CmdStderr() io.Reader {
 }
 
 func (executor *defaultCommandExecutor) Wait() error {
-	executor.mutex.Lock()
-	defer executor.mutex.Unlock()
-	if executor.command == nil {
+	executor.mutex.RLock()
+	command := executor.command
+	exited := executor.exited
+	executor.mutex.RUnlock()
+	if command == nil {
 		return fmt.Errorf("cannot wait for cleared command")
 	}
-	return executor.command.Wait()
+	// don't hold the lock while waiting, so that the command can be terminated in the meantime
+	defer close(exited)
+	return command.Wait()
 }
 
 func (executor *defaultCommandExecutor) Kill() error {
@@ -96,11 +144,44 @@ func (executor *defaultCommandExecutor) Kill() error {
 	if executor.command == nil || executor.command.Process == nil {
 		return nil
 	}
-	result := executor.command.Process.Kill()
+	result := killProcessGroup(executor.command)
 	executor.command = nil
 	return result
 }
 
+// Terminate asks the command to exit gracefully, killing it if it has not exited after the grace period
+//
+// The returned bool indicates whether the command had to be killed.
+func (executor *defaultCommandExecutor) Terminate(gracePeriod time.Duration) (bool, error) {
+	executor.mutex.RLock()
+	command := executor.command
+	exited := executor.exited
+	started := command != nil && command.Process != nil
+	executor.mutex.RUnlock()
+	if !started {
+		return false, nil
+	}
+	if !gracefulTerminationSupported {
+		return true, executor.Kill()
+	}
+	err := terminateProcessGroup(command)
+	if err != nil {
+		select {
+		case <-exited:
+			// the command has exited in the meantime
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+	select {
+	case <-exited:
+		return false, nil
+	case <-time.After(gracePeriod):
+		return true, executor.Kill()
+	}
+}
+
 func (executor *defaultCommandExecutor) Clear() {
 	executor.mutex.Lock()
 	defer executor.mutex.Unlock()
@@ -2,7 +2,9 @@ package shell
 
 import (
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
 	"testing"
+	"time"
 )
 
 func Test_StartClearedCommandExecutor(t *testing.T) {
@@ -49,3 +51,53 @@ func Test_KillCompletedCommand(t *testing.T) {
 	require.NotNil(t, commandExecutor.Kill())
 	require.Nil(t, commandExecutor.command)
 }
+
+func Test_TerminateCommand(t *testing.T) {
+	commandExecutor := newDefaultCommandExecutor()
+	commandExecutor.Init("sh", "-c", "sleep 10 & sleep 10; wait")
+	commandExecutor.SetOwnProcessGroup(true)
+	require.Nil(t, commandExecutor.Start())
+	go func() {
+		_ = commandExecutor.Wait()
+	}()
+	start := time.Now()
+	killed, err := commandExecutor.Terminate(5 * time.Second)
+	require.Nil(t, err)
+	require.False(t, killed)
+	require.Less(t, int64(time.Since(start)), int64(5*time.Second))
+}
+
+func Test_TerminateCommandIgnoringSignal(t *testing.T) {
+	commandExecutor := newDefaultCommandExecutor()
+	commandExecutor.Init("sh", "-c", "trap '' TERM; sleep 10")
+	commandExecutor.SetOwnProcessGroup(true)
+	require.Nil(t, commandExecutor.Start())
+	go func() {
+		_ = commandExecutor.Wait()
+	}()
+	// give the shell time to set up the trap
+	time.Sleep(100 * time.Millisecond)
+	killed, err := commandExecutor.Terminate(100 * time.Millisecond)
+	require.Nil(t, err)
+	require.True(t, killed)
+	require.Nil(t, commandExecutor.command)
+}
+
+func Test_TerminateClearedCommandExecutor(t *testing.T) {
+	commandExecutor := newDefaultCommandExecutor()
+	killed, err := commandExecutor.Terminate(time.Second)
+	require.Nil(t, err)
+	require.False(t, killed)
+}
+
+func Test_SetEnvironment(t *testing.T) {
+	commandExecutor := newDefaultCommandExecutor()
+	commandExecutor.Init("sh", "-c", "printf \"$PIPEDREAM_TEST\"")
+	commandExecutor.SetEnvironment([]string{"PIPEDREAM_TEST=value"})
+	stdout := commandExecutor.CmdStdout()
+	require.Nil(t, commandExecutor.Start())
+	output, err := ioutil.ReadAll(stdout)
+	require.Nil(t, err)
+	require.Nil(t, commandExecutor.Wait())
+	require.Equal(t, "value", string(output))
+}
@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewDryRunMiddleware creates a new middleware instance that records commands instead of executing them
+func NewDryRunMiddleware() Middleware {
+	return NewMiddlewareWithExecutorCreator(func() commandExecutor { return newDryRunCommandExecutor() })
+}
+
+// dryRunCommandExecutor pretends to execute a command successfully, without producing any output
+type dryRunCommandExecutor struct {
+	command []string
+	mutex   *sync.RWMutex
+}
+
+func newDryRunCommandExecutor() *dryRunCommandExecutor {
+	return &dryRunCommandExecutor{
+		mutex: &sync.RWMutex{},
+	}
+}
+
+func (executor *dryRunCommandExecutor) Init(name string, arg ...string) {
+	executor.mutex.Lock()
+	defer executor.mutex.Unlock()
+	executor.command = append([]string{name}, arg...)
+}
+
+func (executor *dryRunCommandExecutor) SetOwnProcessGroup(_ bool) {
+}
+
+func (executor *dryRunCommandExecutor) SetEnvironment(_ []string) {
+}
+
+func (executor *dryRunCommandExecutor) SetWorkingDirectory(_ string) {
+}
+
+func (executor *dryRunCommandExecutor) Kill() error {
+	return nil
+}
+
+func (executor *dryRunCommandExecutor) Terminate(_ time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (executor *dryRunCommandExecutor) Clear() {
+}
+
+func (executor *dryRunCommandExecutor) CmdStdin() io.WriteCloser {
+	return discardingWriteCloser{}
+}
+
+func (executor *dryRunCommandExecutor) CmdStdout() io.Reader {
+	return strings.NewReader("")
+}
+
+func (executor *dryRunCommandExecutor) CmdStderr() io.Reader {
+	return strings.NewReader("")
+}
+
+func (executor *dryRunCommandExecutor) Start() error {
+	return nil
+}
+
+func (executor *dryRunCommandExecutor) Wait() error {
+	return nil
+}
+
+func (executor *dryRunCommandExecutor) String() string {
+	executor.mutex.RLock()
+	defer executor.mutex.RUnlock()
+	components := make([]string, 0, len(executor.command))
+	for _, component := range executor.command {
+		if strings.ContainsAny(component, " \t\n\"'") {
+			component = fmt.Sprintf("%q", component)
+		}
+		components = append(components, component)
+	}
+	return strings.Join(components, " ")
+}
+
+type discardingWriteCloser struct{}
+
+func (discardingWriteCloser) Write(data []byte) (int, error) {
+	return ioutil.Discard.Write(data)
+}
+
+func (discardingWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package shell
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/middleware/docker"
+	"github.com/Layer9Berlin/pipedream/src/middleware/ssh"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestShell_DryRun(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"dir":  "some dir",
+			"run":  "rm -rf build",
+			"args": []interface{}{"--verbose"},
+		},
+	}, nil, nil)
+	run.Log.SetLevel(logrus.DebugLevel)
+	run.Stdin.MergeWith(strings.NewReader("input"))
+
+	NewDryRunMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, 0, *run.ExitCode)
+	require.Equal(t, "", run.Stdout.String())
+	require.Equal(t, "", run.Stderr.String())
+	require.Equal(t, "cd some dir && rm -rf build --verbose", *run.Command())
+	require.Contains(t, run.Log.String(), `>_ shell | sh -c "cd some dir && rm -rf build --verbose"`)
+}
+
+func TestShell_DryRun_Ssh(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"ssh": "user@example.com",
+		"shell": map[string]interface{}{
+			"dir": "/srv",
+			"run": "echo hi",
+		},
+	}, nil, nil)
+
+	NewDryRunMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+			ssh.NewMiddleware().Apply(run, func(run *pipeline.Run) {}, nil)
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	// the directory is changed on the remote host
+	require.Equal(t, `ssh user@example.com "bash -l -c \"cd /srv && echo hi\""`, *run.Command())
+}
+
+func TestShell_DryRun_Docker(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"docker": map[string]interface{}{
+			"service": "app",
+		},
+		"shell": map[string]interface{}{
+			"dir": "/srv",
+			"run": "echo hi",
+		},
+	}, nil, nil)
+
+	NewDryRunMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+			docker.NewMiddleware().Apply(run, func(run *pipeline.Run) {}, nil)
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	// the plan records the command as passed to the shell middleware
+	require.Equal(t, "docker-compose exec -T app cd /srv && echo hi", *run.Command())
+}
+
+func TestDryRunCommandExecutor(t *testing.T) {
+	executor := newDryRunCommandExecutor()
+	executor.Init("sh", "-l", "-c", "echo 'test'")
+	require.Equal(t, `sh -l -c "echo 'test'"`, executor.String())
+	require.Nil(t, executor.Start())
+	written, err := executor.CmdStdin().Write([]byte("ignored"))
+	require.Nil(t, err)
+	require.Equal(t, 7, written)
+	require.Nil(t, executor.Wait())
+	killed, err := executor.Terminate(0)
+	require.False(t, killed)
+	require.Nil(t, err)
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const gracefulTerminationSupported = true
+
+func setOwnProcessGroup(command *exec.Cmd, ownProcessGroup bool) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.Setpgid = ownProcessGroup
+}
+
+// signalProcessGroup sends the signal to all processes in the command's process group, if it has its own,
+// otherwise just to the command's process
+func signalProcessGroup(command *exec.Cmd, signal syscall.Signal) error {
+	if command.SysProcAttr != nil && command.SysProcAttr.Setpgid {
+		// a negative pid addresses the entire process group
+		return syscall.Kill(-command.Process.Pid, signal)
+	}
+	return command.Process.Signal(signal)
+}
+
+func terminateProcessGroup(command *exec.Cmd) error {
+	return signalProcessGroup(command, syscall.SIGTERM)
+}
+
+func killProcessGroup(command *exec.Cmd) error {
+	return signalProcessGroup(command, syscall.SIGKILL)
+}
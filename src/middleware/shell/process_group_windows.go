@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package shell
+
+import (
+	"os/exec"
+)
+
+// process groups are not supported on Windows, so we can only address the command's process directly
+func setOwnProcessGroup(_ *exec.Cmd, _ bool) {
+}
+
+// Windows does not support sending SIGTERM, so we have to kill the process right away
+const gracefulTerminationSupported = false
+
+func terminateProcessGroup(command *exec.Cmd) error {
+	return command.Process.Kill()
+}
+
+func killProcessGroup(command *exec.Cmd) error {
+	return command.Process.Kill()
+}
@@ -4,6 +4,7 @@ package shell
 import (
 	"errors"
 	"fmt"
+	customio "github.com/Layer9Berlin/pipedream/src/custom/io"
 	customstrings "github.com/Layer9Berlin/pipedream/src/custom/strings"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
@@ -14,6 +15,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
@@ -30,6 +32,14 @@ func (shellMiddleware Middleware) String() string {
 	return "shell"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (shellMiddleware Middleware) Arguments() map[string][]interface{} {
+	arguments := newMiddlewareArguments()
+	return map[string][]interface{}{
+		"shell": {&arguments},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return NewMiddlewareWithExecutorCreator(func() commandExecutor { return newDefaultCommandExecutor() })
@@ -51,6 +61,7 @@ type middlewareArguments struct {
 	Exec        string
 	Indefinite  bool
 	Interactive bool
+	KillGrace   string
 	Login       bool
 	Run         *string
 	Quote       string
@@ -62,6 +73,7 @@ func newMiddlewareArguments() middlewareArguments {
 		Exec:        "sh",
 		Indefinite:  false,
 		Interactive: false,
+		KillGrace:   "5s",
 		Login:       false,
 		Quote:       "double",
 	}
@@ -75,7 +87,7 @@ func newMiddlewareArguments() middlewareArguments {
 func (shellMiddleware Middleware) Apply(
 	run *pipeline.Run,
 	next func(pipelineRun *pipeline.Run),
-	_ *middleware.ExecutionContext,
+	executionContext *middleware.ExecutionContext,
 ) {
 	arguments := newMiddlewareArguments()
 	pipeline.ParseArguments(&arguments, "shell", run)
@@ -92,6 +104,15 @@ func (shellMiddleware Middleware) Apply(
 			return
 		}
 
+		killGrace, err := time.ParseDuration(arguments.KillGrace)
+		if err != nil {
+			run.Log.Error(
+				fmt.Errorf("invalid kill grace period %q: %w", arguments.KillGrace, err),
+				fields.Middleware(shellMiddleware),
+			)
+			return
+		}
+
 		if len(shellArgumentsAsArray) > 0 {
 			*arguments.Run = fmt.Sprintf("%v %v", *arguments.Run, strings.Join(shellArgumentsAsArray, " "))
 		}
@@ -107,7 +128,13 @@ func (shellMiddleware Middleware) Apply(
 		// allow other middleware to make changes now that we have set the directory
 		// this is handy for things like the docker and the SSH middleware
 		// since we want to change directories on the remote service instead of locally
+		run.Log.PossibleError(run.SetArgumentAtPath(*arguments.Run, "shell", "run"))
 		next(run)
+		if wrappedCommand, err := run.ArgumentAtPath("shell", "run"); err == nil {
+			if wrappedCommandAsString, isString := wrappedCommand.(string); isString {
+				*arguments.Run = wrappedCommandAsString
+			}
+		}
 
 		executor := shellMiddleware.ExecutorCreator()
 
@@ -118,6 +145,25 @@ func (shellMiddleware Middleware) Apply(
 		commandComponents = append(commandComponents, []string{"-c", *arguments.Run}...)
 
 		executor.Init(arguments.Exec, commandComponents...)
+		run.SetCommand(*arguments.Run)
+		// interactive commands need to remain in the terminal's foreground process group to be able to read from it,
+		// all others get their own process group, so that any processes they spawn can be terminated together
+		executor.SetOwnProcessGroup(!arguments.Interactive)
+
+		// interactive commands are not restricted, as they are waiting for the user anyway
+		var parallelismLimits []*pipeline.Semaphore = nil
+		if !arguments.Interactive {
+			if executionContext != nil {
+				parallelismLimits = executionContext.ParallelismLimits(run)
+			} else {
+				parallelismLimits = run.ParallelismLimits()
+			}
+		}
+		if len(parallelismLimits) > 0 {
+			shellMiddleware.executeWithLimits(run, executor, parallelismLimits)
+			shellMiddleware.addCancelHook(run, executor, killGrace, executionContext)
+			return
+		}
 
 		cmdStdin := executor.CmdStdin()
 		var stdinIntercept io.ReadWriteCloser = nil
@@ -164,7 +210,7 @@ func (shellMiddleware Middleware) Apply(
 			run.Stderr.MergeWith(cmdStderr)
 			run.Stderr.StartCopyingInto(shellMiddleware.osStderr)
 		} else {
-			run.Stdin.StartCopyingInto(cmdStdin)
+			run.Stdin.StartCopyingInto(brokenPipeTolerantWriter{writer: cmdStdin})
 			run.Stdout.MergeWith(executor.CmdStdout())
 			run.Stderr.MergeWith(executor.CmdStderr())
 		}
@@ -175,14 +221,7 @@ func (shellMiddleware Middleware) Apply(
 			fields.Middleware(shellMiddleware),
 		)
 
-		run.AddCancelHook(func() error {
-			run.Log.Warn(
-				fields.Symbol("⎋"),
-				fields.Message("cancelled"),
-				fields.Info(executor.String()),
-			)
-			return executor.Kill()
-		})
+		shellMiddleware.addCancelHook(run, executor, killGrace, executionContext)
 
 		if !run.IndefiniteInput {
 			go func() {
@@ -192,6 +231,9 @@ func (shellMiddleware Middleware) Apply(
 		}
 
 		run.DontCompleteBefore(func() {
+			// determine the environment as late as possible, so that variables saved by previous runs are included
+			executor.SetEnvironment(run.Environ())
+			executor.SetWorkingDirectory(run.WorkingDirectory())
 			run.Log.PossibleError(executor.Start())
 
 			if !run.IndefiniteInput {
@@ -202,22 +244,7 @@ func (shellMiddleware Middleware) Apply(
 			err := executor.Wait()
 			// reset so that later cancellation will not result in error
 			executor.Clear()
-			if err != nil {
-				if exitErr, ok := err.(*exec.ExitError); ok {
-					exitCode := exitErr.ExitCode()
-					run.ExitCode = &exitCode
-					run.Log.Warn(
-						fields.Middleware(shellMiddleware),
-						fields.Message("command exited with non-zero exit code"),
-						fields.Info(fmt.Errorf("command exited with non-zero exit code: %w", exitErr)),
-					)
-				} else {
-					run.Log.Error(err)
-				}
-			} else {
-				exitCode := 0
-				run.ExitCode = &exitCode
-			}
+			shellMiddleware.setExitCode(run, err)
 			if stdinIntercept != nil {
 				err = stdinIntercept.Close()
 				run.Log.PossibleError(err)
@@ -226,6 +253,140 @@ func (shellMiddleware Middleware) Apply(
 	}
 }
 
+// executeWithLimits executes the command once a slot is available in each of the specified semaphores
+//
+// The command's input is buffered without blocking the data flow and, unless the run expects indefinite input,
+// collected completely before a slot is acquired, so that commands holding a slot never wait for other pipes,
+// which might themselves be waiting for a slot. The command's output is streamed as usual.
+func (shellMiddleware Middleware) executeWithLimits(
+	run *pipeline.Run,
+	executor commandExecutor,
+	parallelismLimits []*pipeline.Semaphore,
+) {
+	cmdStdin := brokenPipeTolerantWriter{writer: executor.CmdStdin()}
+	cmdStdout := executor.CmdStdout()
+	cmdStderr := executor.CmdStderr()
+	stdinPipe := customio.NewBufferedPipe()
+	run.Stdin.StartCopyingInto(stdinPipe)
+	go func() {
+		run.Stdin.Wait()
+		run.Log.PossibleError(stdinPipe.Close())
+	}()
+	stdoutReader, stdoutWriter := io.Pipe()
+	run.Stdout.MergeWith(stdoutReader)
+	stderrReader, stderrWriter := io.Pipe()
+	run.Stderr.MergeWith(stderrReader)
+
+	run.Log.Debug(
+		fields.Symbol(">_"),
+		fields.Message(executor.String()),
+		fields.Info(fmt.Sprintf("waiting for %v semaphore(s)", len(parallelismLimits))),
+		fields.Middleware(shellMiddleware),
+	)
+
+	run.DontCompleteBefore(func() {
+		defer func() {
+			_ = stdoutWriter.Close()
+			_ = stderrWriter.Close()
+		}()
+
+		if !run.IndefiniteInput {
+			run.Stdin.Wait()
+		}
+		release, acquired := pipeline.AcquireAll(parallelismLimits, run.CancellationSignal())
+		if !acquired || run.Cancelled() {
+			release()
+			return
+		}
+		executor.SetEnvironment(run.Environ())
+		executor.SetWorkingDirectory(run.WorkingDirectory())
+		err := executor.Start()
+		if err != nil {
+			release()
+			run.Log.Error(err, fields.Middleware(shellMiddleware))
+			return
+		}
+
+		go func() {
+			_, err := io.Copy(cmdStdin, stdinPipe)
+			run.Log.PossibleError(err)
+			run.Log.PossibleError(cmdStdin.Close())
+		}()
+		outputWaitGroup := &sync.WaitGroup{}
+		outputWaitGroup.Add(2)
+		go func() {
+			defer outputWaitGroup.Done()
+			_, err := io.Copy(stdoutWriter, cmdStdout)
+			run.Log.PossibleError(err)
+		}()
+		go func() {
+			defer outputWaitGroup.Done()
+			_, err := io.Copy(stderrWriter, cmdStderr)
+			run.Log.PossibleError(err)
+		}()
+		outputWaitGroup.Wait()
+		err = executor.Wait()
+		release()
+		// reset so that later cancellation will not result in error
+		executor.Clear()
+		shellMiddleware.setExitCode(run, err)
+	})
+}
+
+func (shellMiddleware Middleware) addCancelHook(
+	run *pipeline.Run,
+	executor commandExecutor,
+	killGrace time.Duration,
+	executionContext *middleware.ExecutionContext,
+) {
+	run.AddCancelHook(func() error {
+		run.Log.Warn(
+			fields.Symbol("⎋"),
+			fields.Message("cancelled"),
+			fields.Info(executor.String()),
+		)
+		killed, err := executor.Terminate(killGrace)
+		if killed {
+			run.Log.Warn(
+				fields.Symbol("☠️"),
+				fields.Message("killed"),
+				fields.Info(fmt.Sprintf("command did not terminate within %v", killGrace)),
+				fields.Middleware(shellMiddleware),
+			)
+			if executionContext != nil {
+				executionContext.AddKilledRun(run)
+			}
+		}
+		return err
+	})
+}
+
+func (shellMiddleware Middleware) setExitCode(run *pipeline.Run, err error) {
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode := exitErr.ExitCode()
+			if exitErr.ProcessState != nil {
+				if waitStatus, ok := exitErr.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+					// follow the shell convention for commands terminated by a signal
+					exitCode = 128 + int(waitStatus.Signal())
+				}
+			}
+			run.ExitCode = &exitCode
+			run.Log.Warn(
+				fields.Middleware(shellMiddleware),
+				fields.Message("command exited with non-zero exit code"),
+				fields.Info(fmt.Errorf("command exited with non-zero exit code: %w", exitErr)),
+			)
+		} else if !run.Cancelled() {
+			// if the command has been killed by the cancel hook, that is not an error in itself
+			run.Log.Error(err)
+		}
+	} else {
+		exitCode := 0
+		run.ExitCode = &exitCode
+	}
+}
+
 func shellCommandArguments(pipeArguments middlewareArguments) ([]string, error) {
 	middlewareArguments := make([]string, 0, 10)
 	for _, argumentItem := range pipeArguments.Args {
@@ -268,3 +429,27 @@ func convertMapToStringArray(values map[string]interface{}, quoteType string) []
 	sort.StringSlice.Sort(stringResults)
 	return stringResults
 }
+
+// brokenPipeTolerantWriter discards writes to commands that have exited without reading all of their input
+//
+// Otherwise, the failed write would interrupt the run's input stream for all other readers.
+// Closing the command's input after it has exited is not an error either.
+type brokenPipeTolerantWriter struct {
+	writer io.WriteCloser
+}
+
+func (tolerantWriter brokenPipeTolerantWriter) Write(data []byte) (int, error) {
+	written, err := tolerantWriter.writer.Write(data)
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		return len(data), nil
+	}
+	return written, err
+}
+
+func (tolerantWriter brokenPipeTolerantWriter) Close() error {
+	err := tolerantWriter.writer.Close()
+	if errors.Is(err, os.ErrClosed) {
+		return nil
+	}
+	return err
+}
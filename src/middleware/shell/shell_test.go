@@ -5,14 +5,19 @@ import (
 	"bytes"
 	"fmt"
 	customio "github.com/Layer9Berlin/pipedream/src/custom/io"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestShell_NonRunnable(t *testing.T) {
@@ -228,9 +233,31 @@ func TestShell_NonZeroExitCode(t *testing.T) {
 	require.Equal(t, 0, run.Log.ErrorCount())
 	require.Equal(t, []string{"-l", "-c", "something"}, executor.StartArgs)
 	require.Contains(t, run.Log.String(), "shell")
+	// an exit error without process state does not provide a signal, so it keeps the exit code reported by the error
 	require.Equal(t, -1, *run.ExitCode)
 }
 
+func TestShell_SignalExitCode(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run": "kill -TERM $$",
+		},
+	}, nil, nil)
+
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, 1, run.Log.WarnCount())
+	// commands terminated by a signal follow the shell convention of exiting with 128 + signal number
+	require.Equal(t, 143, *run.ExitCode)
+}
+
 func TestShell_Interactive_userInput(t *testing.T) {
 	run, _ := pipeline.NewRun(nil, map[string]interface{}{
 		"shell": map[string]interface{}{
@@ -554,6 +581,271 @@ func TestShell_Cancel(t *testing.T) {
 	require.Contains(t, run.Log.String(), "shell")
 }
 
+func TestShell_Cancel_IgnoresWaitError(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run": "test",
+		},
+	}, nil, nil)
+
+	executor, shellMiddleware := NewTestShellMiddleware()
+	executor.WaitError = fmt.Errorf("cannot wait for cleared command")
+	executor.WaitGroup.Add(1)
+	shellMiddleware.Apply(
+		run,
+		func(run *pipeline.Run) {},
+		nil,
+	)
+	run.Start()
+	require.Nil(t, run.Cancel())
+	executor.WaitGroup.Done()
+	run.Wait()
+	// give the execution function a chance to handle the wait error
+	time.Sleep(100 * time.Millisecond)
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Nil(t, run.ExitCode)
+}
+
+func TestShell_Cancel_ReportsKilledRun(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run":       "test",
+			"killGrace": "2s",
+		},
+	}, nil, nil)
+
+	executor, shellMiddleware := NewTestShellMiddleware()
+	executor.Killed = true
+	executor.WaitGroup.Add(1)
+	executionContext := middleware.NewExecutionContext()
+	shellMiddleware.Apply(
+		run,
+		func(run *pipeline.Run) {},
+		executionContext,
+	)
+	run.Start()
+	require.Nil(t, run.Cancel())
+	executor.WaitGroup.Done()
+	run.Wait()
+
+	require.True(t, executor.OwnProcessGroup)
+	require.Equal(t, 2*time.Second, executor.TerminateGrace)
+	require.Equal(t, []*pipeline.Run{run}, executionContext.KilledRuns())
+}
+
+func TestShell_InvalidKillGrace(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run":       "test",
+			"killGrace": "soon",
+		},
+	}, nil, nil)
+
+	_, shellMiddleware := NewTestShellMiddleware()
+	shellMiddleware.Apply(
+		run,
+		func(run *pipeline.Run) {
+			t.Fatal("next should not be called")
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "invalid kill grace period \"soon\"")
+}
+
+func TestShell_UsesRunEnvironment(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.Setenv("PIPEDREAM_TEST_PARENT", "parent")
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run": "printf \"$PIPEDREAM_TEST_PARENT $PIPEDREAM_TEST_CHILD\"",
+		},
+	}, nil, parentRun)
+	run.Setenv("PIPEDREAM_TEST_CHILD", "child")
+
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "parent child", run.Stdout.String())
+	require.Equal(t, "", os.Getenv("PIPEDREAM_TEST_CHILD"))
+}
+
+func TestShell_UsesRunWorkingDirectory(t *testing.T) {
+	directory, err := ioutil.TempDir("", "pipedream-shell-test")
+	require.Nil(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	// resolve symlinks, e.g. on macOS /var links to /private/var
+	directory, err = filepath.EvalSymlinks(directory)
+	require.Nil(t, err)
+	currentDirectory, _ := os.Getwd()
+
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.SetWorkingDirectory(directory)
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run": "pwd",
+		},
+	}, nil, parentRun)
+
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	processDirectory, _ := os.Getwd()
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, directory+"\n", run.Stdout.String())
+	require.Equal(t, currentDirectory, processDirectory)
+}
+
+func TestShell_ParallelismLimit(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.LimitParallelism(1)
+	runs := make([]*pipeline.Run, 0, 3)
+	for index := 0; index < 3; index++ {
+		run, _ := pipeline.NewRun(nil, map[string]interface{}{
+			"shell": map[string]interface{}{
+				"run": fmt.Sprintf("sleep 0.2; cat; printf \" %v\"", index),
+			},
+		}, nil, parentRun)
+		run.Stdin.Replace(strings.NewReader("input"))
+		NewMiddleware().Apply(
+			run,
+			func(run *pipeline.Run) {
+			},
+			nil,
+		)
+		runs = append(runs, run)
+	}
+	start := time.Now()
+	for _, run := range runs {
+		run.Start()
+	}
+	for _, run := range runs {
+		run.Wait()
+	}
+
+	// the commands must have been executed one after the other
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(600*time.Millisecond))
+	for index, run := range runs {
+		require.Equal(t, 0, run.Log.ErrorCount())
+		require.Equal(t, fmt.Sprintf("input %v", index), run.Stdout.String())
+		require.Equal(t, 0, *run.ExitCode)
+	}
+}
+
+func TestShell_ParallelismLimit_IndefiniteInput(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.LimitParallelism(1)
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run":        "head -c 5",
+			"indefinite": true,
+		},
+	}, nil, parentRun)
+	stdinReader, stdinWriter := io.Pipe()
+	run.Stdin.Replace(stdinReader)
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+		},
+		nil,
+	)
+	stdoutCopy := run.Stdout.Copy()
+	run.Start()
+	_, err := stdinWriter.Write([]byte("input"))
+	require.Nil(t, err)
+
+	// the command must be executed without waiting for the input to complete
+	output := make([]byte, 5)
+	_, err = io.ReadFull(stdoutCopy, output)
+	require.Nil(t, err)
+	require.Equal(t, "input", string(output))
+	_ = stdinWriter.Close()
+	_, _ = ioutil.ReadAll(stdoutCopy)
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "input", run.Stdout.String())
+	require.Equal(t, 0, *run.ExitCode)
+}
+
+func TestShell_ParallelismLimit_StreamsOutput(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.LimitParallelism(1)
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run": "printf first; sleep 1; printf second",
+		},
+	}, nil, parentRun)
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+		},
+		nil,
+	)
+	stdoutCopy := run.Stdout.Copy()
+	start := time.Now()
+	run.Start()
+
+	// the output is available before the command has exited
+	firstOutput := make([]byte, 5)
+	_, err := io.ReadFull(stdoutCopy, firstOutput)
+	require.Nil(t, err)
+	require.Equal(t, "first", string(firstOutput))
+	require.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	remainingOutput, err := ioutil.ReadAll(stdoutCopy)
+	require.Nil(t, err)
+	require.Equal(t, "second", string(remainingOutput))
+	run.Wait()
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "firstsecond", run.Stdout.String())
+}
+
+func TestShell_ParallelismLimit_Cancel(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.LimitParallelism(1)
+	release, acquired := pipeline.AcquireAll(parentRun.ParallelismLimits(), nil)
+	require.True(t, acquired)
+	defer release()
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"shell": map[string]interface{}{
+			"run": "test",
+		},
+	}, nil, parentRun)
+
+	executor, shellMiddleware := NewTestShellMiddleware()
+	shellMiddleware.Apply(
+		run,
+		func(run *pipeline.Run) {
+		},
+		nil,
+	)
+	run.Start()
+	require.Nil(t, run.Cancel())
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Nil(t, run.ExitCode)
+	require.Nil(t, executor.Environment)
+}
+
 func TestShell_PrintfRun(t *testing.T) {
 	run, err := pipeline.NewRun(
 		nil,
@@ -592,6 +884,11 @@ type TestCommandExecutor struct {
 	WaitGroup         *sync.WaitGroup
 	WaitError         error
 	KillError         error
+	Killed            bool
+	OwnProcessGroup   bool
+	Environment       []string
+	WorkingDirectory  string
+	TerminateGrace    time.Duration
 	Mutex             *sync.RWMutex
 }
 
@@ -639,12 +936,37 @@ func (executor *TestCommandExecutor) Wait() error {
 	return executor.WaitError
 }
 
+func (executor *TestCommandExecutor) SetOwnProcessGroup(ownProcessGroup bool) {
+	executor.Mutex.Lock()
+	defer executor.Mutex.Unlock()
+	executor.OwnProcessGroup = ownProcessGroup
+}
+
+func (executor *TestCommandExecutor) SetEnvironment(environment []string) {
+	executor.Mutex.Lock()
+	defer executor.Mutex.Unlock()
+	executor.Environment = environment
+}
+
+func (executor *TestCommandExecutor) SetWorkingDirectory(directory string) {
+	executor.Mutex.Lock()
+	defer executor.Mutex.Unlock()
+	executor.WorkingDirectory = directory
+}
+
 func (executor *TestCommandExecutor) Kill() error {
 	executor.Mutex.RLock()
 	defer executor.Mutex.RUnlock()
 	return executor.KillError
 }
 
+func (executor *TestCommandExecutor) Terminate(gracePeriod time.Duration) (bool, error) {
+	executor.Mutex.Lock()
+	defer executor.Mutex.Unlock()
+	executor.TerminateGrace = gracePeriod
+	return executor.Killed, executor.KillError
+}
+
 func (executor *TestCommandExecutor) String() string {
 	executor.Mutex.RLock()
 	defer executor.Mutex.RUnlock()
@@ -674,3 +996,44 @@ func NewTestShellMiddleware() (*TestCommandExecutor, Middleware) {
 	executor := NewTestCommandExecutor()
 	return executor, NewMiddlewareWithExecutorCreator(func() commandExecutor { return executor })
 }
+
+func TestShell_CommandNotReadingInput(t *testing.T) {
+	input := strings.Repeat("input that the command does not read\n", 100000)
+	for name, parallelismLimit := range map[string]int{"unlimited": 0, "limited": 1} {
+		t.Run(name, func(t *testing.T) {
+			parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+			if parallelismLimit > 0 {
+				parentRun.LimitParallelism(parallelismLimit)
+			}
+			run, _ := pipeline.NewRun(nil, map[string]interface{}{
+				"shell": map[string]interface{}{
+					"run": "true",
+				},
+			}, nil, parentRun)
+			run.Stdin.Replace(strings.NewReader(input))
+
+			NewMiddleware().Apply(
+				run,
+				func(run *pipeline.Run) {
+				},
+				nil,
+			)
+			// other readers of the input (e.g. the next pipe) must still receive all of it
+			stdinCopy := run.Stdin.Copy()
+			var copiedInput []byte
+			waitGroup := &sync.WaitGroup{}
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				copiedInput, _ = ioutil.ReadAll(stdinCopy)
+			}()
+			run.Start()
+			run.Wait()
+			waitGroup.Wait()
+
+			require.Equal(t, 0, run.Log.ErrorCount())
+			require.Equal(t, input, string(copiedInput))
+			require.Equal(t, 0, *run.ExitCode)
+		})
+	}
+}
@@ -17,6 +17,13 @@ func (sshMiddleware Middleware) String() string {
 	return "ssh"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (sshMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"ssh": {new(string)},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
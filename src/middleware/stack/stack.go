@@ -3,26 +3,33 @@ package stack
 
 import (
 	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/middleware/cache"
 	"github.com/Layer9Berlin/pipedream/src/middleware/catch"
 	"github.com/Layer9Berlin/pipedream/src/middleware/collect"
+	"github.com/Layer9Berlin/pipedream/src/middleware/cond"
 	"github.com/Layer9Berlin/pipedream/src/middleware/dir"
 	"github.com/Layer9Berlin/pipedream/src/middleware/docker"
 	"github.com/Layer9Berlin/pipedream/src/middleware/each"
 	"github.com/Layer9Berlin/pipedream/src/middleware/env"
 	extract "github.com/Layer9Berlin/pipedream/src/middleware/extract"
+	"github.com/Layer9Berlin/pipedream/src/middleware/foreach"
 	"github.com/Layer9Berlin/pipedream/src/middleware/inherit"
 	_input "github.com/Layer9Berlin/pipedream/src/middleware/input"
 	"github.com/Layer9Berlin/pipedream/src/middleware/interpolate"
 	_output "github.com/Layer9Berlin/pipedream/src/middleware/output"
 	"github.com/Layer9Berlin/pipedream/src/middleware/pipe"
+	"github.com/Layer9Berlin/pipedream/src/middleware/query"
+	"github.com/Layer9Berlin/pipedream/src/middleware/retry"
 	_select "github.com/Layer9Berlin/pipedream/src/middleware/select"
 	"github.com/Layer9Berlin/pipedream/src/middleware/sequence"
 	"github.com/Layer9Berlin/pipedream/src/middleware/shell"
 	"github.com/Layer9Berlin/pipedream/src/middleware/ssh"
 	_switch "github.com/Layer9Berlin/pipedream/src/middleware/switch"
 	"github.com/Layer9Berlin/pipedream/src/middleware/sync"
+	"github.com/Layer9Berlin/pipedream/src/middleware/timeout"
 	"github.com/Layer9Berlin/pipedream/src/middleware/timer"
 	"github.com/Layer9Berlin/pipedream/src/middleware/when"
+	"github.com/Layer9Berlin/pipedream/src/middleware/with"
 )
 
 // SetUpMiddleware returns the stack of middleware items that will be unwound during the run's execution
@@ -31,22 +38,40 @@ func SetUpMiddleware() []middleware.Middleware {
 		sync.NewMiddleware(),
 		_select.NewMiddleware(),
 		timer.NewMiddleware(),
+		retry.NewMiddleware(),
+		timeout.NewMiddleware(),
 		inherit.NewMiddleware(),
 		extract.NewMiddleware(),
 		interpolate.NewMiddleware(),
 		env.NewMiddleware(),
+		dir.NewMiddleware(),
+		cache.NewMiddleware(),
 		collect.NewMiddleware(),
 		_switch.NewMiddleware(),
 		when.NewMiddleware(),
+		cond.NewMiddleware(),
 		_output.NewMiddleware(),
+		query.NewMiddleware(),
+		with.NewMiddleware(),
 		catch.NewMiddleware(),
 		pipe.NewMiddleware(),
 		each.NewMiddleware(),
+		foreach.NewMiddleware(),
 		sequence.NewMiddleware(),
 		shell.NewMiddleware(),
 		ssh.NewMiddleware(),
 		docker.NewMiddleware(),
-		dir.NewMiddleware(),
 		_input.NewMiddleware(),
 	}
 }
+
+// SetUpDryRunMiddleware returns a stack in which shell commands are recorded instead of being executed
+func SetUpDryRunMiddleware() []middleware.Middleware {
+	middlewareStack := SetUpMiddleware()
+	for index, middlewareItem := range middlewareStack {
+		if _, isShellMiddleware := middlewareItem.(shell.Middleware); isShellMiddleware {
+			middlewareStack[index] = shell.NewDryRunMiddleware()
+		}
+	}
+	return middlewareStack
+}
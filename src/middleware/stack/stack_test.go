@@ -1,6 +1,9 @@
 package stack
 
 import (
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/middleware/shell"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -11,19 +14,56 @@ func TestRun_MiddlewareStack_setUpMiddleware(t *testing.T) {
 	for _, middlewareItem := range middlewareStack {
 		middlewareStrings = append(middlewareStrings, middlewareItem.String())
 	}
+	require.Contains(t, middlewareStrings, "cache")
 	require.Contains(t, middlewareStrings, "catch")
 	require.Contains(t, middlewareStrings, "dir")
 	require.Contains(t, middlewareStrings, "docker")
 	require.Contains(t, middlewareStrings, "each")
+	require.Contains(t, middlewareStrings, "foreach")
+	require.Contains(t, middlewareStrings, "with")
+	require.Contains(t, middlewareStrings, "cond")
+	require.Contains(t, middlewareStrings, "query")
 	require.Contains(t, middlewareStrings, "env")
 	require.Contains(t, middlewareStrings, "inherit")
 	require.Contains(t, middlewareStrings, "input")
 	require.Contains(t, middlewareStrings, "interpolate")
 	require.Contains(t, middlewareStrings, "output")
 	require.Contains(t, middlewareStrings, "pipe")
+	require.Contains(t, middlewareStrings, "retry")
 	require.Contains(t, middlewareStrings, "shell")
 	require.Contains(t, middlewareStrings, "ssh")
 	require.Contains(t, middlewareStrings, "switch")
+	require.Contains(t, middlewareStrings, "timeout")
 	require.Contains(t, middlewareStrings, "timer")
 	require.Contains(t, middlewareStrings, "when")
 }
+
+func TestRun_MiddlewareStack_setUpDryRunMiddleware(t *testing.T) {
+	middlewareStack := SetUpDryRunMiddleware()
+	require.Equal(t, len(SetUpMiddleware()), len(middlewareStack))
+	for _, middlewareItem := range middlewareStack {
+		if shellMiddleware, isShellMiddleware := middlewareItem.(shell.Middleware); isShellMiddleware {
+			executor := shellMiddleware.ExecutorCreator()
+			executor.Init("sh", "-c", "exit 1")
+			require.Nil(t, executor.Start())
+			require.Nil(t, executor.Wait())
+			return
+		}
+	}
+	t.Fatal("missing shell middleware")
+}
+
+func TestRun_MiddlewareStack_argumentsDescribed(t *testing.T) {
+	for _, middlewareItem := range SetUpMiddleware() {
+		describer, isDescriber := middlewareItem.(middleware.ArgumentsDescriber)
+		require.True(t, isDescriber, middlewareItem.String())
+		shapes := describer.Arguments()
+		require.Contains(t, shapes, middlewareItem.String())
+		for key, keyShapes := range shapes {
+			require.NotEmpty(t, keyShapes, key)
+			for _, shape := range keyShapes {
+				require.Nil(t, pipeline.DecodeArguments(nil, shape), key)
+			}
+		}
+	}
+}
@@ -18,6 +18,13 @@ func (switchMiddleware Middleware) String() string {
 	return "switch"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (switchMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"switch": {&middlewareArguments{}},
+	}
+}
+
 type middlewareArguments = []struct {
 	Pattern *string
 	Text    *string
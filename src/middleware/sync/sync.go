@@ -17,6 +17,13 @@ func (syncMiddleware Middleware) String() string {
 	return "sync"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (syncMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"sync": {new(bool)},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
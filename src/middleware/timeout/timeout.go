@@ -0,0 +1,98 @@
+// Package timeout provides a middleware that cancels pipes exceeding a maximum execution time
+package timeout
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/logging/fields"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"time"
+)
+
+// Middleware is an execution time limiter
+type Middleware struct {
+}
+
+// String is a human-readable description
+func (Middleware) String() string {
+	return "timeout"
+}
+
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"timeout": {new(string)},
+	}
+}
+
+// ArgumentsSchema provides a JSON Schema fragment restricting the argument to valid durations
+func (Middleware) ArgumentsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"timeout": map[string]interface{}{
+			"description": "Maximum execution time, such as `500ms`, `30s` or `1h15m`",
+			"type":        "string",
+			"pattern":     `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+		},
+	}
+}
+
+// NewMiddleware creates a new Middleware instance
+func NewMiddleware() Middleware {
+	return Middleware{}
+}
+
+// Apply is where the middleware's logic resides
+//
+// It adapts the run based on its slice of the run's arguments.
+// It may also trigger side effects such as executing shell commands or full runs of other pipelines.
+// When done, this function should call next in order to continue unwinding the stack.
+func (timeoutMiddleware Middleware) Apply(
+	run *pipeline.Run,
+	next func(*pipeline.Run),
+	executionContext *middleware.ExecutionContext,
+) {
+	argument := ""
+	pipeline.ParseArguments(&argument, "timeout", run)
+
+	if argument == "" {
+		next(run)
+		return
+	}
+
+	timeout, err := time.ParseDuration(argument)
+	if err != nil {
+		run.Log.Error(
+			fmt.Errorf("invalid timeout %q: %w", argument, err),
+			fields.Middleware(timeoutMiddleware),
+		)
+		return
+	}
+
+	run.Log.Debug(
+		fields.Symbol("⏱"),
+		fields.Message("timeout"),
+		fields.Info(timeout),
+		fields.Middleware(timeoutMiddleware),
+	)
+
+	next(run)
+
+	run.DontCompleteBefore(func() {
+		// we only want to start counting once the run has started,
+		// but must not prevent it from completing in time
+		timer := time.AfterFunc(timeout, func() {
+			if run.Completed() {
+				return
+			}
+			run.Log.Error(
+				fmt.Errorf("timed out after %v", timeout),
+				fields.Middleware(timeoutMiddleware),
+			)
+			run.Log.PossibleError(executionContext.CancelWithDescendants(run))
+		})
+		go func() {
+			run.Wait()
+			timer.Stop()
+		}()
+	})
+}
@@ -0,0 +1,86 @@
+package timeout
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTimeout_CancelsRunAndDescendants(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"timeout": "10ms",
+	}, nil, nil)
+	executionContext := middleware.NewExecutionContext()
+	var childRun *pipeline.Run
+	childCancelled := make(chan bool)
+	runCancelled := make(chan bool)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {
+		childRun = executionContext.FullRun(
+			middleware.WithParentRun(run),
+			middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+				childRun.AddCancelHook(func() error {
+					close(childCancelled)
+					return nil
+				})
+				childRun.DontCompleteBefore(func() {
+					<-childCancelled
+				})
+			}))
+		run.AddCancelHook(func() error {
+			close(runCancelled)
+			return nil
+		})
+		run.DontCompleteBefore(func() {
+			<-runCancelled
+		})
+	}, executionContext)
+	run.Start()
+	run.Wait()
+	childRun.Wait()
+
+	require.True(t, run.Cancelled())
+	require.True(t, childRun.Cancelled())
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "timed out after 10ms")
+}
+
+func TestTimeout_CompletesInTime(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"timeout": "1s",
+	}, nil, nil)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {
+		run.DontCompleteBefore(func() {
+			time.Sleep(10 * time.Millisecond)
+		})
+	}, middleware.NewExecutionContext())
+	run.Start()
+	run.Wait()
+
+	require.False(t, run.Cancelled())
+	require.Equal(t, 0, run.Log.ErrorCount())
+}
+
+func TestTimeout_InvalidDuration(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"timeout": "eventually",
+	}, nil, nil)
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {
+		t.Fatal("next should not be called")
+	}, middleware.NewExecutionContext())
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "invalid timeout \"eventually\"")
+}
+
+func TestTimeout_WithoutArguments(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{}, nil, nil)
+	nextCalled := false
+	NewMiddleware().Apply(run, func(run *pipeline.Run) {
+		nextCalled = true
+	}, nil)
+	require.True(t, nextCalled)
+}
@@ -38,6 +38,13 @@ func (timerMiddleware Middleware) String() string {
 	return "timer"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (timerMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"timer": {&timerMiddlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return NewMiddlewareWithProvider(defaultTimeProvider{})
@@ -2,12 +2,14 @@
 package when
 
 import (
+	"bytes"
 	"fmt"
-	"github.com/Layer9Berlin/pipedream/src/custom/evaluate"
 	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io"
+	"io/ioutil"
 )
 
 // Middleware is a conditional executor
@@ -19,6 +21,24 @@ func (whenMiddleware Middleware) String() string {
 	return "when"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (whenMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"else": {&pipeline.Reference{}},
+		"when": {new(string)},
+	}
+}
+
+// ArgumentsSchema provides JSON Schema fragments describing the condition and the alternative pipe
+func (whenMiddleware Middleware) ArgumentsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"when": map[string]interface{}{
+			"description": "Boolean expression determining whether the pipe is executed",
+			"type":        "string",
+		},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
@@ -42,37 +62,28 @@ func (whenMiddleware Middleware) Apply(
 		return
 	}
 
-	shouldExecute, err := evaluate.Bool(argument)
+	condition, err := middleware.NewCondition(argument, run)
 	if err != nil {
 		run.Log.Error(err)
 		return
 	}
+	if condition.Deferred() {
+		whenMiddleware.applyWhenAvailable(run, condition, executionContext)
+		return
+	}
 
+	shouldExecute, err := condition.Evaluate(run, nil, executionContext)
+	if err != nil {
+		run.Log.Error(err)
+		return
+	}
+
+	whenMiddleware.logResult(run, argument, shouldExecute)
 	if shouldExecute {
-		run.Log.Debug(
-			fields.Symbol("?"),
-			fields.Message("satisfied"),
-			fields.Info(fmt.Sprintf("%q", argument)),
-			fields.Middleware(whenMiddleware),
-		)
 		next(run)
 	} else {
-		run.Log.Debug(
-			fields.Symbol("?"),
-			fields.Message("not satisfied"),
-			fields.Info(fmt.Sprintf("%q", argument)),
-			fields.Color("lightgrey"),
-			fields.Middleware(whenMiddleware),
-		)
-		arguments := pipeline.Reference{}
-		pipeline.ParseArguments(&arguments, "else", run)
-
-		if len(arguments) > 0 {
-			run.Log.Debug(
-				fields.Symbol("✖️"),
-				fields.Info("else"),
-				fields.Middleware(whenMiddleware),
-			)
+		elseIdentifier, elseArguments, haveElse := elseReference(run)
+		if haveElse {
 			run.Log.Trace(
 				fields.DataStream(whenMiddleware, "copying stdin")...,
 			)
@@ -88,24 +99,88 @@ func (whenMiddleware Middleware) Apply(
 			// we return immediately and wait for the previous input to be available
 			// then we execute a full run
 			parentLogWriter := run.Log.AddWriteCloserEntry()
+			whenMiddleware.executeElse(
+				run,
+				executionContext,
+				elseIdentifier,
+				elseArguments,
+				stdinCopy,
+				stdoutAppender,
+				stderrAppender,
+				parentLogWriter,
+			)
+		} else {
+			run.MarkSkipped()
+			// the provided input should not be discarded, but passed through
+			// (there might be a next invocation in the chain)
+			run.Stdout.MergeWith(run.Stdin.Copy())
+		}
+	}
+}
 
-			var childIdentifier *string
-			childArguments := make(stringmap.StringMap, 10)
-			for elseIdentifier, elseArguments := range arguments {
-				childIdentifier = elseIdentifier
-				childArguments = elseArguments
-				break
-			}
+// applyWhenAvailable evaluates the condition once the input and the referenced runs are available
+//
+// As we cannot wait for them before unwinding the rest of the stack, the pipe is executed in a separate full run.
+func (whenMiddleware Middleware) applyWhenAvailable(
+	run *pipeline.Run,
+	condition *middleware.Condition,
+	executionContext *middleware.ExecutionContext,
+) {
+	if executionContext == nil {
+		run.Log.Error(
+			fmt.Errorf("condition %q cannot be evaluated without an execution context", condition.Source),
+			fields.Middleware(whenMiddleware),
+		)
+		return
+	}
+	run.Log.Debug(
+		fields.Symbol("💤"),
+		fields.Message("waiting for input and runs referenced in condition"),
+		fields.Info(condition.RunIdentifiers),
+		fields.Middleware(whenMiddleware),
+	)
+	run.Log.Trace(
+		fields.DataStream(whenMiddleware, "copying stdin")...,
+	)
+	stdinCopy := run.Stdin.Copy()
+	run.Log.Trace(
+		fields.DataStream(whenMiddleware, "creating stdout writer")...,
+	)
+	stdoutAppender := run.Stdout.WriteCloser()
+	run.Log.Trace(
+		fields.DataStream(whenMiddleware, "creating stderr writer")...,
+	)
+	stderrAppender := run.Stderr.WriteCloser()
+	parentLogWriter := run.Log.AddWriteCloserEntry()
+	go func() {
+		inputData, inputErr := ioutil.ReadAll(stdinCopy)
+		run.Log.PossibleError(inputErr)
+		input := string(inputData)
+
+		shouldExecute, err := condition.Evaluate(run, &input, executionContext)
+		if err != nil {
+			run.Log.Error(err)
+			run.Log.PossibleError(stdoutAppender.Close())
+			run.Log.PossibleError(stderrAppender.Close())
+			run.Log.PossibleError(parentLogWriter.Close())
+			return
+		}
+
+		whenMiddleware.logResult(run, condition.Source, shouldExecute)
+		if shouldExecute {
 			executionContext.FullRun(
-				middleware.WithIdentifier(childIdentifier),
+				middleware.WithIdentifier(run.Identifier),
 				middleware.WithParentRun(run),
 				middleware.WithLogWriter(parentLogWriter),
-				middleware.WithArguments(childArguments),
+				middleware.WithArguments(run.ArgumentsCopy()),
 				middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+					// need to remove the condition to prevent infinite recursion
+					// we can only do this within the full run, as the pipe's definition might contain a `when` argument
+					childRun.Log.PossibleError(childRun.RemoveArgumentAtPath("when"))
 					childRun.Log.Trace(
 						fields.DataStream(whenMiddleware, "merging parent stdin into child stdin")...,
 					)
-					childRun.Stdin.MergeWith(stdinCopy)
+					childRun.Stdin.MergeWith(bytes.NewReader(inputData))
 				}),
 				middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
 					childRun.Log.Trace(
@@ -116,7 +191,7 @@ func (whenMiddleware Middleware) Apply(
 						fields.DataStream(whenMiddleware, "merging child stderr into parent stderr")...,
 					)
 					childRun.Stderr.StartCopyingInto(stderrAppender)
-					executionContext.AddConnection(run, childRun, "else")
+					executionContext.AddConnection(run, childRun, "when")
 					go func() {
 						childRun.Wait()
 						// need to clean up by closing the writers we created
@@ -124,10 +199,110 @@ func (whenMiddleware Middleware) Apply(
 						childRun.Log.PossibleError(stderrAppender.Close())
 					}()
 				}))
-		} else {
-			// the provided input should not be discarded, but passed through
-			// (there might be a next invocation in the chain)
-			run.Stdout.MergeWith(run.Stdin.Copy())
+			return
+		}
+
+		elseIdentifier, elseArguments, haveElse := elseReference(run)
+		if haveElse {
+			whenMiddleware.executeElse(
+				run,
+				executionContext,
+				elseIdentifier,
+				elseArguments,
+				bytes.NewReader(inputData),
+				stdoutAppender,
+				stderrAppender,
+				parentLogWriter,
+			)
+			return
 		}
+		run.MarkSkipped()
+		// the provided input should not be discarded, but passed through
+		_, err = stdoutAppender.Write(inputData)
+		run.Log.PossibleError(err)
+		run.Log.PossibleError(stdoutAppender.Close())
+		run.Log.PossibleError(stderrAppender.Close())
+		run.Log.PossibleError(parentLogWriter.Close())
+	}()
+}
+
+func (whenMiddleware Middleware) logResult(run *pipeline.Run, argument string, shouldExecute bool) {
+	if shouldExecute {
+		run.Log.Debug(
+			fields.Symbol("?"),
+			fields.Message("satisfied"),
+			fields.Info(fmt.Sprintf("%q", argument)),
+			fields.Middleware(whenMiddleware),
+		)
+	} else {
+		run.Log.Debug(
+			fields.Symbol("?"),
+			fields.Message("not satisfied"),
+			fields.Info(fmt.Sprintf("%q", argument)),
+			fields.Color("lightgrey"),
+			fields.Middleware(whenMiddleware),
+		)
+	}
+}
+
+func elseReference(run *pipeline.Run) (*string, map[string]interface{}, bool) {
+	arguments := pipeline.Reference{}
+	pipeline.ParseArguments(&arguments, "else", run)
+	if len(arguments) == 0 {
+		return nil, nil, false
 	}
+
+	var childIdentifier *string
+	childArguments := make(stringmap.StringMap, 10)
+	for elseIdentifier, elseArguments := range arguments {
+		childIdentifier = elseIdentifier
+		childArguments = elseArguments
+		break
+	}
+	return childIdentifier, childArguments, true
+}
+
+func (whenMiddleware Middleware) executeElse(
+	run *pipeline.Run,
+	executionContext *middleware.ExecutionContext,
+	childIdentifier *string,
+	childArguments map[string]interface{},
+	input io.Reader,
+	stdoutAppender io.WriteCloser,
+	stderrAppender io.WriteCloser,
+	parentLogWriter io.WriteCloser,
+) {
+	run.Log.Debug(
+		fields.Symbol("✖️"),
+		fields.Info("else"),
+		fields.Middleware(whenMiddleware),
+	)
+	executionContext.FullRun(
+		middleware.WithIdentifier(childIdentifier),
+		middleware.WithParentRun(run),
+		middleware.WithLogWriter(parentLogWriter),
+		middleware.WithArguments(childArguments),
+		middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+			childRun.Log.Trace(
+				fields.DataStream(whenMiddleware, "merging parent stdin into child stdin")...,
+			)
+			childRun.Stdin.MergeWith(input)
+		}),
+		middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+			childRun.Log.Trace(
+				fields.DataStream(whenMiddleware, "merging child stdout into parent stdout")...,
+			)
+			childRun.Stdout.StartCopyingInto(stdoutAppender)
+			childRun.Log.Trace(
+				fields.DataStream(whenMiddleware, "merging child stderr into parent stderr")...,
+			)
+			childRun.Stderr.StartCopyingInto(stderrAppender)
+			executionContext.AddConnection(run, childRun, "else")
+			go func() {
+				childRun.Wait()
+				// need to clean up by closing the writers we created
+				childRun.Log.PossibleError(stdoutAppender.Close())
+				childRun.Log.PossibleError(stderrAppender.Close())
+			}()
+		}))
 }
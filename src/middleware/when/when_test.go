@@ -1,12 +1,15 @@
 package when
 
 import (
+	"fmt"
 	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"strings"
 	"testing"
 )
 
@@ -144,3 +147,164 @@ func TestWhen_else_withMapReference(t *testing.T) {
 		"arg": "value",
 	}, runArguments)
 }
+
+func TestWhen_Parameters(t *testing.T) {
+	parentRun, _ := pipeline.NewRun(nil, nil, nil, nil)
+	parentRun.Setenv("PIPEDREAM_WHEN_TEST", "it's \"quoted\"")
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"when":  "env.PIPEDREAM_WHEN_TEST == message && nested.count > 1 && env.PIPEDREAM_UNDEFINED == ''",
+		"count": 3,
+		"nested": map[string]interface{}{
+			"count": 2,
+		},
+		"message": "it's \"quoted\"",
+	}, nil, parentRun)
+
+	nextCalled := false
+	run.Log.SetLevel(logrus.DebugLevel)
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {
+			nextCalled = true
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.True(t, nextCalled)
+}
+
+func TestWhen_Input(t *testing.T) {
+	for _, input := range []string{"it's a \"success\"", "failure"} {
+		run, _ := pipeline.NewRun(nil, map[string]interface{}{
+			"when": "contains(input, 'success')",
+			"arg":  "value",
+		}, nil, nil)
+		run.Stdin.Replace(strings.NewReader(input))
+
+		var childArguments map[string]interface{} = nil
+		NewMiddleware().Apply(
+			run,
+			func(run *pipeline.Run) {
+				require.Fail(t, "the stack should only be unwound in a separate full run")
+			},
+			middleware.NewExecutionContext(
+				middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+					childArguments = childRun.ArgumentsCopy()
+					stdinCopy := childRun.Stdin.Copy()
+					stdoutWriter := childRun.Stdout.WriteCloser()
+					childRun.DontCompleteBefore(func() {
+						childInput, _ := ioutil.ReadAll(stdinCopy)
+						_, _ = stdoutWriter.Write([]byte(fmt.Sprintf("executed with %v", string(childInput))))
+						_ = stdoutWriter.Close()
+					})
+				}),
+			),
+		)
+		run.Start()
+		run.Wait()
+
+		require.Equal(t, 0, run.Log.ErrorCount())
+		if input == "failure" {
+			require.Nil(t, childArguments)
+			require.Equal(t, "failure", run.Stdout.String())
+		} else {
+			require.Equal(t, map[string]interface{}{
+				"arg": "value",
+			}, childArguments)
+			require.Equal(t, "executed with it's a \"success\"", run.Stdout.String())
+		}
+	}
+}
+
+func TestWhen_Runs(t *testing.T) {
+	executionContext := middleware.NewExecutionContext(
+		middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+			if childRun.Identifier != nil && *childRun.Identifier == "build" {
+				exitCode := 2
+				childRun.ExitCode = &exitCode
+				childRun.Stdout.Replace(strings.NewReader("build output"))
+			}
+		}),
+	)
+	buildIdentifier := "build"
+	executionContext.FullRun(middleware.WithIdentifier(&buildIdentifier))
+
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"when": "runs.build.exitCode != 0 && runs.build.stdout == 'build output' && runs.build.stderr == ''",
+		"else": "should-not-run",
+	}, nil, nil)
+	run.Log.SetLevel(logrus.DebugLevel)
+	NewMiddleware().Apply(
+		run,
+		func(run *pipeline.Run) {},
+		executionContext,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Contains(t, run.Log.String(), "when | satisfied")
+}
+
+func TestWhen_InvalidRunReference(t *testing.T) {
+	for _, condition := range []string{"runs.build == 0", "runs.build.output == ''"} {
+		run, _ := pipeline.NewRun(nil, map[string]interface{}{
+			"when": condition,
+		}, nil, nil)
+
+		nextCalled := false
+		NewMiddleware().Apply(
+			run,
+			func(run *pipeline.Run) {
+				nextCalled = true
+			},
+			nil,
+		)
+		run.Start()
+		run.Wait()
+
+		require.False(t, nextCalled)
+		require.Equal(t, 1, run.Log.ErrorCount())
+		require.Contains(t, run.Log.LastError().Error(), "invalid run reference")
+	}
+}
+
+func TestWhen_UnavailableRuns(t *testing.T) {
+	var executionContext *middleware.ExecutionContext
+	executionContext = middleware.NewExecutionContext(
+		middleware.WithExecutionFunction(func(run *pipeline.Run) {
+			NewMiddleware().Apply(run, func(run *pipeline.Run) {}, executionContext)
+		}),
+	)
+	skippedIdentifier := "skipped"
+	skippedRun := executionContext.FullRun(
+		middleware.WithIdentifier(&skippedIdentifier),
+		middleware.WithArguments(map[string]interface{}{
+			"when": "1 == 2",
+		}),
+	)
+	skippedRun.Wait()
+	require.True(t, skippedRun.Skipped())
+
+	for condition, expectedError := range map[string]string{
+		"runs.skipped.exitCode == 0": `referenced pipe "skipped" was skipped`,
+		"runs.unknown.exitCode == 0": `referenced pipe "unknown" was not started`,
+	} {
+		run, _ := pipeline.NewRun(nil, map[string]interface{}{
+			"when": condition,
+		}, nil, nil)
+		NewMiddleware().Apply(
+			run,
+			func(run *pipeline.Run) {},
+			executionContext,
+		)
+		run.Start()
+		run.Wait()
+
+		require.Equal(t, 1, run.Log.ErrorCount())
+		require.Contains(t, run.Log.LastError().Error(), expectedError)
+	}
+}
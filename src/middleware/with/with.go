@@ -3,6 +3,7 @@ package with
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/Layer9Berlin/pipedream/src/logging/fields"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
@@ -20,12 +21,21 @@ func (withMiddleware Middleware) String() string {
 	return "with"
 }
 
+// Arguments describes the shapes of the arguments interpreted by the middleware
+func (withMiddleware Middleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"with": {&withMiddlewareArguments{}},
+	}
+}
+
 // NewMiddleware creates a new middleware instance
 func NewMiddleware() Middleware {
 	return Middleware{}
 }
 
 type withMiddlewareArguments struct {
+	Match   string
+	Output  string
 	Pattern string
 }
 
@@ -40,6 +50,8 @@ func (withMiddleware Middleware) Apply(
 	executionContext *middleware.ExecutionContext,
 ) {
 	argument := withMiddlewareArguments{
+		Match:   "match",
+		Output:  "replace",
 		Pattern: "",
 	}
 	pipeline.ParseArguments(&argument, "with", run)
@@ -59,6 +71,13 @@ func (withMiddleware Middleware) Apply(
 			run.Log.Error(err, fields.Middleware(withMiddleware))
 			return
 		}
+		if argument.Output != "replace" && argument.Output != "matches" {
+			run.Log.Error(
+				fmt.Errorf("invalid output %q, expected one of `replace`, `matches`", argument.Output),
+				fields.Middleware(withMiddleware),
+			)
+			return
+		}
 
 		run.Log.Trace(
 			fields.Symbol("⎇"),
@@ -78,26 +97,45 @@ func (withMiddleware Middleware) Apply(
 			fields.Middleware(withMiddleware),
 		)
 		stderrAppender := run.Stderr.WriteCloser()
+		go func() {
+			// the pipe's own output is replaced, but we still need to read it to unblock the corresponding writes
+			_, _ = ioutil.ReadAll(stdoutIntercept)
+		}()
 		waitGroup := &sync.WaitGroup{}
 		go func() {
 			completeInput, err := ioutil.ReadAll(stdinCopy)
-			inputMutex := &sync.Mutex{}
 			run.Log.PossibleError(err)
-			matches := regex.FindAllSubmatch(completeInput, -1)
-			waitGroup.Add(len(matches))
-			for _, match := range matches {
-				match := match
+			matchIndices := regex.FindAllSubmatchIndex(completeInput, -1)
+			matchOutputs := make([][]byte, len(matchIndices))
+			waitGroup.Add(len(matchIndices))
+			for index, matchIndex := range matchIndices {
+				index := index
+				matchText := completeInput[matchIndex[0]:matchIndex[1]]
 				executionContext.FullRun(
 					middleware.WithParentRun(run),
 					middleware.WithIdentifier(run.Identifier),
-					middleware.WithArguments(run.ArgumentsCopy()),
+					middleware.WithArguments(matchArguments(
+						run.ArgumentsCopy(),
+						argument.Match,
+						regex.SubexpNames(),
+						completeInput,
+						matchIndex,
+					)),
 					middleware.WithSetupFunc(func(matchRun *pipeline.Run) {
+						// the match run executes the same pipe again, so we need to prevent infinite recursion
+						// we can only do this within the full run, as the pipe's definition might contain a `with` argument
+						matchRun.Log.PossibleError(matchRun.RemoveArgumentAtPath("with"))
+						// the parent's arguments might already have been interpolated without the match arguments,
+						// in which case the interpolate middleware will have disabled interpolation for them
+						if interpolationDisabledByMiddleware(run) {
+							matchRun.Log.PossibleError(matchRun.SetArgumentAtPath(true, "interpolate", "enable"))
+						}
 						run.Log.Trace(
 							fields.Symbol("⎇"),
 							fields.Message("replacing stdin with regex match"),
 							fields.Middleware(withMiddleware),
 						)
-						matchRun.Stdin.Replace(bytes.NewBuffer(match[0]))
+						matchRun.Stdin.Replace(bytes.NewBuffer(matchText))
 					}),
 					middleware.WithTearDownFunc(func(matchRun *pipeline.Run) {
 						run.Log.Trace(
@@ -110,16 +148,13 @@ func (withMiddleware Middleware) Apply(
 						go func() {
 							defer waitGroup.Done()
 							matchRun.Wait()
-							defer inputMutex.Unlock()
-							inputMutex.Lock()
-							completeStdout := matchRun.Stdout.Bytes()
-							completeInput = bytes.Replace(completeInput, match[0], completeStdout, -1)
+							matchOutputs[index] = matchRun.Stdout.Bytes()
 						}()
 					}))
 			}
 			go func() {
 				waitGroup.Wait()
-				_, err = stdoutIntercept.Write(completeInput)
+				_, err = stdoutIntercept.Write(mergeOutputs(completeInput, matchIndices, matchOutputs, argument.Output))
 				run.Log.PossibleError(err)
 				run.Log.PossibleError(stdoutIntercept.Close())
 				run.Log.PossibleError(stderrAppender.Close())
@@ -127,3 +162,67 @@ func (withMiddleware Middleware) Apply(
 		}()
 	}
 }
+
+// interpolationDisabledByMiddleware indicates whether the run is the result of interpolating its parent's arguments
+//
+// The interpolate middleware executes the same pipe again with interpolation disabled,
+// so the pipe's own setting is that of the parent run.
+func interpolationDisabledByMiddleware(run *pipeline.Run) bool {
+	if interpolationEnabled(run) || run.Parent == nil || run.Identifier == nil || run.Parent.Identifier == nil {
+		return false
+	}
+	return *run.Identifier == *run.Parent.Identifier && interpolationEnabled(run.Parent)
+}
+
+func interpolationEnabled(run *pipeline.Run) bool {
+	enable, err := run.ArgumentAtPath("interpolate", "enable")
+	if err != nil {
+		return true
+	}
+	enableAsBool, enableIsBool := enable.(bool)
+	return !enableIsBool || enableAsBool
+}
+
+// matchArguments binds the match and its capture groups to the match run's arguments
+//
+// Capture groups are bound by number (e.g. `match.1`) and, if named, by name (e.g. `match.version`).
+func matchArguments(
+	arguments map[string]interface{},
+	matchKey string,
+	groupNames []string,
+	input []byte,
+	matchIndex []int,
+) map[string]interface{} {
+	arguments[matchKey] = string(input[matchIndex[0]:matchIndex[1]])
+	for group := 1; group < len(groupNames); group++ {
+		value := ""
+		// optional groups that did not participate in the match have negative indices
+		if matchIndex[2*group] >= 0 {
+			value = string(input[matchIndex[2*group]:matchIndex[2*group+1]])
+		}
+		arguments[fmt.Sprintf("%v.%d", matchKey, group)] = value
+		if groupNames[group] != "" {
+			arguments[fmt.Sprintf("%v.%v", matchKey, groupNames[group])] = value
+		}
+	}
+	return arguments
+}
+
+func mergeOutputs(input []byte, matchIndices [][]int, matchOutputs [][]byte, output string) []byte {
+	result := new(bytes.Buffer)
+	if output == "matches" {
+		for _, matchOutput := range matchOutputs {
+			result.Write(matchOutput)
+		}
+		return result.Bytes()
+	}
+	// replace each match with the output of its run, leaving the rest of the input untouched
+	previousEnd := 0
+	for index, matchIndex := range matchIndices {
+		result.Write(input[previousEnd:matchIndex[0]])
+		result.Write(matchOutputs[index])
+		previousEnd = matchIndex[1]
+	}
+	result.Write(input[previousEnd:])
+	return result.Bytes()
+}
@@ -1,6 +1,7 @@
 package with
 
 import (
+	"fmt"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/sirupsen/logrus"
@@ -117,3 +118,186 @@ func TestWith_PatternDoesNotCompile(t *testing.T) {
 	require.Equal(t, "", run.Stdout.String())
 	require.Equal(t, "", run.Stderr.String())
 }
+
+func TestWith_CaptureGroups(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"with": map[string]interface{}{
+			"pattern": "(?P<name>[a-z]+)@(\\d+)(-beta)?",
+		},
+		"other": "value",
+	}, nil, nil)
+
+	childArguments := make([]map[string]interface{}, 0, 2)
+	childArgumentsMutex := &sync.Mutex{}
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			run.Stdin.Replace(strings.NewReader("foo@1-beta and bar@2 and foo@1-beta"))
+		},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+				arguments := childRun.ArgumentsCopy()
+				childArgumentsMutex.Lock()
+				childArguments = append(childArguments, arguments)
+				childArgumentsMutex.Unlock()
+				childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("%v:%v", arguments["match.name"], arguments["match.2"])))
+			}),
+		))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	// each match is replaced by the output of its own run, even if the match text is identical
+	require.Equal(t, "foo:1 and bar:2 and foo:1", run.Stdout.String())
+	require.Len(t, childArguments, 3)
+	require.Contains(t, childArguments, map[string]interface{}{
+		"other":      "value",
+		"match":      "bar@2",
+		"match.1":    "bar",
+		"match.name": "bar",
+		"match.2":    "2",
+		"match.3":    "",
+	})
+}
+
+func TestWith_OutputMatches(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"with": map[string]interface{}{
+			"pattern": "v(?P<version>\\d+)",
+			"match":   "found",
+			"output":  "matches",
+		},
+	}, nil, nil)
+
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			run.Stdin.Replace(strings.NewReader("a v1 b v2 c"))
+		},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+				version := childRun.ArgumentsCopy()["found.version"]
+				childRun.Stdout.Replace(strings.NewReader(fmt.Sprintf("%v\n", version)))
+			}),
+		))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, "1\n2\n", run.Stdout.String())
+}
+
+func TestWith_InvalidOutput(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"with": map[string]interface{}{
+			"pattern": "test",
+			"output":  "json",
+		},
+	}, nil, nil)
+
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			run.Stdin.Replace(strings.NewReader("test"))
+		},
+		nil,
+	)
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 1, run.Log.ErrorCount())
+	require.Contains(t, run.Log.LastError().Error(), "invalid output")
+}
+
+func TestWith_MatchRunDoesNotRecurse(t *testing.T) {
+	run, _ := pipeline.NewRun(nil, map[string]interface{}{
+		"with": map[string]interface{}{
+			"pattern": "test",
+		},
+	}, nil, nil)
+
+	haveWithArgument := false
+	NewMiddleware().Apply(
+		run,
+		func(pipelineRun *pipeline.Run) {
+			run.Stdin.Replace(strings.NewReader("test"))
+		},
+		middleware.NewExecutionContext(
+			middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+				haveWithArgument = childRun.HaveArgumentAtPath("with")
+				childRun.Stdout.Replace(strings.NewReader("replaced"))
+			}),
+		))
+	run.Start()
+	run.Wait()
+
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.False(t, haveWithArgument)
+	require.Equal(t, "replaced", run.Stdout.String())
+}
+
+func TestWith_InterpolationOfMatchRuns(t *testing.T) {
+	identifier := "rewrite"
+	for name, testCase := range map[string]struct {
+		parentEnable      interface{}
+		runEnable         interface{}
+		matchRunEnable    interface{}
+		haveInterpolation bool
+	}{
+		"disabled by the interpolate middleware": {
+			parentEnable:      true,
+			runEnable:         false,
+			matchRunEnable:    true,
+			haveInterpolation: true,
+		},
+		"disabled explicitly": {
+			parentEnable:      false,
+			runEnable:         false,
+			matchRunEnable:    false,
+			haveInterpolation: true,
+		},
+		"never disabled": {
+			parentEnable:      nil,
+			runEnable:         nil,
+			haveInterpolation: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			parentArguments := map[string]interface{}{}
+			if testCase.parentEnable != nil {
+				parentArguments["interpolate"] = map[string]interface{}{"enable": testCase.parentEnable}
+			}
+			parentRun, _ := pipeline.NewRun(&identifier, parentArguments, nil, nil)
+			runArguments := map[string]interface{}{
+				"with": map[string]interface{}{
+					"pattern": "test",
+				},
+			}
+			if testCase.runEnable != nil {
+				runArguments["interpolate"] = map[string]interface{}{"enable": testCase.runEnable}
+			}
+			run, _ := pipeline.NewRun(&identifier, runArguments, nil, parentRun)
+
+			var matchRunArguments map[string]interface{}
+			NewMiddleware().Apply(
+				run,
+				func(pipelineRun *pipeline.Run) {
+					run.Stdin.Replace(strings.NewReader("test"))
+				},
+				middleware.NewExecutionContext(
+					middleware.WithExecutionFunction(func(childRun *pipeline.Run) {
+						matchRunArguments = childRun.ArgumentsCopy()
+					}),
+				))
+			run.Start()
+			run.Wait()
+
+			require.Equal(t, 0, run.Log.ErrorCount())
+			interpolateArguments, haveInterpolation := matchRunArguments["interpolate"]
+			require.Equal(t, testCase.haveInterpolation, haveInterpolation)
+			if haveInterpolation {
+				require.Equal(t, testCase.matchRunEnable, interpolateArguments.(map[string]interface{})["enable"])
+			}
+		})
+	}
+}
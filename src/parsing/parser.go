@@ -45,7 +45,6 @@ func (parser *Parser) ParsePipelineFiles(allPipelineFilePaths []string, builtIn
 	// GO types are not quite flexible enough for this
 	definitions = pipeline.DefinitionsLookup{}
 	files = make([]pipeline.File, 0, len(allPipelineFilePaths))
-	// TODO: take care of hooks
 	for index, pipelineFilePath := range allPipelineFilePaths {
 		fileData, err := parser.readFile(pipelineFilePath)
 		if err != nil {
@@ -56,11 +55,16 @@ func (parser *Parser) ParsePipelineFiles(allPipelineFilePaths []string, builtIn
 			Path:     pipelineFilePath,
 			FileName: filepath.Base(pipelineFilePath),
 		}
-		err = yaml.Unmarshal(fileData, &pipelineFile)
+		document := yaml.Node{}
+		err = yaml.Unmarshal(fileData, &document)
+		if err == nil {
+			err = document.Decode(&pipelineFile)
+		}
 		if err != nil {
 			returnErr = fmt.Errorf("unable to parse file %q: %w", pipelineFilePath, err)
 			return
 		}
+		recordSources(&pipelineFile, &document)
 
 		newDefinitions := parser.ProcessPipelineFile(pipelineFile, builtIn)
 
@@ -90,10 +94,12 @@ func (parser *Parser) ProcessPipelineFile(
 	pipelineDefinitions := pipeline.DefinitionsLookup{}
 	for pipelineKey, pipelineValues := range pipelineFile.Public {
 		pipelineDefinition := pipeline.NewDefinition(pipelineValues, pipelineFile.FileName, true, builtIn)
+		addSource(pipelineDefinition, pipelineFile.PublicSources, pipelineKey)
 		pipelineDefinitions[pipelineKey] = []pipeline.Definition{*pipelineDefinition}
 	}
 	for pipelineKey, pipelineValues := range pipelineFile.Private {
 		pipelineDefinition := pipeline.NewDefinition(pipelineValues, pipelineFile.FileName, false, builtIn)
+		addSource(pipelineDefinition, pipelineFile.PrivateSources, pipelineKey)
 		if existingPipelineDefinition, ok := pipelineDefinitions[pipelineKey]; ok {
 			pipelineDefinitions[pipelineKey] = append(existingPipelineDefinition, *pipelineDefinition)
 		} else {
@@ -102,3 +108,11 @@ func (parser *Parser) ProcessPipelineFile(
 	}
 	return pipelineDefinitions
 }
+
+func addSource(definition *pipeline.Definition, sources map[string]pipeline.DefinitionSource, identifier string) {
+	if source, ok := sources[identifier]; ok {
+		position := source.Position
+		definition.Position = &position
+		definition.Invocations = source.Invocations
+	}
+}
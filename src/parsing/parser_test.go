@@ -57,6 +57,48 @@ private:
 	defaults, definitions, files, err := parser.ParsePipelineFiles([]string{"file1", "file2"}, false)
 	require.Nil(t, err)
 	require.Equal(t, pipeline.DefaultSettings{Command: "test-command", Dir: "test-dir"}, defaults)
+	testSource := pipeline.DefinitionSource{
+		Invocations: []pipeline.Invocation{
+			{
+				Arguments:  map[string]interface{}{"arg1": "value1"},
+				Identifier: stringPointer("test1"),
+				Position:   pipeline.SourcePosition{Column: 9, Line: 12, Path: "file1"},
+			},
+			{
+				Identifier: stringPointer("test2"),
+				Position:   pipeline.SourcePosition{Column: 9, Line: 14, Path: "file1"},
+			},
+		},
+		Position: pipeline.SourcePosition{Column: 3, Line: 9, Path: "file1"},
+	}
+	test1Source := pipeline.DefinitionSource{
+		Invocations: []pipeline.Invocation{
+			{
+				Arguments:  map[string]interface{}{"command": "test1"},
+				Identifier: stringPointer("run"),
+				Position:   pipeline.SourcePosition{Column: 9, Line: 19, Path: "file1"},
+			},
+		},
+		Position: pipeline.SourcePosition{Column: 3, Line: 17, Path: "file1"},
+	}
+	test2Source := pipeline.DefinitionSource{
+		Invocations: []pipeline.Invocation{
+			{
+				Arguments:  map[string]interface{}{"command": "test2"},
+				Identifier: stringPointer("run"),
+				Position:   pipeline.SourcePosition{Column: 9, Line: 24, Path: "file1"},
+			},
+		},
+		Position: pipeline.SourcePosition{Column: 3, Line: 22, Path: "file1"},
+	}
+	publicTestSource := pipeline.DefinitionSource{
+		Invocations: []pipeline.Invocation{},
+		Position:    pipeline.SourcePosition{Column: 3, Line: 5, Path: "file2"},
+	}
+	privateTestSource := pipeline.DefinitionSource{
+		Invocations: []pipeline.Invocation{},
+		Position:    pipeline.SourcePosition{Column: 3, Line: 9, Path: "file2"},
+	}
 	require.Equal(t, map[string][]pipeline.Definition{
 		"test": {
 			{
@@ -72,24 +114,30 @@ private:
 						"test2",
 					},
 				},
-				FileName: "file1",
-				Public:   true,
+				FileName:    "file1",
+				Invocations: testSource.Invocations,
+				Position:    &testSource.Position,
+				Public:      true,
 			},
 			{
 				BuiltIn: false,
 				DefinitionArguments: map[string]interface{}{
 					"key1": "value1",
 				},
-				FileName: "file2",
-				Public:   true,
+				FileName:    "file2",
+				Invocations: publicTestSource.Invocations,
+				Position:    &publicTestSource.Position,
+				Public:      true,
 			},
 			{
 				BuiltIn: false,
 				DefinitionArguments: map[string]interface{}{
 					"key2": "value2",
 				},
-				FileName: "file2",
-				Public:   false,
+				FileName:    "file2",
+				Invocations: privateTestSource.Invocations,
+				Position:    &privateTestSource.Position,
+				Public:      false,
 			},
 		},
 		"test1": {
@@ -104,8 +152,10 @@ private:
 						},
 					},
 				},
-				FileName: "file1",
-				Public:   false,
+				FileName:    "file1",
+				Invocations: test1Source.Invocations,
+				Position:    &test1Source.Position,
+				Public:      false,
 			},
 		},
 		"test2": {
@@ -120,8 +170,10 @@ private:
 						},
 					},
 				},
-				FileName: "file1",
-				Public:   false,
+				FileName:    "file1",
+				Invocations: test2Source.Invocations,
+				Position:    &test2Source.Position,
+				Public:      false,
 			},
 		},
 	}, definitions)
@@ -130,6 +182,13 @@ private:
 			Default:  pipeline.DefaultSettings{Command: "test-command", Dir: "test-dir"},
 			Path:     "file1",
 			FileName: "file1",
+			PublicSources: map[string]pipeline.DefinitionSource{
+				"test": testSource,
+			},
+			PrivateSources: map[string]pipeline.DefinitionSource{
+				"test1": test1Source,
+				"test2": test2Source,
+			},
 			Public: map[string]map[string]interface{}{
 				"test": {
 					"description": "Public test pipe",
@@ -166,6 +225,12 @@ private:
 			Default:  pipeline.DefaultSettings{Command: "", Dir: ""},
 			Path:     "file2",
 			FileName: "file2",
+			PublicSources: map[string]pipeline.DefinitionSource{
+				"test": publicTestSource,
+			},
+			PrivateSources: map[string]pipeline.DefinitionSource{
+				"test": privateTestSource,
+			},
 			Public: map[string]map[string]interface{}{
 				"test": {
 					"key1": "value1",
@@ -224,6 +289,38 @@ public:
 	require.Contains(t, err.Error(), "line 10")
 }
 
+func TestParser_ParsePipelineFiles_WithHooks(t *testing.T) {
+	parser := NewParser(
+		WithReadFileImplementation(func(filename string) ([]byte, error) {
+			return []byte(`
+version: 0.0.1
+
+hooks:
+  before:
+    - set-up
+  onError:
+    - notify:
+        channel: test
+
+public:
+  test:
+    shell:
+      run: echo test
+`), nil
+		}))
+
+	_, _, files, err := parser.ParsePipelineFiles([]string{"file"}, false)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(files))
+	require.Equal(t, 1, len(files[0].Hooks.Before))
+	require.Equal(t, 1, len(files[0].Hooks.OnError))
+	require.Equal(t, 0, len(files[0].Hooks.After))
+	for identifier, arguments := range files[0].Hooks.OnError[0] {
+		require.Equal(t, "notify", *identifier)
+		require.Equal(t, "test", arguments["channel"])
+	}
+}
+
 func TestParser_ProcessPipelineFile(t *testing.T) {
 	parser := NewParser()
 	pipelineFile := pipeline.File{
@@ -330,3 +427,7 @@ func TestParser_ProcessPipelineFile_WithMultipleDefinitionsInSameFile(t *testing
 		},
 	}, definitions)
 }
+
+func stringPointer(value string) *string {
+	return &value
+}
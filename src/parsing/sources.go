@@ -0,0 +1,114 @@
+package parsing
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+// recordSources stores the positions of all definitions in the parsed document, as well as the inline invocations within them
+//
+// The parser does not know which arguments are interpreted as pipe references by the middleware,
+// so all sequence items and nested mappings that look like a reference are recorded as invocations.
+// Plain identifiers used as mapping values (e.g. `catch: handler`) are not recorded, as they cannot be told apart from other strings.
+func recordSources(pipelineFile *pipeline.File, document *yaml.Node) {
+	pipelineFile.PublicSources = map[string]pipeline.DefinitionSource{}
+	pipelineFile.PrivateSources = map[string]pipeline.DefinitionSource{}
+	if len(document.Content) == 0 {
+		return
+	}
+	root := resolveAlias(document.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+	for index := 0; index+1 < len(root.Content); index += 2 {
+		sources := pipelineFile.PublicSources
+		switch root.Content[index].Value {
+		case "public":
+		case "private":
+			sources = pipelineFile.PrivateSources
+		default:
+			continue
+		}
+		definitionsNode := resolveAlias(root.Content[index+1])
+		if definitionsNode.Kind != yaml.MappingNode {
+			continue
+		}
+		for definitionIndex := 0; definitionIndex+1 < len(definitionsNode.Content); definitionIndex += 2 {
+			identifierNode := definitionsNode.Content[definitionIndex]
+			invocations := make([]pipeline.Invocation, 0, 8)
+			collectInvocations(pipelineFile.Path, definitionsNode.Content[definitionIndex+1], &invocations)
+			sources[identifierNode.Value] = pipeline.DefinitionSource{
+				Invocations: invocations,
+				Position:    sourcePosition(pipelineFile.Path, identifierNode),
+			}
+		}
+	}
+}
+
+func collectInvocations(path string, node *yaml.Node, invocations *[]pipeline.Invocation) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		collectInvocations(path, node.Alias, invocations)
+	case yaml.SequenceNode:
+		for _, itemNode := range node.Content {
+			addInvocation(path, itemNode, invocations)
+			collectInvocations(path, itemNode, invocations)
+		}
+	case yaml.MappingNode:
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			valueNode := node.Content[index+1]
+			if valueNode.Kind != yaml.ScalarNode {
+				addInvocation(path, valueNode, invocations)
+			}
+			collectInvocations(path, valueNode, invocations)
+		}
+	}
+}
+
+// addInvocation records the node as an invocation if it is either a plain identifier
+// or a mapping of a single identifier (or null, for anonymous pipes) to inline arguments
+func addInvocation(path string, node *yaml.Node, invocations *[]pipeline.Invocation) {
+	targetNode := resolveAlias(node)
+	switch {
+	case targetNode.Kind == yaml.ScalarNode && targetNode.Tag == "!!str":
+		identifier := targetNode.Value
+		*invocations = append(*invocations, pipeline.Invocation{
+			Identifier: &identifier,
+			Position:   sourcePosition(path, node),
+		})
+	case targetNode.Kind == yaml.MappingNode && len(targetNode.Content) == 2:
+		keyNode := targetNode.Content[0]
+		argumentsNode := resolveAlias(targetNode.Content[1])
+		if keyNode.Value == "<<" || (argumentsNode.Kind != yaml.MappingNode && argumentsNode.Tag != "!!null") {
+			return
+		}
+		var identifier *string = nil
+		if keyNode.Tag != "!!null" {
+			identifier = &keyNode.Value
+		}
+		var arguments map[string]interface{} = nil
+		if argumentsNode.Kind == yaml.MappingNode {
+			_ = argumentsNode.Decode(&arguments)
+		}
+		*invocations = append(*invocations, pipeline.Invocation{
+			Arguments:  arguments,
+			Identifier: identifier,
+			Position:   sourcePosition(path, keyNode),
+		})
+	}
+}
+
+func sourcePosition(path string, node *yaml.Node) pipeline.SourcePosition {
+	return pipeline.SourcePosition{
+		Column: node.Column,
+		Line:   node.Line,
+		Path:   path,
+	}
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
@@ -0,0 +1,70 @@
+package parsing
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParser_ParsePipelineFiles_recordsSources(t *testing.T) {
+	parser := NewParser(
+		WithReadFileImplementation(func(filename string) ([]byte, error) {
+			return []byte(`
+public:
+  test:
+    pipe:
+      - ~:
+          pipe:
+            - nested
+      - "quoted": &arguments
+          key: value
+      - aliased: *arguments
+      - with-null:
+    catch: handler
+private:
+  nested:
+    shell:
+      run: echo test
+`), nil
+		}))
+	_, definitions, files, err := parser.ParsePipelineFiles([]string{"test.pipe"}, false)
+	require.Nil(t, err)
+	require.Equal(t, &pipeline.SourcePosition{Column: 3, Line: 3, Path: "test.pipe"}, definitions["test"][0].Position)
+	require.Equal(t, &pipeline.SourcePosition{Column: 3, Line: 14, Path: "test.pipe"}, definitions["nested"][0].Position)
+	require.Equal(t, []pipeline.Invocation{
+		{
+			Arguments:  map[string]interface{}{"pipe": []interface{}{"nested"}},
+			Identifier: nil,
+			Position:   pipeline.SourcePosition{Column: 9, Line: 5, Path: "test.pipe"},
+		},
+		{
+			Identifier: stringPointer("nested"),
+			Position:   pipeline.SourcePosition{Column: 15, Line: 7, Path: "test.pipe"},
+		},
+		{
+			Arguments:  map[string]interface{}{"key": "value"},
+			Identifier: stringPointer("quoted"),
+			Position:   pipeline.SourcePosition{Column: 9, Line: 8, Path: "test.pipe"},
+		},
+		{
+			Arguments:  map[string]interface{}{"key": "value"},
+			Identifier: stringPointer("aliased"),
+			Position:   pipeline.SourcePosition{Column: 9, Line: 10, Path: "test.pipe"},
+		},
+		{
+			Identifier: stringPointer("with-null"),
+			Position:   pipeline.SourcePosition{Column: 9, Line: 11, Path: "test.pipe"},
+		},
+	}, definitions["test"][0].Invocations)
+	require.Equal(t, definitions["test"][0].Invocations, files[0].PublicSources["test"].Invocations)
+}
+
+func TestParser_ProcessPipelineFile_withoutSources(t *testing.T) {
+	definitions := NewParser().ProcessPipelineFile(pipeline.File{
+		Public: map[string]map[string]interface{}{
+			"test": {},
+		},
+	}, false)
+	require.Nil(t, definitions["test"][0].Position)
+	require.Nil(t, definitions["test"][0].Invocations)
+}
@@ -61,18 +61,28 @@ func ParseArguments(
 	if !ok || argument == nil {
 		return false
 	}
+	err := DecodeArguments(argument, middlewareArguments)
+	if err != nil {
+		run.Log.Error(fmt.Errorf("malformed arguments for %q: %w", middlewareIdentifier, err))
+		return false
+	}
+	return true
+}
+
+// DecodeArguments transfers an unstructured argument value into a struct, rejecting unknown keys
+//
+// Pipeline references are accepted in all their supported formats.
+func DecodeArguments(argument interface{}, result interface{}) error {
 	decoderConfig := mapstructure.DecoderConfig{
 		DecodeHook:  pipelineReferenceDecodeHook,
 		ErrorUnused: true,
-		Result:      middlewareArguments,
+		Result:      result,
 	}
-	decoder, _ := mapstructure.NewDecoder(&decoderConfig)
-	err := decoder.Decode(argument)
+	decoder, err := mapstructure.NewDecoder(&decoderConfig)
 	if err != nil {
-		run.Log.Error(fmt.Errorf("malformed arguments for %q: %w", middlewareIdentifier, err))
-		return false
+		return err
 	}
-	return true
+	return decoder.Decode(argument)
 }
 
 // ParseArgumentsIncludingParents is like ParseArguments, but will traverse through parents if no suitable has been found
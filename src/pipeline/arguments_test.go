@@ -44,6 +44,33 @@ func TestParseArguments_WithValidArguments(t *testing.T) {
 	require.Equal(t, 1, len(reference))
 }
 
+func TestDecodeArguments(t *testing.T) {
+	result := struct {
+		Pipe  Reference
+		Items []string
+	}{}
+	err := DecodeArguments(map[string]interface{}{
+		"pipe":  "test",
+		"items": []interface{}{"a", "b"},
+	}, &result)
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b"}, result.Items)
+	for identifier := range result.Pipe {
+		require.Equal(t, "test", *identifier)
+	}
+}
+
+func TestDecodeArguments_WithUnknownKey(t *testing.T) {
+	result := struct {
+		Items []string
+	}{}
+	err := DecodeArguments(map[string]interface{}{
+		"itmes": []interface{}{"a"},
+	}, &result)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "itmes")
+}
+
 func TestParsePipelineReferences_WithInvalidReference_MapWithNilKey(t *testing.T) {
 	run, _ := NewRun(nil, map[string]interface{}{
 		"test": []interface{}{
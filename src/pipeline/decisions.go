@@ -0,0 +1,52 @@
+package pipeline
+
+// ConditionResult is the outcome of a condition evaluated during a run
+type ConditionResult struct {
+	Source    string
+	Satisfied bool
+}
+
+// AddConditionResult records the outcome of a condition evaluated during the run
+func (run *Run) AddConditionResult(source string, satisfied bool) {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.conditionResults = append(run.conditionResults, ConditionResult{
+		Source:    source,
+		Satisfied: satisfied,
+	})
+}
+
+// ConditionResults returns the outcomes of all conditions evaluated during the run, in the order of evaluation
+func (run *Run) ConditionResults() []ConditionResult {
+	run.mutex.RLock()
+	defer run.mutex.RUnlock()
+	return append(make([]ConditionResult, 0, len(run.conditionResults)), run.conditionResults...)
+}
+
+// MarkSkipped records that the run's pipe was not executed, as a condition was not satisfied
+func (run *Run) MarkSkipped() {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.skipped = true
+}
+
+// Skipped indicates whether the run's pipe was not executed, as a condition was not satisfied
+func (run *Run) Skipped() bool {
+	run.mutex.RLock()
+	defer run.mutex.RUnlock()
+	return run.skipped
+}
+
+// SetCommand records the shell command executed by the run
+func (run *Run) SetCommand(command string) {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.command = &command
+}
+
+// Command returns the shell command executed by the run, if any
+func (run *Run) Command() *string {
+	run.mutex.RLock()
+	defer run.mutex.RUnlock()
+	return run.command
+}
@@ -0,0 +1,26 @@
+package pipeline
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRun_ConditionResults(t *testing.T) {
+	run, _ := NewRun(nil, nil, nil, nil)
+	require.Empty(t, run.ConditionResults())
+
+	run.AddConditionResult("@{a} == 1", true)
+	run.AddConditionResult("input == ''", false)
+	require.Equal(t, []ConditionResult{
+		{Source: "@{a} == 1", Satisfied: true},
+		{Source: "input == ''", Satisfied: false},
+	}, run.ConditionResults())
+}
+
+func TestRun_Command(t *testing.T) {
+	run, _ := NewRun(nil, nil, nil, nil)
+	require.Nil(t, run.Command())
+
+	run.SetCommand("cd dir && make")
+	require.Equal(t, "cd dir && make", *run.Command())
+}
@@ -1,8 +1,5 @@
 package pipeline
 
-// HookDefinitions are not currently used
-type HookDefinitions = map[string][]string
-
 // DefinitionsLookup maps identifiers to their definitions
 type DefinitionsLookup = map[string][]Definition
 
@@ -11,7 +8,11 @@ type Definition struct {
 	BuiltIn             bool
 	DefinitionArguments map[string]interface{}
 	FileName            string
-	Public              bool
+	// Invocations lists the pipes invoked inline within the definition, in the order they appear in the file
+	Invocations []Invocation
+	// Position is the location of the definition's identifier, if the definition was parsed from a file
+	Position *SourcePosition
+	Public   bool
 }
 
 // NewDefinition creates a new Definition
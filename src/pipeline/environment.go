@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Getenv looks up the value of an environment variable as seen by the run
+//
+// Variables set or unset for the run itself take precedence over those of its ancestors.
+// If none of them has changed the variable, the value is taken from the process environment.
+func (run *Run) Getenv(key string) (string, bool) {
+	for currentRun := run; currentRun != nil; currentRun = currentRun.Parent {
+		currentRun.environmentMutex.RLock()
+		value, changed := currentRun.environment[key]
+		currentRun.environmentMutex.RUnlock()
+		if changed {
+			if value == nil {
+				return "", false
+			}
+			return *value, true
+		}
+	}
+	return os.LookupEnv(key)
+}
+
+// Setenv sets an environment variable for the run and its descendants
+//
+// The process environment and the environment of other runs remain unchanged.
+func (run *Run) Setenv(key string, value string) {
+	run.environmentMutex.Lock()
+	defer run.environmentMutex.Unlock()
+	run.environment[key] = &value
+}
+
+// Unsetenv removes an environment variable for the run and its descendants
+func (run *Run) Unsetenv(key string) {
+	run.environmentMutex.Lock()
+	defer run.environmentMutex.Unlock()
+	run.environment[key] = nil
+}
+
+// ExpandEnv replaces $var or ${var} in the string according to the run's environment
+//
+// References to undefined variables are replaced by the empty string.
+func (run *Run) ExpandEnv(value string) string {
+	return os.Expand(value, func(key string) string {
+		result, _ := run.Getenv(key)
+		return result
+	})
+}
+
+// Environ returns the run's environment in the form "key=value", sorted by key
+func (run *Run) Environ() []string {
+	environment := make(map[string]string, 100)
+	for _, keyValuePair := range os.Environ() {
+		components := strings.SplitN(keyValuePair, "=", 2)
+		if len(components) == 2 {
+			environment[components[0]] = components[1]
+		}
+	}
+
+	// apply the changes made by the outermost ancestor first, so that they can be overridden by its descendants
+	ancestors := make([]*Run, 0, 10)
+	for currentRun := run; currentRun != nil; currentRun = currentRun.Parent {
+		ancestors = append(ancestors, currentRun)
+	}
+	for index := len(ancestors) - 1; index >= 0; index-- {
+		ancestors[index].environmentMutex.RLock()
+		for key, value := range ancestors[index].environment {
+			if value == nil {
+				delete(environment, key)
+			} else {
+				environment[key] = *value
+			}
+		}
+		ancestors[index].environmentMutex.RUnlock()
+	}
+
+	result := make([]string, 0, len(environment))
+	for key, value := range environment {
+		result = append(result, fmt.Sprintf("%v=%v", key, value))
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestPipelineRun_Getenv_InheritsFromParent(t *testing.T) {
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+	sibling, _ := NewRun(nil, nil, nil, parent)
+
+	parent.Setenv("PIPEDREAM_TEST_PARENT", "parent")
+	child.Setenv("PIPEDREAM_TEST_CHILD", "child")
+
+	value, found := child.Getenv("PIPEDREAM_TEST_PARENT")
+	require.True(t, found)
+	require.Equal(t, "parent", value)
+	value, found = child.Getenv("PIPEDREAM_TEST_CHILD")
+	require.True(t, found)
+	require.Equal(t, "child", value)
+
+	_, found = sibling.Getenv("PIPEDREAM_TEST_CHILD")
+	require.False(t, found)
+	_, found = parent.Getenv("PIPEDREAM_TEST_CHILD")
+	require.False(t, found)
+	_, found = os.LookupEnv("PIPEDREAM_TEST_CHILD")
+	require.False(t, found)
+}
+
+func TestPipelineRun_Getenv_FallsBackToProcessEnvironment(t *testing.T) {
+	require.Nil(t, os.Setenv("PIPEDREAM_TEST_PROCESS", "process"))
+	defer func() { _ = os.Unsetenv("PIPEDREAM_TEST_PROCESS") }()
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+
+	value, found := child.Getenv("PIPEDREAM_TEST_PROCESS")
+	require.True(t, found)
+	require.Equal(t, "process", value)
+
+	child.Setenv("PIPEDREAM_TEST_PROCESS", "overridden")
+	value, _ = child.Getenv("PIPEDREAM_TEST_PROCESS")
+	require.Equal(t, "overridden", value)
+	value, _ = parent.Getenv("PIPEDREAM_TEST_PROCESS")
+	require.Equal(t, "process", value)
+	require.Equal(t, "process", os.Getenv("PIPEDREAM_TEST_PROCESS"))
+}
+
+func TestPipelineRun_Unsetenv(t *testing.T) {
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+	grandchild, _ := NewRun(nil, nil, nil, child)
+
+	parent.Setenv("PIPEDREAM_TEST", "value")
+	child.Unsetenv("PIPEDREAM_TEST")
+
+	_, found := grandchild.Getenv("PIPEDREAM_TEST")
+	require.False(t, found)
+	require.NotContains(t, grandchild.Environ(), "PIPEDREAM_TEST=value")
+
+	grandchild.Setenv("PIPEDREAM_TEST", "restored")
+	value, found := grandchild.Getenv("PIPEDREAM_TEST")
+	require.True(t, found)
+	require.Equal(t, "restored", value)
+}
+
+func TestPipelineRun_ExpandEnv(t *testing.T) {
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+
+	parent.Setenv("PIPEDREAM_TEST_A", "a")
+	child.Setenv("PIPEDREAM_TEST_B", "b")
+
+	require.Equal(t, "a b  c", child.ExpandEnv("$PIPEDREAM_TEST_A ${PIPEDREAM_TEST_B} $PIPEDREAM_TEST_MISSING c"))
+	require.Equal(t, "a ", parent.ExpandEnv("$PIPEDREAM_TEST_A $PIPEDREAM_TEST_B"))
+}
+
+func TestPipelineRun_Environ(t *testing.T) {
+	require.Nil(t, os.Setenv("PIPEDREAM_TEST_PROCESS", "process"))
+	defer func() { _ = os.Unsetenv("PIPEDREAM_TEST_PROCESS") }()
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+
+	parent.Setenv("PIPEDREAM_TEST_A", "parent")
+	child.Setenv("PIPEDREAM_TEST_A", "child")
+	child.Setenv("PIPEDREAM_TEST_B", "b=c")
+
+	environment := child.Environ()
+	require.Contains(t, environment, "PIPEDREAM_TEST_PROCESS=process")
+	require.Contains(t, environment, "PIPEDREAM_TEST_A=child")
+	require.Contains(t, environment, "PIPEDREAM_TEST_B=b=c")
+	require.NotContains(t, environment, "PIPEDREAM_TEST_A=parent")
+	require.Contains(t, parent.Environ(), "PIPEDREAM_TEST_A=parent")
+}
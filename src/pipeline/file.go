@@ -15,6 +15,9 @@ type File struct {
 	// or a dictionary containing additional parameters
 	Public  map[string]map[string]interface{}
 	Private map[string]map[string]interface{}
+	// PublicSources and PrivateSources record the locations of the definitions in the file, by identifier
+	PublicSources  map[string]DefinitionSource `yaml:"-"`
+	PrivateSources map[string]DefinitionSource `yaml:"-"`
 }
 
 // FileImportSkeleton is a very basic representation of a yaml pipeline file concerned only with import declarations
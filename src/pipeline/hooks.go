@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
+)
+
+// HookDefinitions reference pipes that are executed around the pipe selected for execution
+type HookDefinitions struct {
+	// Before hooks are executed in sequence before the selected pipe
+	//
+	// If any of them fails, the selected pipe will not be executed.
+	Before []Reference
+	// After hooks are always executed after the selected pipe, even if it failed or was cancelled
+	After []Reference
+	// OnError hooks are executed after the selected pipe if it failed
+	//
+	// The failure details are passed as the `error`, `exitCode` and `failedPipe` arguments.
+	OnError []Reference
+	// OnCancel hooks are executed after the selected pipe if the execution was cancelled by the user
+	OnCancel []Reference
+}
+
+// UnmarshalYAML decodes hook definitions, accepting the same pipeline reference formats as middleware arguments
+func (hooks *HookDefinitions) UnmarshalYAML(value *yaml.Node) error {
+	rawHooks := make(map[string]interface{}, 4)
+	err := value.Decode(&rawHooks)
+	if err != nil {
+		return err
+	}
+	decoderConfig := mapstructure.DecoderConfig{
+		DecodeHook:  pipelineReferenceDecodeHook,
+		ErrorUnused: true,
+		Result:      hooks,
+	}
+	decoder, _ := mapstructure.NewDecoder(&decoderConfig)
+	err = decoder.Decode(rawHooks)
+	if err != nil {
+		return fmt.Errorf("malformed hooks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestHookDefinitions_UnmarshalYAML(t *testing.T) {
+	file := File{}
+	err := yaml.Unmarshal([]byte(`
+hooks:
+  before:
+    - set-up
+  after:
+    - tear-down:
+        arg: value
+  onError:
+    - ~:
+        shell:
+          run: echo "failed"
+`), &file)
+	require.Nil(t, err)
+	setUp := "set-up"
+	tearDown := "tear-down"
+	require.Equal(t, 1, len(file.Hooks.Before))
+	require.Equal(t, map[string]interface{}{}, file.Hooks.Before[0][getKey(file.Hooks.Before[0])])
+	require.Equal(t, setUp, *getKey(file.Hooks.Before[0]))
+	require.Equal(t, tearDown, *getKey(file.Hooks.After[0]))
+	require.Equal(t, map[string]interface{}{"arg": "value"}, file.Hooks.After[0][getKey(file.Hooks.After[0])])
+	require.Nil(t, getKey(file.Hooks.OnError[0]))
+	require.Equal(t, 0, len(file.Hooks.OnCancel))
+}
+
+func TestHookDefinitions_UnmarshalYAML_Malformed(t *testing.T) {
+	file := File{}
+	err := yaml.Unmarshal([]byte(`
+hooks:
+  beforeAll:
+    - set-up
+`), &file)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "malformed hooks")
+
+	err = yaml.Unmarshal([]byte(`
+hooks:
+  - set-up
+`), &file)
+	require.NotNil(t, err)
+}
+
+func getKey(reference Reference) *string {
+	for key := range reference {
+		return key
+	}
+	return nil
+}
@@ -41,6 +41,7 @@ type Logger struct {
 	closed       bool
 
 	ErrorCallback func(error)
+	errorsHandled bool
 
 	unreadBuffer []byte
 }
@@ -144,6 +145,22 @@ func (logger *Logger) Level() logrus.Level {
 	return logger.baseLogger.Level
 }
 
+// SetErrorsHandled sets whether errors are handled elsewhere, e.g. by retrying the run
+//
+// Handled errors and stderr output are logged as warnings. The setting is passed on to the loggers of child runs.
+func (logger *Logger) SetErrorsHandled(handled bool) {
+	logger.logMutex.Lock()
+	defer logger.logMutex.Unlock()
+	logger.errorsHandled = handled
+}
+
+// ErrorsHandled indicates whether errors are handled elsewhere and logged as warnings
+func (logger *Logger) ErrorsHandled() bool {
+	logger.logMutex.RLock()
+	defer logger.logMutex.RUnlock()
+	return logger.errorsHandled
+}
+
 // AddReaderEntry adds an entry that will write the entire contents of the provided reader before proceeding to the next entry
 func (logger *Logger) AddReaderEntry(reader io.Reader) {
 	logger.logMutex.Lock()
@@ -185,17 +202,21 @@ func (logger *Logger) PossibleErrorWithExplanation(err error, explanation string
 func (logger *Logger) StderrOutput(message string, logFields ...fields.LogEntryField) {
 	logger.logMutex.Lock()
 	defer logger.logMutex.Unlock()
-	logger.logCountError++
-	logger.errors = multierror.Append(logger.errors, fmt.Errorf("stderr: %v", message))
+	if logger.errorsHandled {
+		logger.logCountWarning++
+	} else {
+		logger.logCountError++
+		logger.errors = multierror.Append(logger.errors, fmt.Errorf("stderr: %v", message))
+	}
 	logEntry := logrus.WithFields(logrus.Fields{
 		"prefix":  "⛔️ ",
 		"message": message,
 	})
-	logFields = append(logFields, fields.Run(logger.run))
+	logFields = append(logFields, logger.runFields()...)
 	for _, withField := range logFields {
 		logEntry = withField(logEntry)
 	}
-	logEntry.Level = logrus.ErrorLevel
+	logEntry.Level = logger.errorLevel()
 	logger.logEntries.PushBack(logEntry)
 }
 
@@ -203,25 +224,49 @@ func (logger *Logger) StderrOutput(message string, logFields ...fields.LogEntryF
 func (logger *Logger) Error(err error, logFields ...fields.LogEntryField) {
 	logger.logMutex.Lock()
 	defer logger.logMutex.Unlock()
-	logger.logCountError++
-	logger.errors = multierror.Append(logger.errors, err)
+	if logger.errorsHandled {
+		logger.logCountWarning++
+	} else {
+		logger.logCountError++
+		logger.errors = multierror.Append(logger.errors, err)
+	}
 	logEntry := logrus.WithFields(logrus.Fields{
 		"prefix":  "🛑 ",
 		"message": err.Error(),
 	})
-	logFields = append(logFields, fields.Run(logger.run))
+	logFields = append(logFields, logger.runFields()...)
 	for _, withField := range logFields {
 		logEntry = withField(logEntry)
 	}
-	logEntry.Level = logrus.ErrorLevel
+	logEntry.Level = logger.errorLevel()
 	logger.logEntries.PushBack(logEntry)
-	if logger.ErrorCallback != nil {
-		if logger.run == nil || logger.run.Identifier == nil {
-			logger.ErrorCallback(fmt.Errorf("%v:\n%w", "anonymous", err))
-		} else {
-			logger.ErrorCallback(fmt.Errorf("%v:\n%w", *logger.run.Identifier, err))
+	if logger.ErrorCallback != nil && !logger.errorsHandled {
+		name := "anonymous"
+		if logger.run != nil && logger.run.Identifier != nil {
+			name = *logger.run.Identifier
 		}
+		if position := logger.run.SourcePosition(); position != nil {
+			name = fmt.Sprintf("%v (%v)", name, position)
+		}
+		logger.ErrorCallback(fmt.Errorf("%v:\n%w", name, err))
+	}
+}
+
+// errorLevel is the level of error log entries, which is lowered to a warning if errors are handled elsewhere
+func (logger *Logger) errorLevel() logrus.Level {
+	if logger.errorsHandled {
+		return logrus.WarnLevel
+	}
+	return logrus.ErrorLevel
+}
+
+// runFields adds information about the logger's run to log entries, including its location in the pipeline files
+func (logger *Logger) runFields() []fields.LogEntryField {
+	result := []fields.LogEntryField{fields.Run(logger.run)}
+	if position := logger.run.SourcePosition(); position != nil {
+		result = append(result, fields.Position(position.String()))
 	}
+	return result
 }
 
 // Warn adds an appropriate entry for an encountered warning
@@ -229,7 +274,7 @@ func (logger *Logger) Warn(logFields ...fields.LogEntryField) {
 	logger.logMutex.Lock()
 	defer logger.logMutex.Unlock()
 	logger.logCountWarning++
-	logFields = append(logFields, fields.Run(logger.run))
+	logFields = append(logFields, logger.runFields()...)
 	entry := fields.EntryWithFields(logFields...)
 	entry.Level = logrus.WarnLevel
 	logger.logEntries.PushBack(entry)
@@ -240,7 +285,7 @@ func (logger *Logger) Info(logFields ...fields.LogEntryField) {
 	logger.logMutex.Lock()
 	defer logger.logMutex.Unlock()
 	logger.logCountInfo++
-	logFields = append(logFields, fields.Run(logger.run))
+	logFields = append(logFields, logger.runFields()...)
 	entry := fields.EntryWithFields(logFields...)
 	entry.Level = logrus.InfoLevel
 	logger.logEntries.PushBack(entry)
@@ -251,7 +296,7 @@ func (logger *Logger) Debug(logFields ...fields.LogEntryField) {
 	logger.logMutex.Lock()
 	defer logger.logMutex.Unlock()
 	logger.logCountDebug++
-	logFields = append(logFields, fields.Run(logger.run))
+	logFields = append(logFields, logger.runFields()...)
 	entry := fields.EntryWithFields(logFields...)
 	entry.Level = logrus.DebugLevel
 	logger.logEntries.PushBack(entry)
@@ -262,7 +307,7 @@ func (logger *Logger) Trace(logFields ...fields.LogEntryField) {
 	logger.logMutex.Lock()
 	defer logger.logMutex.Unlock()
 	logger.logCountTrace++
-	logFields = append(logFields, fields.Run(logger.run))
+	logFields = append(logFields, logger.runFields()...)
 	entry := fields.EntryWithFields(logFields...)
 	entry.Level = logrus.TraceLevel
 	logger.logEntries.PushBack(entry)
@@ -326,6 +371,9 @@ func (logger *Logger) LastError() error {
 func (logger *Logger) AllErrorMessages() []string {
 	logger.logMutex.RLock()
 	defer logger.logMutex.RUnlock()
+	if logger.errors == nil {
+		return []string{}
+	}
 	result := make([]string, 0, logger.errors.Len())
 	for _, err := range logger.errors.WrappedErrors() {
 		result = append(result, err.Error())
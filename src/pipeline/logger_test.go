@@ -362,6 +362,27 @@ func TestLogger_Error_WithCallback_namedPipe(t *testing.T) {
 	require.Equal(t, "test:\ntest error", errMessage)
 }
 
+func TestLogger_ErrorsHandled(t *testing.T) {
+	parent, _ := NewRun(nil, nil, nil, nil)
+	parent.Log.SetErrorsHandled(true)
+	run, _ := NewRun(nil, nil, nil, parent)
+	require.True(t, run.Log.ErrorsHandled())
+	callbackCalled := false
+	run.Log.ErrorCallback = func(err error) {
+		callbackCalled = true
+	}
+	run.Log.Error(fmt.Errorf("test error"))
+	run.Log.StderrOutput("test stderr output")
+	require.False(t, callbackCalled)
+	require.Equal(t, 0, run.Log.ErrorCount())
+	require.Equal(t, 2, run.Log.WarnCount())
+	require.Nil(t, run.Log.LastError())
+	run.Log.Close()
+	logs := run.Log.String()
+	require.Contains(t, logs, "test error")
+	require.Contains(t, logs, "test stderr output")
+}
+
 func TestLogger_AddWriteCloserEntry(t *testing.T) {
 	logger := NewLogger(nil, 0)
 	writeCloser := logger.AddWriteCloserEntry()
@@ -387,3 +408,20 @@ func TestLogger_AddWriteCloserEntry(t *testing.T) {
 
 	require.Equal(t, "test", result)
 }
+
+func TestLogger_Error_WithCallback_sourcePosition(t *testing.T) {
+	identifier := "test"
+	run, _ := NewRun(&identifier, nil, &Definition{Position: &SourcePosition{Column: 3, Line: 5, Path: "test.pipe"}}, nil)
+	run.InvocationPosition = &SourcePosition{Column: 7, Line: 42, Path: "other.pipe"}
+	logger := NewLogger(run, 0)
+	errMessage := ""
+	logger.ErrorCallback = func(err error) {
+		errMessage = err.Error()
+	}
+	logger.Error(fmt.Errorf("test error"))
+	require.Equal(t, "test (other.pipe:42:7):\ntest error", errMessage)
+	buffer := make([]byte, 1024)
+	count, err := logger.Read(buffer)
+	require.Nil(t, err)
+	require.Contains(t, string(buffer[:count]), "test error (other.pipe:42:7)")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/logrusorgru/aurora/v3"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Run contains everything needed to actually execute the invocation of a pipe
@@ -49,6 +50,8 @@ type Run struct {
 	Definition *Definition
 	// InvocationArguments are passed to the pipe at the time of invocation / run creation
 	InvocationArguments map[string]interface{}
+	// InvocationPosition is the location of the inline invocation that the run was created from, if any
+	InvocationPosition *SourcePosition
 
 	argumentsMutex *sync.RWMutex
 
@@ -77,9 +80,11 @@ type Run struct {
 	Parent *Run
 
 	started        bool
+	startTime      time.Time
 	startWaitGroup *sync.WaitGroup
 
 	completed           bool
+	completionTime      time.Time
 	completionWaitGroup *sync.WaitGroup
 
 	// used internally to signal completion of all execution functions,
@@ -89,8 +94,26 @@ type Run struct {
 
 	mutex *sync.RWMutex
 
-	cancelled   bool
-	cancelHooks []func() error
+	cancelled          bool
+	cancellationSignal chan struct{}
+	cancelHooks        []func() error
+
+	// parallelismLimit restricts the number of shell commands executed simultaneously by the run and its descendants
+	parallelismLimit *Semaphore
+
+	// workingDirectory is the absolute path of the directory in which the run's commands are executed, if set explicitly
+	workingDirectory string
+
+	// environment contains the environment variables set (or unset, if nil) for this run and its descendants
+	environment      map[string]*string
+	environmentMutex *sync.RWMutex
+
+	// command is the shell command executed by the run, if any
+	command *string
+	// conditionResults are the outcomes of the conditions evaluated during the run
+	conditionResults []ConditionResult
+	// skipped indicates that the run's pipe was not executed, as a condition was not satisfied
+	skipped bool
 }
 
 // NewRun creates a new Run with the specified identifier, invocation arguments, definition and parent
@@ -112,10 +135,11 @@ func NewRun(
 
 	randomUUID, _ := uuid.GenerateUUID()
 	run := &Run{
-		arguments:  arguments,
-		Definition: definition,
-		Identifier: identifier,
-		Id:         randomUUID,
+		arguments:           arguments,
+		Definition:          definition,
+		Identifier:          identifier,
+		Id:                  randomUUID,
+		InvocationArguments: stringmap.CopyMap(invocationArguments),
 
 		ExitCode: nil,
 
@@ -131,10 +155,14 @@ func NewRun(
 
 		executionWaitGroup: &sync.WaitGroup{},
 
-		cancelled:   false,
-		cancelHooks: make([]func() error, 0, 10),
+		cancelled:          false,
+		cancellationSignal: make(chan struct{}),
+		cancelHooks:        make([]func() error, 0, 10),
 
 		mutex: &sync.RWMutex{},
+
+		environment:      make(map[string]*string, 10),
+		environmentMutex: &sync.RWMutex{},
 	}
 
 	if parent == nil {
@@ -142,6 +170,7 @@ func NewRun(
 	} else {
 		run.Log = NewLogger(run, parent.Log.Indentation+2)
 		run.Log.SetLevel(parent.Log.Level())
+		run.Log.SetErrorsHandled(parent.Log.ErrorsHandled())
 	}
 
 	// the run has not yet started nor completed
@@ -168,6 +197,7 @@ func (run *Run) Start() {
 		return
 	}
 	run.started = true
+	run.startTime = time.Now()
 	run.startWaitGroup.Done()
 	run.mutex.Unlock()
 
@@ -212,6 +242,7 @@ func (run *Run) complete() {
 	}
 
 	run.completed = true
+	run.completionTime = time.Now()
 
 	run.Log.Info(
 		fields.Symbol("✔"),
@@ -236,6 +267,39 @@ func (run *Run) Completed() bool {
 	return run.completed
 }
 
+// StartTime is the time at which the run was started
+//
+// The zero value indicates that the run has not yet started.
+func (run *Run) StartTime() time.Time {
+	run.mutex.RLock()
+	defer run.mutex.RUnlock()
+	return run.startTime
+}
+
+// CompletionTime is the time at which the run completed or was cancelled
+//
+// The zero value indicates that the run has not yet completed.
+func (run *Run) CompletionTime() time.Time {
+	run.mutex.RLock()
+	defer run.mutex.RUnlock()
+	return run.completionTime
+}
+
+// Duration is the time elapsed between the start and completion of the run
+//
+// If the run has not yet completed, Duration is the time elapsed since it was started.
+func (run *Run) Duration() time.Duration {
+	run.mutex.RLock()
+	defer run.mutex.RUnlock()
+	if run.startTime.IsZero() {
+		return 0
+	}
+	if run.completionTime.IsZero() {
+		return time.Since(run.startTime)
+	}
+	return run.completionTime.Sub(run.startTime)
+}
+
 // Name returns the run's identifier or "anonymous", if the identifier is nil
 func (run *Run) Name() string {
 	run.mutex.RLock()
@@ -295,6 +359,22 @@ func (run *Run) string() string {
 	return strings.Join(components, "  ")
 }
 
+// SourcePosition is the location in a pipeline file that is most relevant for the run, if known
+//
+// This is the position of the run's inline invocation or, failing that, of its definition.
+func (run *Run) SourcePosition() *SourcePosition {
+	if run == nil {
+		return nil
+	}
+	if run.InvocationPosition != nil {
+		return run.InvocationPosition
+	}
+	if run.Definition != nil {
+		return run.Definition.Position
+	}
+	return nil
+}
+
 func (run *Run) GraphLabel() string {
 	displayString := run.DisplayString()
 	run.mutex.RLock()
@@ -314,6 +394,18 @@ func (run *Run) GraphLabel() string {
 	return fmt.Sprintf("🔜 %v", displayString)
 }
 
+// GraphTooltip describes where the run's invocation and definition are located in the pipeline files
+func (run *Run) GraphTooltip() string {
+	lines := make([]string, 0, 2)
+	if run.InvocationPosition != nil {
+		lines = append(lines, fmt.Sprintf("invoked at %v", run.InvocationPosition))
+	}
+	if run.Definition != nil && run.Definition.Position != nil {
+		lines = append(lines, fmt.Sprintf("defined at %v", run.Definition.Position))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (run *Run) GraphGroup() string {
 	run.mutex.RLock()
 	defer run.mutex.RUnlock()
@@ -448,14 +540,20 @@ func (run *Run) Cancel() error {
 		err = multierror.Append(err, fmt.Errorf("cancelling a run that has not yet started"))
 	}
 
+	if !run.cancelled {
+		close(run.cancellationSignal)
+	}
 	run.cancelled = true
+	cancelHooks := run.cancelHooks
 	run.mutex.Unlock()
-	run.complete()
 
-	for _, cancelHook := range run.cancelHooks {
+	// execute the hooks before completing, as completion requires any data streams fed by e.g. shell commands to stop
+	for _, cancelHook := range cancelHooks {
 		err = multierror.Append(err, cancelHook())
 	}
 
+	run.complete()
+
 	return err.ErrorOrNil()
 }
 
@@ -466,6 +564,11 @@ func (run *Run) Cancelled() bool {
 	return run.cancelled
 }
 
+// CancellationSignal returns a channel that is closed when the run is cancelled
+func (run *Run) CancellationSignal() <-chan struct{} {
+	return run.cancellationSignal
+}
+
 func (run *Run) DontCompleteBefore(executionFunction func()) {
 	run.mutex.RLock()
 	defer run.mutex.RUnlock()
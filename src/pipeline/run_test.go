@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPipelineRun_AppendToStdout(t *testing.T) {
@@ -275,6 +276,29 @@ func TestPipelineRun_GraphLabel(t *testing.T) {
 	require.Equal(t, "✘ Test", run.GraphLabel())
 }
 
+func TestPipelineRun_GraphTooltip(t *testing.T) {
+	runIdentifier := "test"
+	run, _ := NewRun(&runIdentifier, nil, nil, nil)
+	require.Equal(t, "", run.GraphTooltip())
+	run, _ = NewRun(&runIdentifier, nil, &Definition{Position: &SourcePosition{Column: 3, Line: 5, Path: "test.pipe"}}, nil)
+	require.Equal(t, "defined at test.pipe:5:3", run.GraphTooltip())
+	run.InvocationPosition = &SourcePosition{Column: 7, Line: 42, Path: "other.pipe"}
+	require.Equal(t, "invoked at other.pipe:42:7\ndefined at test.pipe:5:3", run.GraphTooltip())
+}
+
+func TestPipelineRun_SourcePosition(t *testing.T) {
+	var nilRun *Run = nil
+	require.Nil(t, nilRun.SourcePosition())
+	run, _ := NewRun(nil, nil, nil, nil)
+	require.Nil(t, run.SourcePosition())
+	definitionPosition := &SourcePosition{Column: 3, Line: 5, Path: "test.pipe"}
+	run, _ = NewRun(nil, nil, &Definition{Position: definitionPosition}, nil)
+	require.Equal(t, definitionPosition, run.SourcePosition())
+	invocationPosition := &SourcePosition{Column: 7, Line: 42, Path: "other.pipe"}
+	run.InvocationPosition = invocationPosition
+	require.Equal(t, invocationPosition, run.SourcePosition())
+}
+
 func TestPipelineRun_GraphGroup(t *testing.T) {
 	runIdentifier := "test"
 	run, _ := NewRun(&runIdentifier, nil, nil, nil)
@@ -298,3 +322,20 @@ func TestPipelineRun_Name(t *testing.T) {
 	namedRun, _ := NewRun(&runIdentifier, nil, nil, nil)
 	require.Equal(t, "test", namedRun.Name())
 }
+
+func TestPipelineRun_Timing(t *testing.T) {
+	run, _ := NewRun(nil, nil, nil, nil)
+	require.True(t, run.StartTime().IsZero())
+	require.True(t, run.CompletionTime().IsZero())
+	require.Equal(t, time.Duration(0), run.Duration())
+
+	run.DontCompleteBefore(func() {
+		time.Sleep(10 * time.Millisecond)
+	})
+	run.Start()
+	require.False(t, run.StartTime().IsZero())
+	run.Wait()
+	require.False(t, run.CompletionTime().IsZero())
+	require.GreaterOrEqual(t, int64(run.Duration()), int64(10*time.Millisecond))
+	require.Equal(t, run.CompletionTime().Sub(run.StartTime()), run.Duration())
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+// Semaphore limits the number of shell commands that may execute simultaneously
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates a new Semaphore with the specified number of slots
+func NewSemaphore(size int) *Semaphore {
+	return &Semaphore{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// Size returns the number of slots
+func (semaphore *Semaphore) Size() int {
+	return cap(semaphore.slots)
+}
+
+// Acquire blocks until a slot becomes available or abort is closed
+//
+// The returned bool indicates whether a slot has been acquired.
+func (semaphore *Semaphore) Acquire(abort <-chan struct{}) bool {
+	select {
+	case semaphore.slots <- struct{}{}:
+		return true
+	case <-abort:
+		return false
+	}
+}
+
+// Release frees a previously acquired slot
+func (semaphore *Semaphore) Release() {
+	<-semaphore.slots
+}
+
+// AcquireAll acquires a slot from each of the semaphores in turn
+//
+// To prevent deadlocks, semaphores must always be acquired in the same order.
+// If abort is closed before all slots have been acquired, those already acquired are released again.
+// The returned function releases all acquired slots.
+func AcquireAll(semaphores []*Semaphore, abort <-chan struct{}) (func(), bool) {
+	release := func(acquired []*Semaphore) {
+		for index := len(acquired) - 1; index >= 0; index-- {
+			acquired[index].Release()
+		}
+	}
+	for index, semaphore := range semaphores {
+		if !semaphore.Acquire(abort) {
+			release(semaphores[:index])
+			return func() {}, false
+		}
+	}
+	return func() {
+		release(semaphores)
+	}, true
+}
+
+// LimitParallelism restricts the number of shell commands that the run and its descendants may execute simultaneously
+//
+// A limit of zero or less removes the restriction.
+func (run *Run) LimitParallelism(limit int) {
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	if limit > 0 {
+		run.parallelismLimit = NewSemaphore(limit)
+	} else {
+		run.parallelismLimit = nil
+	}
+}
+
+// ParallelismLimits returns the semaphores limiting the run's parallelism, starting with the outermost ancestor's
+func (run *Run) ParallelismLimits() []*Semaphore {
+	result := make([]*Semaphore, 0, 2)
+	for currentRun := run; currentRun != nil; currentRun = currentRun.Parent {
+		currentRun.mutex.RLock()
+		limit := currentRun.parallelismLimit
+		currentRun.mutex.RUnlock()
+		if limit != nil {
+			result = append([]*Semaphore{limit}, result...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package pipeline
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSemaphore_AcquireAndRelease(t *testing.T) {
+	semaphore := NewSemaphore(2)
+	require.Equal(t, 2, semaphore.Size())
+	abort := make(chan struct{})
+
+	require.True(t, semaphore.Acquire(abort))
+	require.True(t, semaphore.Acquire(abort))
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- semaphore.Acquire(abort)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired more slots than available")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	semaphore.Release()
+	require.True(t, <-acquired)
+}
+
+func TestSemaphore_Abort(t *testing.T) {
+	semaphore := NewSemaphore(1)
+	abort := make(chan struct{})
+	require.True(t, semaphore.Acquire(abort))
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- semaphore.Acquire(abort)
+	}()
+	close(abort)
+	require.False(t, <-acquired)
+}
+
+func TestSemaphore_AcquireAll(t *testing.T) {
+	first := NewSemaphore(1)
+	second := NewSemaphore(1)
+	abort := make(chan struct{})
+
+	release, acquired := AcquireAll([]*Semaphore{first, second}, abort)
+	require.True(t, acquired)
+	require.Len(t, first.slots, 1)
+	require.Len(t, second.slots, 1)
+
+	release()
+	require.Len(t, first.slots, 0)
+	require.Len(t, second.slots, 0)
+}
+
+func TestSemaphore_AcquireAll_AbortReleasesAcquiredSlots(t *testing.T) {
+	first := NewSemaphore(1)
+	second := NewSemaphore(1)
+	second.slots <- struct{}{}
+	abort := make(chan struct{})
+
+	result := make(chan bool)
+	go func() {
+		_, acquired := AcquireAll([]*Semaphore{first, second}, abort)
+		result <- acquired
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(abort)
+
+	require.False(t, <-result)
+	require.Len(t, first.slots, 0)
+}
+
+func TestPipelineRun_ParallelismLimits(t *testing.T) {
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+	grandchild, _ := NewRun(nil, nil, nil, child)
+
+	require.Empty(t, grandchild.ParallelismLimits())
+
+	parent.LimitParallelism(4)
+	grandchild.LimitParallelism(2)
+	limits := grandchild.ParallelismLimits()
+	require.Len(t, limits, 2)
+	require.Equal(t, 4, limits[0].Size())
+	require.Equal(t, 2, limits[1].Size())
+	require.Len(t, child.ParallelismLimits(), 1)
+
+	parent.LimitParallelism(0)
+	require.Len(t, grandchild.ParallelismLimits(), 1)
+}
+
+func TestPipelineRun_CancellationSignal(t *testing.T) {
+	run, _ := NewRun(nil, nil, nil, nil)
+	run.Start()
+	select {
+	case <-run.CancellationSignal():
+		t.Fatal("cancellation signal received before cancellation")
+	default:
+	}
+	_ = run.Cancel()
+	_ = run.Cancel()
+	<-run.CancellationSignal()
+	run.Wait()
+}
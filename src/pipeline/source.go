@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SourcePosition is the location of a pipe definition or invocation in a pipeline file
+type SourcePosition struct {
+	Column int
+	Line   int
+	Path   string
+}
+
+// String formats the position as `path:line:column`
+func (position SourcePosition) String() string {
+	return fmt.Sprintf("%v:%v:%v", position.Path, position.Line, position.Column)
+}
+
+// Invocation is an invocation of a pipe written inline within a definition, e.g. as an item of a `pipe` list
+type Invocation struct {
+	// Arguments are the inline arguments as written in the pipeline file, before any interpolation
+	Arguments map[string]interface{}
+	// Identifier is the invoked pipe's identifier (nil for anonymous pipes)
+	Identifier *string
+	Position   SourcePosition
+}
+
+// DefinitionSource records where a definition is located in its pipeline file, as well as the invocations within it
+type DefinitionSource struct {
+	Invocations []Invocation
+	Position    SourcePosition
+}
+
+// FindInvocation finds the inline invocation within the definition that a run was most likely created from
+//
+// Invocations with the same identifier and arguments are preferred over ones that merely share the identifier,
+// as the arguments may have been changed (e.g. interpolated) before the invocation.
+func (definition *Definition) FindInvocation(identifier *string, arguments map[string]interface{}) *Invocation {
+	var firstMatch *Invocation = nil
+	for index, invocation := range definition.Invocations {
+		if !sameIdentifier(invocation.Identifier, identifier) {
+			continue
+		}
+		if sameArguments(invocation.Arguments, arguments) {
+			return &definition.Invocations[index]
+		}
+		if firstMatch == nil {
+			firstMatch = &definition.Invocations[index]
+		}
+	}
+	return firstMatch
+}
+
+func sameIdentifier(identifier1 *string, identifier2 *string) bool {
+	if identifier1 == nil || identifier2 == nil {
+		return identifier1 == identifier2
+	}
+	return *identifier1 == *identifier2
+}
+
+func sameArguments(arguments1 map[string]interface{}, arguments2 map[string]interface{}) bool {
+	if len(arguments1) == 0 || len(arguments2) == 0 {
+		return len(arguments1) == len(arguments2)
+	}
+	return reflect.DeepEqual(arguments1, arguments2)
+}
@@ -0,0 +1,29 @@
+package pipeline
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSourcePosition_String(t *testing.T) {
+	require.Equal(t, "test.pipe:42:7", SourcePosition{Column: 7, Line: 42, Path: "test.pipe"}.String())
+}
+
+func TestDefinition_FindInvocation(t *testing.T) {
+	identifier := "test"
+	otherIdentifier := "other"
+	definition := Definition{
+		Invocations: []Invocation{
+			{Identifier: &identifier, Position: SourcePosition{Line: 1}},
+			{Arguments: map[string]interface{}{"key": "value"}, Identifier: &identifier, Position: SourcePosition{Line: 2}},
+			{Arguments: map[string]interface{}{"key": "value"}, Identifier: nil, Position: SourcePosition{Line: 3}},
+		},
+	}
+	require.Equal(t, 1, definition.FindInvocation(&identifier, nil).Position.Line)
+	require.Equal(t, 1, definition.FindInvocation(&identifier, map[string]interface{}{}).Position.Line)
+	require.Equal(t, 2, definition.FindInvocation(&identifier, map[string]interface{}{"key": "value"}).Position.Line)
+	// arguments may have been changed, e.g. by interpolation
+	require.Equal(t, 1, definition.FindInvocation(&identifier, map[string]interface{}{"key": "interpolated"}).Position.Line)
+	require.Equal(t, 3, definition.FindInvocation(nil, nil).Position.Line)
+	require.Nil(t, definition.FindInvocation(&otherIdentifier, nil))
+}
@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SetWorkingDirectory sets the directory in which the run and its descendants execute their commands
+//
+// Relative paths are resolved against the working directory of the run's parent.
+func (run *Run) SetWorkingDirectory(directory string) {
+	if !filepath.IsAbs(directory) {
+		parentDirectory := ""
+		if run.Parent != nil {
+			parentDirectory = run.Parent.WorkingDirectory()
+		} else {
+			parentDirectory, _ = os.Getwd()
+		}
+		directory = filepath.Join(parentDirectory, directory)
+	}
+	run.mutex.Lock()
+	defer run.mutex.Unlock()
+	run.workingDirectory = filepath.Clean(directory)
+}
+
+// WorkingDirectory returns the directory in which the run executes its commands
+//
+// Unless set explicitly, this is inherited from the run's parent or, failing that,
+// the current working directory of the pipedream process.
+func (run *Run) WorkingDirectory() string {
+	for currentRun := run; currentRun != nil; currentRun = currentRun.Parent {
+		currentRun.mutex.RLock()
+		directory := currentRun.workingDirectory
+		currentRun.mutex.RUnlock()
+		if directory != "" {
+			return directory
+		}
+	}
+	directory, _ := os.Getwd()
+	return directory
+}
+
+// ResolvePath resolves a relative path against the run's working directory
+//
+// Absolute paths are returned unchanged.
+func (run *Run) ResolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(run.WorkingDirectory(), path)
+}
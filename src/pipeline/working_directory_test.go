@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestPipelineRun_WorkingDirectory_DefaultsToCurrentDirectory(t *testing.T) {
+	currentDirectory, _ := os.Getwd()
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+
+	require.Equal(t, currentDirectory, parent.WorkingDirectory())
+	require.Equal(t, currentDirectory, child.WorkingDirectory())
+}
+
+func TestPipelineRun_WorkingDirectory_InheritsFromParent(t *testing.T) {
+	parent, _ := NewRun(nil, nil, nil, nil)
+	child, _ := NewRun(nil, nil, nil, parent)
+	grandchild, _ := NewRun(nil, nil, nil, child)
+	sibling, _ := NewRun(nil, nil, nil, parent)
+
+	parent.SetWorkingDirectory("/test")
+	child.SetWorkingDirectory("sub/../relative")
+	sibling.SetWorkingDirectory("/other")
+
+	require.Equal(t, "/test", parent.WorkingDirectory())
+	require.Equal(t, "/test/relative", child.WorkingDirectory())
+	require.Equal(t, "/test/relative", grandchild.WorkingDirectory())
+	require.Equal(t, "/other", sibling.WorkingDirectory())
+}
+
+func TestPipelineRun_WorkingDirectory_RelativeToCurrentDirectory(t *testing.T) {
+	currentDirectory, _ := os.Getwd()
+	run, _ := NewRun(nil, nil, nil, nil)
+
+	run.SetWorkingDirectory("relative")
+
+	require.Equal(t, currentDirectory+"/relative", run.WorkingDirectory())
+}
+
+func TestPipelineRun_ResolvePath(t *testing.T) {
+	run, _ := NewRun(nil, nil, nil, nil)
+	run.SetWorkingDirectory("/test")
+
+	require.Equal(t, "/test/file.yml", run.ResolvePath("file.yml"))
+	require.Equal(t, "/file.yml", run.ResolvePath("../file.yml"))
+	require.Equal(t, "/absolute/file.yml", run.ResolvePath("/absolute/file.yml"))
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName   xml.Name         `xml:"testsuites"`
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      float64          `xml:"time,attr"`
+	TestSuite []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+// JUnit converts the report into JUnit XML format
+//
+// Each root run becomes a test suite, each run within its tree a test case.
+func (report *Report) JUnit() ([]byte, error) {
+	testSuites := junitTestSuites{
+		Name:      "pipedream",
+		TestSuite: make([]junitTestSuite, 0, len(report.Runs)),
+	}
+	for _, rootRun := range report.Runs {
+		testSuite := junitTestSuite{
+			Name:      rootRun.Name,
+			Time:      rootRun.Duration,
+			TestCases: make([]junitTestCase, 0, 16),
+		}
+		if !rootRun.StartTime.IsZero() {
+			testSuite.Timestamp = rootRun.StartTime.Format("2006-01-02T15:04:05")
+		}
+		addJUnitTestCases(&testSuite, rootRun, nil)
+		testSuites.Tests += testSuite.Tests
+		testSuites.Failures += testSuite.Failures
+		testSuites.Skipped += testSuite.Skipped
+		testSuites.Time += testSuite.Time
+		testSuites.TestSuite = append(testSuites.TestSuite, testSuite)
+	}
+	result, err := xml.MarshalIndent(testSuites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), result...), nil
+}
+
+func addJUnitTestCases(testSuite *junitTestSuite, run *Run, path []string) {
+	path = append(path, run.Name)
+	className := run.FileName
+	if className == "" {
+		className = "anonymous"
+	}
+	testCase := junitTestCase{
+		Name:      strings.Join(path, " > "),
+		ClassName: className,
+		Time:      run.Duration,
+	}
+	testSuite.Tests++
+	if run.Cancelled {
+		testSuite.Skipped++
+		testCase.Skipped = &junitMessage{Message: "cancelled"}
+	} else if run.Failed() {
+		testSuite.Failures++
+		message := "errors logged"
+		if run.ExitCode != nil && *run.ExitCode != 0 {
+			message = fmt.Sprintf("exit code %v", *run.ExitCode)
+		}
+		testCase.Failure = &junitMessage{
+			Message: message,
+			Content: strings.Join(run.Errors, "\n"),
+		}
+	}
+	testSuite.TestCases = append(testSuite.TestCases, testCase)
+	for _, child := range run.Children {
+		addJUnitTestCases(testSuite, child, path)
+	}
+}
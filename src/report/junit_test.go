@@ -0,0 +1,47 @@
+package report
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReport_JUnit(t *testing.T) {
+	rootIdentifier := "root"
+	exitCode := 1
+	report := &Report{
+		Runs: []*Run{
+			{
+				Identifier: &rootIdentifier,
+				Name:       "Root",
+				FileName:   "test.pipe",
+				Duration:   1.5,
+				Children: []*Run{
+					{
+						Name:     "Failing",
+						ExitCode: &exitCode,
+						Errors:   []string{"test error"},
+						Duration: 0.5,
+					},
+					{
+						Name:      "Cancelled",
+						Cancelled: true,
+					},
+				},
+			},
+		},
+	}
+	result, err := report.JUnit()
+	require.Nil(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="pipedream" tests="3" failures="1" skipped="1" time="1.5">
+  <testsuite name="Root" tests="3" failures="1" skipped="1" time="1.5">
+    <testcase name="Root" classname="test.pipe" time="1.5"></testcase>
+    <testcase name="Root &gt; Failing" classname="anonymous" time="0.5">
+      <failure message="exit code 1">test error</failure>
+    </testcase>
+    <testcase name="Root &gt; Cancelled" classname="anonymous" time="0">
+      <skipped message="cancelled"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>`, string(result))
+}
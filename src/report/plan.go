@@ -0,0 +1,70 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WritePlan writes the runs as a human-readable tree, including the commands they executed and the conditions they evaluated
+//
+// After a dry run, this shows what the execution would have done.
+func (report *Report) WritePlan(writer io.Writer) error {
+	buffer := new(bytes.Buffer)
+	for _, run := range report.Runs {
+		buffer.WriteString(run.Name)
+		buffer.WriteString("\n")
+		writePlanItems(buffer, run, "")
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+// planItem is either a single line describing the run or one of its children
+type planItem struct {
+	line  string
+	child *Run
+}
+
+func writePlanItems(buffer *bytes.Buffer, run *Run, prefix string) {
+	items := make([]planItem, 0, len(run.Conditions)+len(run.Errors)+len(run.Children)+1)
+	for _, condition := range run.Conditions {
+		if condition.Satisfied {
+			items = append(items, planItem{line: fmt.Sprintf("✔ %v", condition.Source)})
+		} else {
+			items = append(items, planItem{line: fmt.Sprintf("✘ %v (not satisfied)", condition.Source)})
+		}
+	}
+	for _, err := range run.Errors {
+		items = append(items, planItem{line: fmt.Sprintf("🛑 %v", firstLine(err))})
+	}
+	if run.Command != nil {
+		items = append(items, planItem{line: fmt.Sprintf("$ %v", *run.Command)})
+	}
+	for _, child := range run.Children {
+		items = append(items, planItem{child: child})
+	}
+	for index, item := range items {
+		connector, childPrefix := "├── ", prefix+"│   "
+		if index == len(items)-1 {
+			connector, childPrefix = "└── ", prefix+"    "
+		}
+		if item.child == nil {
+			// continuation lines of multi-line commands are aligned with the first line
+			buffer.WriteString(prefix + connector + strings.ReplaceAll(item.line, "\n", "\n"+childPrefix+"  "))
+			buffer.WriteString("\n")
+			continue
+		}
+		buffer.WriteString(prefix + connector + item.child.Name)
+		buffer.WriteString("\n")
+		writePlanItems(buffer, item.child, childPrefix)
+	}
+}
+
+func firstLine(value string) string {
+	if newlineIndex := strings.Index(value, "\n"); newlineIndex >= 0 {
+		return value[:newlineIndex] + " …"
+	}
+	return value
+}
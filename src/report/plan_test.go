@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bytes"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReport_WritePlan(t *testing.T) {
+	buildCommand := "cd src && go build\n  -o bin/app ."
+	tagCommand := "git tag v1"
+	report := &Report{
+		Runs: []*Run{
+			{
+				Name: "Release",
+				Children: []*Run{
+					{
+						Name:    "Build",
+						Command: &buildCommand,
+					},
+					{
+						Name: "Publish",
+						Conditions: []Condition{
+							{Source: "version != ''", Satisfied: true},
+							{Source: "env.CI == 'true'", Satisfied: false},
+						},
+						Errors: []string{"first line\nsecond line"},
+						Children: []*Run{
+							{
+								Name:    "Tag",
+								Command: &tagCommand,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	buffer := new(bytes.Buffer)
+	require.Nil(t, report.WritePlan(buffer))
+	require.Equal(t, `Release
+├── Build
+│   └── $ cd src && go build
+│           -o bin/app .
+└── Publish
+    ├── ✔ version != ''
+    ├── ✘ env.CI == 'true' (not satisfied)
+    ├── 🛑 first line …
+    └── Tag
+        └── $ git tag v1
+`, buffer.String())
+}
+
+func TestReport_NewReport_CommandsAndConditions(t *testing.T) {
+	childIdentifier := "child"
+	var executionContext *middleware.ExecutionContext
+	executionContext = middleware.NewExecutionContext(
+		middleware.WithExecutionFunction(func(run *pipeline.Run) {
+			if run.Parent == nil {
+				executionContext.FullRun(
+					middleware.WithParentRun(run),
+					middleware.WithIdentifier(&childIdentifier),
+				)
+			}
+		}),
+	)
+	rootIdentifier := "root"
+	rootRun := executionContext.FullRun(middleware.WithIdentifier(&rootIdentifier))
+	rootRun.Wait()
+	childRun := executionContext.Runs()[1]
+	childRun.Wait()
+	childRun.SetCommand("make")
+	childRun.AddConditionResult("a == b", false)
+
+	report := NewReport(executionContext)
+	require.Nil(t, report.Runs[0].Command)
+	require.Nil(t, report.Runs[0].Conditions)
+	require.Equal(t, "make", *report.Runs[0].Children[0].Command)
+	require.Equal(t, []Condition{{Source: "a == b", Satisfied: false}}, report.Runs[0].Children[0].Conditions)
+}
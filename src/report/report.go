@@ -0,0 +1,135 @@
+// Package report provides machine-readable summaries of an execution, suitable for CI systems
+package report
+
+import (
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"time"
+)
+
+// Report summarizes an entire execution, including all runs and the data connections between them
+type Report struct {
+	ExitCode    int          `json:"exitCode"`
+	Errors      []string     `json:"errors"`
+	Runs        []*Run       `json:"runs"`
+	Connections []Connection `json:"connections"`
+}
+
+// Run summarizes a single pipeline run and its descendants
+type Run struct {
+	Id          string      `json:"id"`
+	Identifier  *string     `json:"identifier"`
+	Name        string      `json:"name"`
+	FileName    string      `json:"fileName,omitempty"`
+	BuiltIn     bool        `json:"builtIn"`
+	ParentId    *string     `json:"parentId"`
+	ExitCode    *int        `json:"exitCode"`
+	Cancelled   bool        `json:"cancelled"`
+	Killed      bool        `json:"killed"`
+	StdinBytes  int         `json:"stdinBytes"`
+	StdoutBytes int         `json:"stdoutBytes"`
+	StderrBytes int         `json:"stderrBytes"`
+	Warnings    int         `json:"warnings"`
+	Errors      []string    `json:"errors"`
+	Command     *string     `json:"command,omitempty"`
+	Conditions  []Condition `json:"conditions,omitempty"`
+	StartTime   time.Time   `json:"startTime"`
+	EndTime     time.Time   `json:"endTime"`
+	Duration    float64     `json:"durationSeconds"`
+	Children    []*Run      `json:"children"`
+}
+
+// Condition is the outcome of a condition evaluated during a run
+type Condition struct {
+	Source    string `json:"source"`
+	Satisfied bool   `json:"satisfied"`
+}
+
+// Connection describes the flow of data from one run to another
+type Connection struct {
+	Source string  `json:"source"`
+	Target string  `json:"target"`
+	Label  *string `json:"label"`
+}
+
+// NewReport creates a Report from the runs and connections recorded by the execution context
+//
+// Runs are arranged as a tree, with each run listed among the children of its parent.
+func NewReport(executionContext *middleware.ExecutionContext) *Report {
+	errors := executionContext.Errors()
+	report := &Report{
+		ExitCode:    executionContext.ExitCode(),
+		Errors:      make([]string, 0, len(errors)),
+		Runs:        make([]*Run, 0, 1),
+		Connections: make([]Connection, 0, 16),
+	}
+	for _, err := range errors {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	killedRuns := make(map[*pipeline.Run]bool, 4)
+	for _, run := range executionContext.KilledRuns() {
+		killedRuns[run] = true
+	}
+
+	runReports := make(map[*pipeline.Run]*Run, 16)
+	// runs are always recorded after their parents, so a single pass suffices
+	for _, run := range executionContext.Runs() {
+		runReport := newRunReport(run)
+		runReport.Killed = killedRuns[run]
+		runReports[run] = runReport
+		if parentReport, haveParentReport := runReports[run.Parent]; haveParentReport && run.Parent != nil {
+			parentReport.Children = append(parentReport.Children, runReport)
+		} else {
+			report.Runs = append(report.Runs, runReport)
+		}
+	}
+
+	for _, connection := range executionContext.Connections() {
+		report.Connections = append(report.Connections, Connection{
+			Source: connection.Source.Id,
+			Target: connection.Target.Id,
+			Label:  connection.Label,
+		})
+	}
+	return report
+}
+
+func newRunReport(run *pipeline.Run) *Run {
+	runReport := &Run{
+		Id:          run.Id,
+		Identifier:  run.Identifier,
+		Name:        run.DisplayString(),
+		ExitCode:    run.ExitCode,
+		Cancelled:   run.Cancelled(),
+		StdinBytes:  run.Stdin.Len(),
+		StdoutBytes: run.Stdout.Len(),
+		StderrBytes: run.Stderr.Len(),
+		Warnings:    run.Log.WarnCount(),
+		Errors:      run.Log.AllErrorMessages(),
+		Command:     run.Command(),
+		StartTime:   run.StartTime(),
+		EndTime:     run.CompletionTime(),
+		Duration:    run.Duration().Seconds(),
+		Children:    make([]*Run, 0, 4),
+	}
+	if run.Definition != nil {
+		runReport.FileName = run.Definition.FileName
+		runReport.BuiltIn = run.Definition.BuiltIn
+	}
+	if run.Parent != nil {
+		runReport.ParentId = &run.Parent.Id
+	}
+	for _, conditionResult := range run.ConditionResults() {
+		runReport.Conditions = append(runReport.Conditions, Condition{
+			Source:    conditionResult.Source,
+			Satisfied: conditionResult.Satisfied,
+		})
+	}
+	return runReport
+}
+
+// Failed indicates whether the run logged any errors or its shell command exited with a non-zero exit code
+func (run *Run) Failed() bool {
+	return len(run.Errors) > 0 || (run.ExitCode != nil && *run.ExitCode != 0)
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestReport_NewReport(t *testing.T) {
+	childIdentifier := "child"
+	var executionContext *middleware.ExecutionContext
+	executionContext = middleware.NewExecutionContext(
+		middleware.WithDefinitionsLookup(pipeline.DefinitionsLookup{
+			"child": {
+				{
+					DefinitionArguments: map[string]interface{}{},
+					FileName:            "test.pipe",
+				},
+			},
+		}),
+		middleware.WithExecutionFunction(func(run *pipeline.Run) {
+			if run.Parent == nil {
+				executionContext.FullRun(
+					middleware.WithParentRun(run),
+					middleware.WithIdentifier(&childIdentifier),
+					middleware.WithSetupFunc(func(childRun *pipeline.Run) {
+						childRun.Stdout.Replace(strings.NewReader("output"))
+					}),
+					middleware.WithTearDownFunc(func(childRun *pipeline.Run) {
+						exitCode := 2
+						childRun.ExitCode = &exitCode
+						childRun.Log.Error(fmt.Errorf("test error"))
+						executionContext.AddConnection(run, childRun, "test")
+					}),
+				)
+			}
+		}),
+	)
+	rootIdentifier := "root"
+	rootRun := executionContext.FullRun(middleware.WithIdentifier(&rootIdentifier))
+	rootRun.Wait()
+	childRun := executionContext.Runs()[1]
+	childRun.Wait()
+	executionContext.AddKilledRun(childRun)
+
+	report := NewReport(executionContext)
+	require.Equal(t, 1, report.ExitCode)
+	require.Equal(t, []string{"child:\ntest error"}, report.Errors)
+	require.Equal(t, 1, len(report.Runs))
+
+	rootReport := report.Runs[0]
+	require.Equal(t, rootRun.Id, rootReport.Id)
+	require.Equal(t, "root", *rootReport.Identifier)
+	require.Equal(t, "Root", rootReport.Name)
+	require.Nil(t, rootReport.ParentId)
+	require.Nil(t, rootReport.ExitCode)
+	require.False(t, rootReport.Failed())
+	require.False(t, rootReport.Killed)
+	require.Equal(t, 1, len(rootReport.Children))
+
+	childReport := rootReport.Children[0]
+	require.Equal(t, childRun.Id, childReport.Id)
+	require.Equal(t, rootRun.Id, *childReport.ParentId)
+	require.Equal(t, "test.pipe", childReport.FileName)
+	require.Equal(t, 2, *childReport.ExitCode)
+	require.Equal(t, 6, childReport.StdoutBytes)
+	require.Equal(t, []string{"test error"}, childReport.Errors)
+	require.False(t, childReport.StartTime.IsZero())
+	require.False(t, childReport.EndTime.IsZero())
+	require.True(t, childReport.Failed())
+	require.True(t, childReport.Killed)
+
+	require.Equal(t, []Connection{{Source: rootRun.Id, Target: childRun.Id, Label: executionContext.Connections()[0].Label}}, report.Connections)
+}
+
+func TestReport_NewReport_Empty(t *testing.T) {
+	report := NewReport(middleware.NewExecutionContext())
+	require.Equal(t, 0, report.ExitCode)
+	require.Equal(t, []string{}, report.Errors)
+	require.Equal(t, []*Run{}, report.Runs)
+	require.Equal(t, []Connection{}, report.Connections)
+}
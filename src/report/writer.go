@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Target specifies the format of a report and the path of the file it should be written to
+type Target struct {
+	Format string
+	Path   string
+}
+
+// ParseTargets parses report targets of the form `format=path`, as passed to the `--report` flag
+func ParseTargets(values []string) ([]Target, error) {
+	targets := make([]Target, 0, len(values))
+	for _, value := range values {
+		formatAndPath := strings.SplitN(value, "=", 2)
+		if len(formatAndPath) != 2 || formatAndPath[1] == "" {
+			return nil, fmt.Errorf("invalid report %q, expected format `format=path`", value)
+		}
+		switch formatAndPath[0] {
+		case "json", "junit":
+		default:
+			return nil, fmt.Errorf("unknown report format %q, expected `json` or `junit`", formatAndPath[0])
+		}
+		targets = append(targets, Target{
+			Format: formatAndPath[0],
+			Path:   formatAndPath[1],
+		})
+	}
+	return targets, nil
+}
+
+// Writer writes reports to disk
+type Writer struct {
+	WriteFile func(fileName string, data []byte, perm os.FileMode) error
+}
+
+// NewWriter creates a new Writer
+func NewWriter() *Writer {
+	return &Writer{
+		WriteFile: ioutil.WriteFile,
+	}
+}
+
+// Write creates a report for the execution context and writes it to each of the targets
+func (writer *Writer) Write(executionContext *middleware.ExecutionContext, targets []Target) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	report := NewReport(executionContext)
+	for _, target := range targets {
+		var data []byte
+		var err error
+		switch target.Format {
+		case "json":
+			data, err = json.MarshalIndent(report, "", "  ")
+		case "junit":
+			data, err = report.JUnit()
+		default:
+			err = fmt.Errorf("unknown report format %q", target.Format)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create %v report: %w", target.Format, err)
+		}
+		err = writer.WriteFile(target.Path, data, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write %v report: %w", target.Format, err)
+		}
+	}
+	return nil
+}
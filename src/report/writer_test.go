@@ -0,0 +1,90 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReport_ParseTargets(t *testing.T) {
+	targets, err := ParseTargets([]string{"json=report.json", "junit=out/report.xml"})
+	require.Nil(t, err)
+	require.Equal(t, []Target{
+		{Format: "json", Path: "report.json"},
+		{Format: "junit", Path: "out/report.xml"},
+	}, targets)
+}
+
+func TestReport_ParseTargets_Invalid(t *testing.T) {
+	_, err := ParseTargets([]string{"report.json"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "expected format `format=path`")
+
+	_, err = ParseTargets([]string{"xml=report.xml"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unknown report format \"xml\"")
+}
+
+func TestReport_Writer_Write(t *testing.T) {
+	childIdentifier := "child"
+	var executionContext *middleware.ExecutionContext
+	executionContext = middleware.NewExecutionContext(
+		middleware.WithExecutionFunction(func(run *pipeline.Run) {
+			if run.Parent == nil {
+				executionContext.FullRun(
+					middleware.WithParentRun(run),
+					middleware.WithIdentifier(&childIdentifier),
+				)
+			}
+		}),
+	)
+	rootIdentifier := "root"
+	executionContext.FullRun(middleware.WithIdentifier(&rootIdentifier)).Wait()
+	executionContext.Runs()[1].Wait()
+
+	writtenFiles := make(map[string][]byte, 2)
+	writer := NewWriter()
+	writer.WriteFile = func(fileName string, data []byte, _ os.FileMode) error {
+		writtenFiles[fileName] = data
+		return nil
+	}
+	err := writer.Write(executionContext, []Target{
+		{Format: "json", Path: "report.json"},
+		{Format: "junit", Path: "report.xml"},
+	})
+	require.Nil(t, err)
+
+	jsonReport := Report{}
+	require.Nil(t, json.Unmarshal(writtenFiles["report.json"], &jsonReport))
+	require.Equal(t, "root", *jsonReport.Runs[0].Identifier)
+	require.Equal(t, "child", *jsonReport.Runs[0].Children[0].Identifier)
+	require.True(t, strings.HasPrefix(string(writtenFiles["report.xml"]), "<?xml"))
+}
+
+func TestReport_Writer_Write_NoTargets(t *testing.T) {
+	writer := NewWriter()
+	writer.WriteFile = func(fileName string, data []byte, _ os.FileMode) error {
+		t.Errorf("unexpected write")
+		return nil
+	}
+	require.Nil(t, writer.Write(middleware.NewExecutionContext(), nil))
+}
+
+func TestReport_Writer_Write_Error(t *testing.T) {
+	writer := NewWriter()
+	writer.WriteFile = func(fileName string, data []byte, _ os.FileMode) error {
+		return fmt.Errorf("test error")
+	}
+	err := writer.Write(middleware.NewExecutionContext(), []Target{{Format: "json", Path: "report.json"}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "test error")
+
+	err = writer.Write(middleware.NewExecutionContext(), []Target{{Format: "xml", Path: "report.xml"}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unknown report format")
+}
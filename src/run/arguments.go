@@ -0,0 +1,43 @@
+package run
+
+import (
+	"fmt"
+	"github.com/Layer9Berlin/pipedream/src/custom/stringmap"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"strings"
+)
+
+var readFile = ioutil.ReadFile
+
+// parseInvocationArguments combines the values of the `--args-file` and `--arg` flags into a map of arguments
+//
+// Values provided via `--arg` take precedence over those in the arguments file.
+// Keys may contain dots to specify a path into nested maps.
+func parseInvocationArguments(argumentFlags []string, argumentsFile string) (map[string]interface{}, error) {
+	arguments := make(map[string]interface{}, len(argumentFlags))
+	if argumentsFile != "" {
+		fileData, err := readFile(argumentsFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read arguments file %q: %w", argumentsFile, err)
+		}
+		err = yaml.Unmarshal(fileData, &arguments)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse arguments file %q: %w", argumentsFile, err)
+		}
+		if arguments == nil {
+			arguments = make(map[string]interface{}, len(argumentFlags))
+		}
+	}
+	for _, argumentFlag := range argumentFlags {
+		keyAndValue := strings.SplitN(argumentFlag, "=", 2)
+		if len(keyAndValue) != 2 || keyAndValue[0] == "" {
+			return nil, fmt.Errorf("invalid argument %q, expected format `key=value`", argumentFlag)
+		}
+		err := stringmap.SetValueInMap(arguments, keyAndValue[1], strings.Split(keyAndValue[0], ".")...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to set argument %q: %w", keyAndValue[0], err)
+		}
+	}
+	return arguments, nil
+}
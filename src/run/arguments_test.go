@@ -0,0 +1,87 @@
+package run
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestArguments_parseInvocationArguments(t *testing.T) {
+	arguments, err := parseInvocationArguments([]string{
+		"key=value",
+		"nested.key=nested=value",
+		"empty=",
+	}, "")
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"key": "value",
+		"nested": map[string]interface{}{
+			"key": "nested=value",
+		},
+		"empty": "",
+	}, arguments)
+}
+
+func TestArguments_parseInvocationArguments_withFile(t *testing.T) {
+	oldReadFile := readFile
+	defer func() {
+		readFile = oldReadFile
+	}()
+	readFile = func(fileName string) ([]byte, error) {
+		require.Equal(t, "args.yaml", fileName)
+		return []byte(`
+key: file value
+count: 3
+nested:
+  key: file value
+  other: other value
+`), nil
+	}
+	arguments, err := parseInvocationArguments([]string{
+		"nested.key=flag value",
+	}, "args.yaml")
+	require.Nil(t, err)
+	require.Equal(t, map[string]interface{}{
+		"key":   "file value",
+		"count": 3,
+		"nested": map[string]interface{}{
+			"key":   "flag value",
+			"other": "other value",
+		},
+	}, arguments)
+}
+
+func TestArguments_parseInvocationArguments_invalidFormat(t *testing.T) {
+	_, err := parseInvocationArguments([]string{"key"}, "")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "expected format `key=value`")
+
+	_, err = parseInvocationArguments([]string{"=value"}, "")
+	require.NotNil(t, err)
+}
+
+func TestArguments_parseInvocationArguments_invalidPath(t *testing.T) {
+	_, err := parseInvocationArguments([]string{"key=value", "key.nested=value"}, "")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unable to set argument \"key.nested\"")
+}
+
+func TestArguments_parseInvocationArguments_fileErrors(t *testing.T) {
+	oldReadFile := readFile
+	defer func() {
+		readFile = oldReadFile
+	}()
+	readFile = func(fileName string) ([]byte, error) {
+		return nil, fmt.Errorf("test error")
+	}
+	_, err := parseInvocationArguments(nil, "args.yaml")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "test error")
+
+	readFile = func(fileName string) ([]byte, error) {
+		return []byte("- not\n- a map"), nil
+	}
+	_, err = parseInvocationArguments(nil, "args.yaml")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unable to parse arguments file")
+}
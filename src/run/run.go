@@ -2,9 +2,11 @@
 package run
 
 import (
+	"fmt"
 	"github.com/Layer9Berlin/pipedream/src/graph"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/middleware/stack"
+	"github.com/Layer9Berlin/pipedream/src/report"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"io"
@@ -28,6 +30,26 @@ var PipelineFlag string
 // ShowGraphFlag is a toggle indicating whether a graph should be opened in the browser after execution
 var ShowGraphFlag bool
 
+// ArgumentFlags are `key=value` pairs passed to the selected pipe as invocation arguments
+//
+// Keys may contain dots to specify a path into nested maps.
+var ArgumentFlags []string
+
+// ArgumentsFileFlag is the path to a yaml file containing invocation arguments for the selected pipe
+var ArgumentsFileFlag string
+
+// ReportFlags are report targets of the form `format=path`, e.g. `json=report.json` or `junit=report.xml`
+var ReportFlags []string
+
+// MaxParallelFlag limits the number of shell commands executed simultaneously (zero means unlimited)
+var MaxParallelFlag int
+
+// NoCacheFlag prevents cached results from being used, forcing all pipes to execute
+var NoCacheFlag bool
+
+// DryRunFlag causes shell commands to be recorded instead of executed, printing the resulting plan
+var DryRunFlag bool
+
 // FileFlag sets the file to be executed, skipping the user selection prompt
 var FileFlag string
 
@@ -35,38 +57,135 @@ var executionContextFactory = middleware.NewExecutionContext
 var osStdin io.ReadCloser = os.Stdin
 var osStdout io.WriteCloser = os.Stdout
 var osStderr io.WriteCloser = os.Stderr
+var osExit = os.Exit
+var stdinIsTerminal = defaultStdinIsTerminal
 
 var graphWriter = graph.NewWriter()
+var reportWriter = report.NewWriter()
+var planWriter = writePlan
 
 // Cmd executes the main command, selecting and running a pipeline within an execution context
 func Cmd(_ *cobra.Command, _ []string) {
+	execute()
+}
+
+// RunCmd executes the `run` subcommand, running a pipeline without user interaction
+//
+// The optional positional arguments are the pipeline file and the identifier of the pipe to execute.
+// If stdin is not a terminal, no selection prompt will be shown.
+// The process exits with a non-zero exit code if the execution failed.
+func RunCmd(_ *cobra.Command, args []string) {
+	if len(args) > 0 {
+		FileFlag = args[0]
+	}
+	if len(args) > 1 {
+		PipelineFlag = args[1]
+	}
+	options := make([]middleware.ExecutionContextOption, 0, 1)
+	if !stdinIsTerminal() {
+		options = append(options, middleware.WithUserPromptImplementation(nonInteractiveUserPrompt))
+	}
+	exitCode := execute(options...)
+	if exitCode != 0 {
+		osExit(exitCode)
+	}
+}
+
+func execute(options ...middleware.ExecutionContextOption) int {
 	executableLocation, _ := os.Executable()
 	executableDir := path.Dir(executableLocation)
 	projectPath, _ := filepath.EvalSymlinks(executableDir)
+	middlewareStack := stack.SetUpMiddleware()
+	if DryRunFlag {
+		middlewareStack = stack.SetUpDryRunMiddleware()
+	}
 	executionContext := executionContextFactory(
-		middleware.WithMiddlewareStack(stack.SetUpMiddleware()),
-		middleware.WithProjectPath(projectPath),
-		middleware.WithLogger(Log),
+		append([]middleware.ExecutionContextOption{
+			middleware.WithMiddlewareStack(middlewareStack),
+			middleware.WithProjectPath(projectPath),
+			middleware.WithLogger(Log),
+			middleware.WithMaxParallel(MaxParallelFlag),
+			middleware.WithCacheDisabled(NoCacheFlag),
+			middleware.WithDryRun(DryRunFlag),
+		}, options...)...,
 	)
-	err := executionContext.SetUpPipelines(FileFlag)
+	invocationArguments, err := parseInvocationArguments(ArgumentFlags, ArgumentsFileFlag)
+	if err != nil {
+		executionContext.Log.Error(err)
+		return 1
+	}
+	executionContext.RootArguments = invocationArguments
+
+	reportTargets, err := report.ParseTargets(ReportFlags)
+	if err != nil {
+		executionContext.Log.Error(err)
+		return 1
+	}
+
+	err = executionContext.SetUpPipelines(FileFlag)
 	if err != nil {
 		executionContext.Log.Error(err)
-		return
+		return 1
 	}
 
 	pipelineIdentifier, fileName, err := letUserSelectPipelineFileAndPipeline(executionContext, 10, osStdin, osStdout)
 	if err != nil {
 		executionContext.Log.Error(err)
-		return
+		return 1
 	}
 	executionContext.RootFileName = fileName
 
 	executionContext.Execute(pipelineIdentifier, osStdout, osStderr)
 
+	if DryRunFlag {
+		err := planWriter(executionContext, osStdout)
+		if err != nil {
+			executionContext.Log.Error(err)
+			return 1
+		}
+	}
+
 	if ShowGraphFlag {
 		err := graphWriter.Write(executionContext)
 		if err != nil {
 			panic(err)
 		}
 	}
+
+	exitCode := executionContext.ExitCode()
+	err = reportWriter.Write(executionContext, reportTargets)
+	if err != nil {
+		executionContext.Log.Error(err)
+		if exitCode == 0 {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func writePlan(executionContext *middleware.ExecutionContext, writer io.Writer) error {
+	_, err := fmt.Fprintln(writer, "===== PLAN =====")
+	if err != nil {
+		return err
+	}
+	return report.NewReport(executionContext).WritePlan(writer)
+}
+
+func defaultStdinIsTerminal() bool {
+	fileInfo, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+func nonInteractiveUserPrompt(
+	label string,
+	_ []string,
+	_ int,
+	_ int,
+	_ io.ReadCloser,
+	_ io.WriteCloser,
+) (int, string, error) {
+	return 0, "", fmt.Errorf("unable to show prompt %q, stdin is not a terminal (please specify the file and pipe explicitly)", label)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/Layer9Berlin/pipedream/src/graph"
 	"github.com/Layer9Berlin/pipedream/src/middleware"
 	"github.com/Layer9Berlin/pipedream/src/parsing"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
 	"github.com/stretchr/testify/require"
 	"io"
 	"io/ioutil"
@@ -123,6 +124,63 @@ public:
 	require.Equal(t, "", buffer.String())
 }
 
+func TestRun_Cmd_withDryRunFlag(t *testing.T) {
+	DryRunFlag = true
+	oldStdout := osStdout
+	oldExecutionContextFactory := executionContextFactory
+	defer func() {
+		DryRunFlag = false
+		osStdout = oldStdout
+		executionContextFactory = oldExecutionContextFactory
+	}()
+	reader, writer := io.Pipe()
+	osStdout = writer
+	buffer := new(bytes.Buffer)
+	result := make([]byte, 0, 1024)
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(1)
+	go func() {
+		result, _ = ioutil.ReadAll(reader)
+		waitGroup.Done()
+	}()
+	executionContextFactory = func(options ...middleware.ExecutionContextOption) *middleware.ExecutionContext {
+		options = append(options, middleware.WithParser(
+			parsing.NewParser(
+				parsing.WithFindByGlobImplementation(func(_ string) ([]string, error) {
+					return []string{"test1.pipe"}, nil
+				}),
+				parsing.WithReadFileImplementation(func(_ string) ([]byte, error) {
+					return []byte(`
+public:
+  test:
+    shell:
+      dir: build
+      run: exit 3
+`), nil
+				}),
+				parsing.WithRecursivelyAddImportsImplementation(func(paths []string) ([]string, error) {
+					return []string{"test1.pipe"}, nil
+				}),
+			)))
+		executionContext := middleware.NewExecutionContext(options...)
+		require.True(t, executionContext.DryRun)
+		executionContext.Log.SetOutput(buffer)
+		return executionContext
+	}
+
+	FileFlag = "test1.pipe"
+	defer func() {
+		FileFlag = ""
+	}()
+	exitCode := execute()
+
+	_ = writer.Close()
+	waitGroup.Wait()
+	require.Equal(t, 0, exitCode)
+	require.Contains(t, string(result), "===== PLAN =====\nTest\n└── $ cd build && exit 3\n")
+	require.Equal(t, "", buffer.String())
+}
+
 func TestRun_Cmd_withGraphFlag(t *testing.T) {
 	ShowGraphFlag = true
 	previousGraphWriter := graphWriter
@@ -168,3 +226,110 @@ public:
 
 	Cmd(nil, []string{"test1.pipe"})
 }
+
+func TestRun_RunCmd_nonInteractive(t *testing.T) {
+	oldExecutionContextFactory := executionContextFactory
+	oldStdinIsTerminal := stdinIsTerminal
+	oldOsExit := osExit
+	defer func() {
+		executionContextFactory = oldExecutionContextFactory
+		stdinIsTerminal = oldStdinIsTerminal
+		osExit = oldOsExit
+		FileFlag = ""
+		PipelineFlag = ""
+	}()
+	buffer := new(bytes.Buffer)
+	stdinIsTerminal = func() bool {
+		return false
+	}
+	exitCode := 0
+	osExit = func(code int) {
+		exitCode = code
+	}
+	executionContextFactory = func(options ...middleware.ExecutionContextOption) *middleware.ExecutionContext {
+		options = append(options, middleware.WithParser(
+			parsing.NewParser(
+				parsing.WithFindByGlobImplementation(func(_ string) ([]string, error) {
+					return []string{"test1.pipe"}, nil
+				}),
+				parsing.WithReadFileImplementation(func(_ string) ([]byte, error) {
+					return []byte(`
+public:
+  test1:
+    arg: value
+  test2:
+    arg: value
+`), nil
+				}),
+				parsing.WithRecursivelyAddImportsImplementation(func(paths []string) ([]string, error) {
+					return paths, nil
+				}),
+			)))
+		executionContext := middleware.NewExecutionContext(options...)
+		executionContext.Log.SetOutput(buffer)
+		return executionContext
+	}
+
+	RunCmd(nil, []string{"test1.pipe"})
+
+	require.Equal(t, "test1.pipe", FileFlag)
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, buffer.String(), "stdin is not a terminal")
+}
+
+func TestRun_RunCmd_exitCode(t *testing.T) {
+	oldStdout := osStdout
+	oldExecutionContextFactory := executionContextFactory
+	oldOsExit := osExit
+	defer func() {
+		osStdout = oldStdout
+		executionContextFactory = oldExecutionContextFactory
+		osExit = oldOsExit
+		FileFlag = ""
+		PipelineFlag = ""
+	}()
+	reader, writer := io.Pipe()
+	osStdout = writer
+	go func() {
+		_, _ = ioutil.ReadAll(reader)
+	}()
+	exitCode := 0
+	osExit = func(code int) {
+		exitCode = code
+	}
+	executionContextFactory = func(options ...middleware.ExecutionContextOption) *middleware.ExecutionContext {
+		options = append(options,
+			middleware.WithParser(
+				parsing.NewParser(
+					parsing.WithFindByGlobImplementation(func(_ string) ([]string, error) {
+						return []string{"test1.pipe"}, nil
+					}),
+					parsing.WithReadFileImplementation(func(_ string) ([]byte, error) {
+						return []byte(`
+public:
+  test1:
+    arg: value
+  test2:
+    arg: value
+`), nil
+					}),
+					parsing.WithRecursivelyAddImportsImplementation(func(paths []string) ([]string, error) {
+						return paths, nil
+					}),
+				)),
+			middleware.WithExecutionFunction(func(run *pipeline.Run) {
+				exitCode := 2
+				run.ExitCode = &exitCode
+			}),
+		)
+		executionContext := middleware.NewExecutionContext(options...)
+		executionContext.Log.SetOutput(new(bytes.Buffer))
+		return executionContext
+	}
+
+	RunCmd(nil, []string{"test1.pipe", "test2"})
+
+	_ = writer.Close()
+	require.Equal(t, "test2", PipelineFlag)
+	require.Equal(t, 2, exitCode)
+}
@@ -0,0 +1,229 @@
+// Package schema provides the implementation of the schema command, generating a JSON Schema for pipeline files
+package schema
+
+import (
+	"encoding/json"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/middleware/stack"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"io"
+	"reflect"
+	"unicode"
+)
+
+// Schema is a JSON Schema document (or fragment)
+type Schema = map[string]interface{}
+
+const pipeDefinitionReference = "#/definitions/pipe"
+
+var referenceType = reflect.TypeOf(pipeline.Reference{})
+
+// Generate creates a JSON Schema for pipeline files from the argument shapes described by the middleware
+//
+// Schema fragments contributed by middleware take precedence over the ones derived from the argument shapes.
+func Generate(middlewareStack []middleware.Middleware) Schema {
+	return Schema{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "PipeDream pipeline file",
+		"description": "Pipes defined in a `.pipe` file",
+		"type":        "object",
+		"properties": Schema{
+			"version": Schema{
+				"description": "Version of the pipeline file syntax",
+				"type":        "string",
+			},
+			"default": Schema{
+				"description":          "File-level default settings",
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": Schema{
+					"command": Schema{
+						"description": "Identifier of the pipe selected by default",
+						"type":        "string",
+					},
+					"dir": Schema{
+						"description": "Default working directory",
+						"type":        "string",
+					},
+				},
+			},
+			"import": Schema{
+				"description": "Paths of further pipeline files whose pipes should be available",
+				"type":        "array",
+				"items":       Schema{"type": "string"},
+			},
+			"public": Schema{
+				"description":          "Pipes that can be selected for execution and invoked from other files",
+				"type":                 "object",
+				"additionalProperties": Schema{"$ref": pipeDefinitionReference},
+			},
+			"private": Schema{
+				"description":          "Pipes that can only be invoked from within the same file (unless no public pipe matches)",
+				"type":                 "object",
+				"additionalProperties": Schema{"$ref": pipeDefinitionReference},
+			},
+			"hooks": hooksSchema(),
+		},
+		"additionalProperties": false,
+		"definitions": Schema{
+			"pipe": pipeSchema(middlewareStack),
+		},
+	}
+}
+
+func hooksSchema() Schema {
+	hookList := func(description string) Schema {
+		return Schema{
+			"description": description,
+			"type":        "array",
+			"items":       referenceSchema(),
+		}
+	}
+	return Schema{
+		"description":          "Pipes executed around the pipe selected for execution",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": Schema{
+			"before":   hookList("Executed in sequence before the selected pipe, which is skipped if any of them fails"),
+			"after":    hookList("Always executed after the selected pipe"),
+			"onError":  hookList("Executed if the selected pipe failed, receiving the arguments `error`, `exitCode` and `failedPipe`"),
+			"onCancel": hookList("Executed if the execution was cancelled by the user"),
+		},
+	}
+}
+
+// pipeSchema describes the arguments of a pipe, which may contain arbitrary keys in addition to the middleware keys
+func pipeSchema(middlewareStack []middleware.Middleware) Schema {
+	properties := Schema{}
+	for _, middlewareItem := range middlewareStack {
+		if describer, ok := middlewareItem.(middleware.ArgumentsDescriber); ok {
+			for key, shapes := range describer.Arguments() {
+				properties[key] = shapesSchema(shapes)
+			}
+		}
+		if contributor, ok := middlewareItem.(middleware.SchemaContributor); ok {
+			for key, fragment := range contributor.ArgumentsSchema() {
+				properties[key] = fragment
+			}
+		}
+	}
+	return Schema{
+		"type":                 []string{"object", "null"},
+		"properties":           properties,
+		"additionalProperties": true,
+	}
+}
+
+func shapesSchema(shapes []interface{}) Schema {
+	if len(shapes) == 1 {
+		return valueSchema(reflect.ValueOf(shapes[0]))
+	}
+	alternatives := make([]interface{}, 0, len(shapes))
+	for _, shape := range shapes {
+		alternatives = append(alternatives, valueSchema(reflect.ValueOf(shape)))
+	}
+	return Schema{"oneOf": alternatives}
+}
+
+// referenceSchema describes a pipeline reference, either as a plain identifier or as a single identifier with inline arguments
+func referenceSchema() Schema {
+	return Schema{
+		"oneOf": []interface{}{
+			Schema{
+				"description": "Identifier of the pipe to invoke",
+				"type":        "string",
+			},
+			Schema{
+				"description":          "Identifier of the pipe to invoke, mapped to inline arguments (use `~` for anonymous pipes)",
+				"type":                 "object",
+				"minProperties":        1,
+				"maxProperties":        1,
+				"additionalProperties": Schema{"$ref": pipeDefinitionReference},
+			},
+		},
+	}
+}
+
+// valueSchema derives a schema from the type of a value, using non-zero values as defaults
+func valueSchema(value reflect.Value) Schema {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return typeSchema(value.Type())
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct || value.Type() == referenceType {
+		schema := typeSchema(value.Type())
+		if value.Kind() != reflect.Map && value.Kind() != reflect.Slice && !value.IsZero() {
+			schema["default"] = value.Interface()
+		}
+		return schema
+	}
+	properties := Schema{}
+	for index := 0; index < value.NumField(); index++ {
+		field := value.Type().Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		properties[propertyName(field.Name)] = valueSchema(value.Field(index))
+	}
+	return Schema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func typeSchema(valueType reflect.Type) Schema {
+	if valueType == referenceType {
+		return referenceSchema()
+	}
+	switch valueType.Kind() {
+	case reflect.Ptr:
+		return typeSchema(valueType.Elem())
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return Schema{
+			"type":  "array",
+			"items": typeSchema(valueType.Elem()),
+		}
+	case reflect.Map:
+		return Schema{
+			"type":                 "object",
+			"additionalProperties": typeSchema(valueType.Elem()),
+		}
+	case reflect.Struct:
+		return valueSchema(reflect.New(valueType).Elem())
+	}
+	// any value is accepted for interfaces
+	return Schema{}
+}
+
+// propertyName converts a field name to the lower camel case used in pipeline files, e.g. `TTL` to `ttl`
+func propertyName(fieldName string) string {
+	runes := []rune(fieldName)
+	for index := range runes {
+		// keep the last capital letter of an acronym that is followed by another word
+		if !unicode.IsUpper(runes[index]) || (index > 0 && index+1 < len(runes) && unicode.IsLower(runes[index+1])) {
+			break
+		}
+		runes[index] = unicode.ToLower(runes[index])
+	}
+	return string(runes)
+}
+
+// Cmd implements the schema command, writing the JSON Schema for the default middleware stack
+func Cmd(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(Generate(stack.SetUpMiddleware()))
+}
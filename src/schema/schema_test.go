@@ -0,0 +1,112 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/Layer9Berlin/pipedream/src/middleware"
+	"github.com/Layer9Berlin/pipedream/src/middleware/stack"
+	"github.com/Layer9Berlin/pipedream/src/pipeline"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGenerate_fileLayout(t *testing.T) {
+	schema := Generate(stack.SetUpMiddleware())
+	require.Equal(t, false, schema["additionalProperties"])
+	properties := schema["properties"].(Schema)
+	for _, key := range []string{"default", "hooks", "import", "private", "public", "version"} {
+		require.Contains(t, properties, key)
+	}
+	require.Equal(t, Schema{"$ref": "#/definitions/pipe"}, properties["public"].(Schema)["additionalProperties"])
+	require.Equal(t, Schema{"$ref": "#/definitions/pipe"}, properties["private"].(Schema)["additionalProperties"])
+	hookProperties := properties["hooks"].(Schema)["properties"].(Schema)
+	for _, key := range []string{"after", "before", "onCancel", "onError"} {
+		require.Equal(t, referenceSchema(), hookProperties[key].(Schema)["items"])
+	}
+}
+
+func TestGenerate_middlewareArguments(t *testing.T) {
+	middlewareStack := stack.SetUpMiddleware()
+	pipeDefinition := Generate(middlewareStack)["definitions"].(Schema)["pipe"].(Schema)
+	require.Equal(t, true, pipeDefinition["additionalProperties"])
+	properties := pipeDefinition["properties"].(Schema)
+	for _, middlewareItem := range middlewareStack {
+		require.Contains(t, properties, middlewareItem.String())
+	}
+	require.Contains(t, properties, "else")
+
+	retryProperties := properties["retry"].(Schema)["properties"].(Schema)
+	require.Equal(t, Schema{"type": "integer", "default": 3}, retryProperties["attempts"])
+	require.Equal(t, Schema{"type": "string"}, retryProperties["pattern"])
+	require.Contains(t, properties["cache"].(Schema)["properties"], "ttl")
+	require.Equal(t, Schema{
+		"type":  "array",
+		"items": referenceSchema(),
+	}, properties["pipe"])
+	require.Len(t, properties["each"].(Schema)["oneOf"], 2)
+	require.Equal(t, "string", properties["timeout"].(Schema)["type"])
+	require.Contains(t, properties["timeout"], "pattern")
+}
+
+func TestGenerate_schemaContributor(t *testing.T) {
+	properties := Generate([]middleware.Middleware{
+		testMiddleware{},
+	})["definitions"].(Schema)["pipe"].(Schema)["properties"].(Schema)
+	require.Equal(t, Schema{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": Schema{
+			"count":   Schema{"type": "integer", "default": 2},
+			"enabled": Schema{"type": "boolean"},
+			"items":   Schema{"type": "array", "items": Schema{"type": "number"}},
+			"values":  Schema{"type": "object", "additionalProperties": Schema{}},
+		},
+	}, properties["test"])
+	require.Equal(t, Schema{"type": "string", "enum": []string{"a", "b"}}, properties["contributed"])
+}
+
+func TestPropertyName(t *testing.T) {
+	require.Equal(t, "attempts", propertyName("Attempts"))
+	require.Equal(t, "continueOnError", propertyName("ContinueOnError"))
+	require.Equal(t, "ttl", propertyName("TTL"))
+	require.Equal(t, "urlPath", propertyName("URLPath"))
+}
+
+func TestCmd(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	require.Nil(t, Cmd(buffer))
+	result := make(map[string]interface{})
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &result))
+	require.Equal(t, "http://json-schema.org/draft-07/schema#", result["$schema"])
+}
+
+type testMiddleware struct{}
+
+type testMiddlewareArguments struct {
+	Count   int
+	Enabled bool
+	Items   []float64
+	Values  map[string]interface{}
+	hidden  string
+}
+
+func (testMiddleware) String() string {
+	return "test"
+}
+
+func (testMiddleware) Apply(run *pipeline.Run, next func(*pipeline.Run), _ *middleware.ExecutionContext) {
+	next(run)
+}
+
+func (testMiddleware) Arguments() map[string][]interface{} {
+	return map[string][]interface{}{
+		"contributed": {new(string)},
+		"test":        {&testMiddlewareArguments{Count: 2, hidden: "ignored"}},
+	}
+}
+
+func (testMiddleware) ArgumentsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"contributed": Schema{"type": "string", "enum": []string{"a", "b"}},
+	}
+}